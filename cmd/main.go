@@ -1,20 +1,37 @@
 // @title Subscription Service API
 // @version 1.0
-// @description This is the API documentation for the subscription service.
+// @description This is the API documentation for the subscription service. Data is isolated per tenant: requests act for the tenant of their token or API key, or, for admins bound to no tenant, the one named in the X-Tenant-ID header.
 // @host localhost:8080
 // @BasePath /
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT as "Bearer <token>", required when JWT_HS256_SECRET or JWT_JWKS_FILE is set
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key of a service caller, limited to the scopes it was issued with
 package main
 
 import (
+    "context"
     "fmt"
     "log"
+    "os"
+    "time"
 
+    "service/internal/billing"
     "service/internal/config"
     "service/internal/routes"
     "service/internal/database"
+    "service/internal/jobs"
+    "service/internal/reminders"
+    "service/internal/webhooks"
+    "service/internal/repository"
 	"service/internal/logger"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
     ginSwagger "github.com/swaggo/gin-swagger"
     swaggerFiles "github.com/swaggo/files"
      _ "service/docs"
@@ -27,14 +44,41 @@ func main() {
 	logger.Init()
 
 	db := database.InitDB(cfg.DBDsn)
+	systemDB := database.InitSystemDB(cfg.SystemDBDsn)
 	defer database.CloseDB()
-	_ = db 
+	checkRowLevelSecurity(db, systemDB)
+	repos := repository.NewPostgres(db, systemDB)
+	// Background jobs work across tenants.
+	systemRepos := repository.NewPostgres(systemDB, systemDB)
+
+	if cfg.ExchangeRatesFile != "" {
+		if err := loadExchangeRatesFile(repos.ExchangeRates, cfg.ExchangeRatesFile); err != nil {
+			log.Fatalf("[error] Loading exchange rates failed: %v", err)
+		}
+	}
+
+	if cfg.TrashRetention > 0 && cfg.TrashPurgeInterval > 0 {
+		go jobs.PurgeTrash(context.Background(), systemRepos, cfg.TrashRetention, cfg.TrashPurgeInterval)
+	}
+	go jobs.PurgeIdempotencyKeys(context.Background(), systemRepos.Idempotency, time.Hour)
+
+	if cfg.ReminderDays > 0 && cfg.ReminderInterval > 0 {
+		notifiers, err := reminderNotifiers(cfg)
+		if err != nil {
+			log.Fatalf("[error] Configuring reminders failed: %v", err)
+		}
+		scheduler := reminders.NewScheduler(systemRepos.Subscriptions, systemRepos.Prices, systemRepos.Notifications, notifiers, cfg.ReminderDays)
+		go jobs.SendReminders(context.Background(), scheduler, cfg.ReminderInterval)
+	}
+	if cfg.WebhookInterval > 0 {
+		go jobs.DeliverWebhooks(context.Background(), webhooks.NewDispatcher(systemRepos), cfg.WebhookInterval)
+	}
 
 	router := gin.Default()
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	router.Use(gin.Recovery())
-	routes.SetupRoutes(router) 
+	routes.SetupRoutes(router, repos, cfg)
 
 	addr := fmt.Sprintf(":%s", cfg.AppPort)
 	log.Printf("🚀 Starting server on %s", addr)
@@ -44,6 +88,69 @@ func main() {
 	}
 }
 
+// checkRowLevelSecurity refuses to start when the system connection is held
+// to the row-level security policies, which show it no rows, and warns when
+// requests are not held to them.
+func checkRowLevelSecurity(db, systemDB *gorm.DB) {
+	bypass, err := database.BypassesRLS(systemDB)
+	if err != nil {
+		log.Fatalf("[error] Checking the system database role failed: %v", err)
+	}
+	if !bypass {
+		log.Fatal("[error] The SYSTEM_DB_DSN role, or the DB_DSN role when it is not set, needs BYPASSRLS for background jobs and API key lookups")
+	}
+	if db == systemDB {
+		logger.Log.Warn("SYSTEM_DB_DSN is not set, requests bypass row-level security and tenants are only isolated by the application")
+		return
+	}
+	if bypass, err = database.BypassesRLS(db); err == nil && bypass {
+		logger.Log.Warn("The DB_DSN role bypasses row-level security, tenants are only isolated by the application")
+	}
+}
+
+func loadExchangeRatesFile(rates repository.ExchangeRateRepository, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	parsed, err := billing.ParseRatesCSV(f)
+	if err != nil {
+		return err
+	}
+	if err := rates.Upsert(context.Background(), parsed); err != nil {
+		return err
+	}
+	log.Printf("Loaded %d exchange rates from %s", len(parsed), path)
+	return nil
+}
+
+// reminderNotifiers builds the notifiers named in cfg.ReminderNotifiers.
+func reminderNotifiers(cfg *config.Config) ([]reminders.Notifier, error) {
+	var notifiers []reminders.Notifier
+	for _, name := range cfg.ReminderNotifiers {
+		switch name {
+		case "log":
+			notifiers = append(notifiers, reminders.LogNotifier{})
+		case "webhook":
+			if cfg.ReminderWebhookURL == "" {
+				return nil, fmt.Errorf("the webhook notifier needs REMINDER_WEBHOOK_URL")
+			}
+			notifiers = append(notifiers, reminders.NewWebhookNotifier(cfg.ReminderWebhookURL))
+		case "smtp":
+			n, err := reminders.NewSMTPNotifier(cfg.SMTPAddr, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom, cfg.SMTPTo)
+			if err != nil {
+				return nil, err
+			}
+			notifiers = append(notifiers, n)
+		default:
+			return nil, fmt.Errorf("unknown reminder notifier %q, use log, webhook or smtp", name)
+		}
+	}
+	return notifiers, nil
+}
+
 func LoggerMiddleware() gin.HandlerFunc {
     return func(c *gin.Context) {
         path := c.Request.URL.Path
@@ -0,0 +1,135 @@
+// Package apierror defines the errors handlers report and their RFC 7807
+// problem+json representation.
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+
+	"service/internal/validation"
+)
+
+// ContentType is the media type of problem responses.
+const ContentType = "application/problem+json"
+
+// TypePrefix prefixes the stable error code to form the problem type URI.
+const TypePrefix = "urn:subscription-service:problem:"
+
+// Stable error codes shared by several handlers.
+const (
+	CodeValidation  = "validation_failed"
+	CodeMalformed   = "malformed_request"
+	CodeNotFound    = "not_found"
+	CodeConflict    = "conflict"
+	CodeInternal    = "internal_error"
+	CodeNoRoute     = "route_not_found"
+	CodeRateMissing = "exchange_rate_missing"
+	CodeStale       = "precondition_failed"
+	CodeNoIfMatch   = "precondition_required"
+	CodeNoToken     = "unauthenticated"
+	CodeBadToken    = "invalid_token"
+	CodeBadKey      = "invalid_api_key"
+	CodeForbidden   = "forbidden"
+	CodeScope       = "insufficient_scope"
+)
+
+// Error is an error with the HTTP status and stable code it is reported with.
+// Cause is logged but never exposed to clients.
+type Error struct {
+	Status int
+	Code   string
+	Detail string
+	Fields validation.Errors
+	Cause  error
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Detail, e.Cause)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Detail)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// New returns an error reported with the given status, code and detail.
+func New(status int, code, detail string) *Error {
+	return &Error{Status: status, Code: code, Detail: detail}
+}
+
+// WithCause records the internal cause of the error.
+func (e *Error) WithCause(err error) *Error {
+	e.Cause = err
+	return e
+}
+
+func BadRequest(code, detail string) *Error {
+	return New(http.StatusBadRequest, code, detail)
+}
+
+func Unauthorized(code, detail string) *Error {
+	return New(http.StatusUnauthorized, code, detail)
+}
+
+func Forbidden(code, detail string) *Error {
+	return New(http.StatusForbidden, code, detail)
+}
+
+func NotFound(code, detail string) *Error {
+	return New(http.StatusNotFound, code, detail)
+}
+
+func Conflict(code, detail string) *Error {
+	return New(http.StatusConflict, code, detail)
+}
+
+func Unprocessable(code, detail string) *Error {
+	return New(http.StatusUnprocessableEntity, code, detail)
+}
+
+func PreconditionFailed(code, detail string) *Error {
+	return New(http.StatusPreconditionFailed, code, detail)
+}
+
+func PreconditionRequired(code, detail string) *Error {
+	return New(http.StatusPreconditionRequired, code, detail)
+}
+
+// Invalid reports every rejected field with the given status, usually 400
+// for query parameters and 422 for payloads.
+func Invalid(status int, errs validation.Errors) *Error {
+	return &Error{Status: status, Code: CodeValidation, Detail: "one or more fields are invalid", Fields: errs}
+}
+
+// Internal hides an unexpected error behind a generic 500.
+func Internal(cause error) *Error {
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Detail: "an unexpected error occurred", Cause: cause}
+}
+
+// Problem is an RFC 7807 problem details body.
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      string            `json:"code"`
+	RequestID string            `json:"request_id,omitempty"`
+	Errors    validation.Errors `json:"errors,omitempty"`
+}
+
+// Problem renders the error for the given request path and correlation ID.
+func (e *Error) Problem(instance, requestID string) Problem {
+	return Problem{
+		Type:      TypePrefix + e.Code,
+		Title:     http.StatusText(e.Status),
+		Status:    e.Status,
+		Detail:    e.Detail,
+		Instance:  instance,
+		Code:      e.Code,
+		RequestID: requestID,
+		Errors:    e.Fields,
+	}
+}
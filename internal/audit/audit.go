@@ -0,0 +1,135 @@
+// Package audit builds the entries of the subscription audit log.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"service/internal/auth"
+	"service/internal/middleware"
+	"service/internal/models"
+)
+
+// SystemActor is the actor of changes made by background jobs.
+const SystemActor = "system"
+
+// ignored are the fields left out of the changes: those recorded in the
+// columns of an entry, and the bookkeeping the action itself implies.
+var ignored = map[string]bool{"ID": true, "TenantID": true, "Version": true, "DeletedAt": true}
+
+// NewEntry records a change of a subscription from before to after, made by
+// the caller of ctx at now. before is nil for creations and restores, after
+// is nil for deletions and purges.
+func NewEntry(ctx context.Context, action models.AuditAction, before, after *models.Subscription, now time.Time) (*models.AuditEntry, error) {
+	changes, err := Diff(before, after)
+	if err != nil {
+		return nil, err
+	}
+	sub := after
+	if sub == nil {
+		sub = before
+	}
+	return &models.AuditEntry{
+		TenantID:       sub.TenantID,
+		SubscriptionID: sub.ID,
+		UserID:         sub.UserID,
+		Action:         action,
+		Actor:          Actor(ctx),
+		RequestID:      middleware.RequestIDFromContext(ctx),
+		Changes:        changes,
+		CreatedAt:      now.UTC(),
+	}, nil
+}
+
+// PriceChangeField is the change under which NewPriceEntry records the price
+// change that was scheduled or cancelled.
+const PriceChangeField = "PriceChange"
+
+// NewPriceEntry records that the caller of ctx scheduled a price change of
+// sub at now, or cancelled it when before is set instead of after.
+func NewPriceEntry(ctx context.Context, sub *models.Subscription, before, after *models.PriceChange, now time.Time) (*models.AuditEntry, error) {
+	entry, err := NewEntry(ctx, models.AuditUpdate, sub, sub, now)
+	if err != nil {
+		return nil, err
+	}
+	var change models.AuditChange
+	if before != nil {
+		if change.Old, err = json.Marshal(before); err != nil {
+			return nil, err
+		}
+	}
+	if after != nil {
+		if change.New, err = json.Marshal(after); err != nil {
+			return nil, err
+		}
+	}
+	entry.Changes[PriceChangeField] = change
+	return entry, nil
+}
+
+// Diff returns the fields that differ between before and after, either of
+// which may be nil. Fields that are null on the missing side are left out, so
+// a creation lists only the fields that were set.
+func Diff(before, after *models.Subscription) (models.AuditChanges, error) {
+	old, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := models.AuditChanges{}
+	for name, value := range old {
+		if ignored[name] {
+			continue
+		}
+		if next, ok := updated[name]; ok {
+			if !bytes.Equal(value, next) {
+				changes[name] = models.AuditChange{Old: value, New: next}
+			}
+		} else if !isNull(value) {
+			changes[name] = models.AuditChange{Old: value}
+		}
+	}
+	for name, value := range updated {
+		if _, ok := old[name]; !ok && !ignored[name] && !isNull(value) {
+			changes[name] = models.AuditChange{New: value}
+		}
+	}
+	return changes, nil
+}
+
+// Actor names the caller of ctx.
+func Actor(ctx context.Context) string {
+	principal, ok := auth.FromContext(ctx)
+	switch {
+	case !ok:
+		return "anonymous"
+	case principal.IsAPIKey():
+		return "api_key:" + principal.APIKeyID.String()
+	}
+	return principal.Subject
+}
+
+// fields encodes sub as a JSON object and returns its fields, or nothing for
+// a nil sub.
+func fields(sub *models.Subscription) (map[string]json.RawMessage, error) {
+	if sub == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(sub)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]json.RawMessage
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
+
+func isNull(value json.RawMessage) bool {
+	return bytes.Equal(value, []byte("null"))
+}
@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service/internal/auth"
+	"service/internal/models"
+)
+
+func TestDiff(t *testing.T) {
+	before := &models.Subscription{ID: uuid.New(), TenantID: uuid.New(), ServiceName: "Netflix", Price: 100, UserID: uuid.New()}
+	after := *before
+	after.Price = 150
+	after.Category = "streaming"
+	after.Version = before.Version + 1
+
+	changes, err := Diff(before, &after)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if len(changes) != 2 {
+		t.Errorf("changes %v, want Price and Category", changes)
+	}
+	if c := changes["Price"]; string(c.Old) != "100" || string(c.New) != "150" {
+		t.Errorf("Price change %s -> %s", c.Old, c.New)
+	}
+	if c := changes["Category"]; string(c.Old) != `""` || string(c.New) != `"streaming"` {
+		t.Errorf("Category change %s -> %s", c.Old, c.New)
+	}
+
+	created, _ := Diff(nil, before)
+	if _, ok := created["EndDate"]; ok {
+		t.Error("creation lists the unset EndDate")
+	}
+	for _, name := range []string{"ID", "TenantID", "Version", "DeletedAt"} {
+		if _, ok := created[name]; ok {
+			t.Errorf("creation lists %s", name)
+		}
+	}
+	if c := created["ServiceName"]; c.Old != nil || string(c.New) != `"Netflix"` {
+		t.Errorf("created ServiceName %s -> %s", c.Old, c.New)
+	}
+	deleted, _ := Diff(before, nil)
+	if c := deleted["Price"]; string(c.Old) != "100" || c.New != nil {
+		t.Errorf("deleted Price %s -> %s", c.Old, c.New)
+	}
+}
+
+func TestNewEntry(t *testing.T) {
+	sub := &models.Subscription{ID: uuid.New(), TenantID: uuid.New(), ServiceName: "Netflix", UserID: uuid.New()}
+	key := uuid.New()
+	ctx := auth.NewContext(context.Background(), &auth.Principal{Subject: "service", APIKeyID: key})
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+
+	entry, err := NewEntry(ctx, models.AuditDelete, sub, nil, now)
+	if err != nil {
+		t.Fatalf("NewEntry: %v", err)
+	}
+	if entry.SubscriptionID != sub.ID || entry.TenantID != sub.TenantID || entry.UserID != sub.UserID {
+		t.Errorf("entry %+v does not describe %+v", entry, sub)
+	}
+	if entry.Actor != "api_key:"+key.String() || entry.Action != models.AuditDelete || entry.CreatedAt.Location() != time.UTC {
+		t.Errorf("entry %+v", entry)
+	}
+	if got := Actor(context.Background()); got != "anonymous" {
+		t.Errorf("actor without credentials %q", got)
+	}
+	if got := Actor(auth.NewContext(context.Background(), &auth.Principal{Subject: "alice"})); got != "alice" {
+		t.Errorf("actor of a token %q", got)
+	}
+}
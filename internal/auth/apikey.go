@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// APIKeyHeader carries the API key of service callers.
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyPrefix starts every key, so leaked keys are easy to recognize.
+const apiKeyPrefix = "sk_"
+
+// NewAPIKey generates an API key. The key is shown to its owner once; only
+// the prefix, its public part, and the hash are stored.
+func NewAPIKey() (key, prefix, hash string, err error) {
+	id := make([]byte, 6)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+	prefix = apiKeyPrefix + hex.EncodeToString(id)
+	key = prefix + "." + base64.RawURLEncoding.EncodeToString(secret)
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// APIKeyPrefix returns the public part of a key, which names its stored
+// record.
+func APIKeyPrefix(key string) (string, bool) {
+	prefix, secret, ok := strings.Cut(key, ".")
+	if !ok || !strings.HasPrefix(prefix, apiKeyPrefix) || secret == "" {
+		return "", false
+	}
+	return prefix, true
+}
+
+// HashAPIKey returns the hash stored for a key. Keys are long random strings,
+// so a plain SHA-256 is enough to keep them from being recovered.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// MatchAPIKey reports whether key has the stored hash.
+func MatchAPIKey(key, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashAPIKey(key)), []byte(hash)) == 1
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAPIKeys(t *testing.T) {
+	key, prefix, hash, err := NewAPIKey()
+	if err != nil {
+		t.Fatalf("NewAPIKey: %v", err)
+	}
+	if !strings.HasPrefix(key, prefix+".") || !strings.HasPrefix(prefix, "sk_") || hash != HashAPIKey(key) {
+		t.Errorf("key %q, prefix %q, hash %q", key, prefix, hash)
+	}
+	if got, ok := APIKeyPrefix(key); !ok || got != prefix {
+		t.Errorf("APIKeyPrefix = %q, %v", got, ok)
+	}
+	if !MatchAPIKey(key, hash) || MatchAPIKey(key+"x", hash) || MatchAPIKey(key, HashAPIKey("other")) {
+		t.Error("MatchAPIKey does not compare the hash")
+	}
+
+	other, _, _, _ := NewAPIKey()
+	if other == key {
+		t.Error("two keys are equal")
+	}
+	for _, raw := range []string{"", "sk_abc", "sk_abc.", "pk_abc.secret", "secret"} {
+		if _, ok := APIKeyPrefix(raw); ok {
+			t.Errorf("APIKeyPrefix accepted %q", raw)
+		}
+	}
+}
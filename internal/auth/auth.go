@@ -0,0 +1,71 @@
+// Package auth verifies the JWTs callers authenticate with and carries the
+// resulting principal through request contexts.
+package auth
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"service/internal/models"
+)
+
+// Principal is an authenticated caller: a user with a token or a service with
+// an API key.
+type Principal struct {
+	// Subject is the sub claim of the token.
+	Subject string
+	// UserID is the user whose data the caller may access. It is uuid.Nil
+	// for admins whose token names no user.
+	UserID uuid.UUID
+	// Admin callers may access the data of every user.
+	Admin bool
+	// TenantID is the tenant the caller belongs to. It is uuid.Nil for
+	// callers not bound to a tenant, who may pick one if they are admins.
+	TenantID uuid.UUID
+	// APIKeyID is the key a service authenticated with, uuid.Nil for users.
+	APIKeyID uuid.UUID
+	// Scopes limit what an API key may do. Users are limited by their role
+	// only.
+	Scopes models.Scopes
+}
+
+// IsAPIKey reports whether the caller authenticated with an API key.
+func (p *Principal) IsAPIKey() bool {
+	return p.APIKeyID != uuid.Nil
+}
+
+// Allows reports whether the caller may perform operations of scope.
+func (p *Principal) Allows(scope models.Scope) bool {
+	return !p.IsAPIKey() || p.Scopes.Contains(scope)
+}
+
+type principalKey struct{}
+
+// NewContext returns a copy of ctx carrying p.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal carried by ctx.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// Restricted returns the only user whose data the caller in ctx may access,
+// and false when the caller is unrestricted: an admin, or no principal at all
+// because authentication is disabled.
+func Restricted(ctx context.Context) (uuid.UUID, bool) {
+	p, ok := FromContext(ctx)
+	if !ok || p.Admin {
+		return uuid.Nil, false
+	}
+	return p.UserID, true
+}
+
+// CanAccess reports whether the caller in ctx may access the data of userID.
+func CanAccess(ctx context.Context, userID uuid.UUID) bool {
+	own, restricted := Restricted(ctx)
+	return !restricted || own == userID
+}
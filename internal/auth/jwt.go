@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// leeway is the clock skew tolerated when checking exp, nbf and iat.
+const leeway = 30 * time.Second
+
+// Config configures a Verifier. At least one of HS256Secret and RSAKeys must
+// be set.
+type Config struct {
+	// HS256Secret verifies HS256 tokens.
+	HS256Secret []byte
+	// RSAKeys verify RS256 tokens by key ID. A token without a kid header is
+	// accepted when there is exactly one key.
+	RSAKeys map[string]*rsa.PublicKey
+	// Issuer and Audience, when set, must match the iss and aud claims.
+	Issuer   string
+	Audience string
+	// UserClaim names the claim holding the caller's user ID, "sub" by
+	// default.
+	UserClaim string
+	// RolesClaim names the claim holding the caller's roles as a string or
+	// an array of strings, "roles" by default.
+	RolesClaim string
+	// AdminRole is the role that grants access to every user's data,
+	// "admin" by default.
+	AdminRole string
+	// TenantClaim names the claim holding the ID of the caller's tenant,
+	// "tenant_id" by default. Tokens without it are not bound to a tenant.
+	TenantClaim string
+}
+
+// Verifier checks bearer tokens and turns them into principals.
+type Verifier struct {
+	cfg    Config
+	parser *jwt.Parser
+}
+
+func NewVerifier(cfg Config) (*Verifier, error) {
+	if len(cfg.HS256Secret) == 0 && len(cfg.RSAKeys) == 0 {
+		return nil, errors.New("no HS256 secret or RSA keys to verify tokens with")
+	}
+	if cfg.UserClaim == "" {
+		cfg.UserClaim = "sub"
+	}
+	if cfg.RolesClaim == "" {
+		cfg.RolesClaim = "roles"
+	}
+	if cfg.AdminRole == "" {
+		cfg.AdminRole = "admin"
+	}
+	if cfg.TenantClaim == "" {
+		cfg.TenantClaim = "tenant_id"
+	}
+
+	var methods []string
+	if len(cfg.HS256Secret) > 0 {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if len(cfg.RSAKeys) > 0 {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(leeway),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	return &Verifier{cfg: cfg, parser: jwt.NewParser(opts...)}, nil
+}
+
+// Verify checks the signature and claims of a token and returns its
+// principal.
+func (v *Verifier) Verify(raw string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	if _, err := v.parser.ParseWithClaims(raw, claims, v.key); err != nil {
+		return nil, err
+	}
+
+	p := &Principal{Admin: v.hasRole(claims[v.cfg.RolesClaim], v.cfg.AdminRole)}
+	p.Subject, _ = claims["sub"].(string)
+	user, _ := claims[v.cfg.UserClaim].(string)
+	id, err := uuid.Parse(user)
+	switch {
+	case err == nil:
+		p.UserID = id
+	case !p.Admin:
+		return nil, fmt.Errorf("claim %s does not hold a user ID", v.cfg.UserClaim)
+	}
+	if claim, ok := claims[v.cfg.TenantClaim]; ok {
+		raw, _ := claim.(string)
+		if p.TenantID, err = uuid.Parse(raw); err != nil {
+			return nil, fmt.Errorf("claim %s does not hold a tenant ID", v.cfg.TenantClaim)
+		}
+	}
+	return p, nil
+}
+
+func (v *Verifier) key(token *jwt.Token) (any, error) {
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		return v.cfg.HS256Secret, nil
+	case jwt.SigningMethodRS256.Alg():
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" && len(v.cfg.RSAKeys) == 1 {
+			for _, key := range v.cfg.RSAKeys {
+				return key, nil
+			}
+		}
+		if key, ok := v.cfg.RSAKeys[kid]; ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("unknown key ID %q", kid)
+	}
+	return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+}
+
+func (v *Verifier) hasRole(claim any, role string) bool {
+	switch roles := claim.(type) {
+	case string:
+		return roles == role
+	case []any:
+		for _, r := range roles {
+			if r == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// LoadJWKS reads the RSA signing keys of a JSON Web Key Set file by key ID.
+// Keys of other types or meant for encryption are skipped.
+func LoadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			Alg string `json:"alg"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse JWKS %s: %w", path, err)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for i, k := range set.Keys {
+		if k.Kty != "RSA" || k.Use == "enc" || (k.Alg != "" && k.Alg != "RS256") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("JWKS %s: key %d has an invalid modulus or exponent", path, i)
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS %s has no RSA signing keys", path)
+	}
+	return keys, nil
+}
@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+func sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	raw, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return raw
+}
+
+func TestVerifyHS256(t *testing.T) {
+	v, err := NewVerifier(Config{HS256Secret: secret, Issuer: "issuer"})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	user := uuid.New()
+	exp := time.Now().Add(time.Hour).Unix()
+
+	p, err := v.Verify(sign(t, jwt.MapClaims{"sub": user.String(), "iss": "issuer", "exp": exp}))
+	if err != nil || p.UserID != user || p.Subject != user.String() || p.Admin {
+		t.Errorf("user token: %+v, %v", p, err)
+	}
+	p, err = v.Verify(sign(t, jwt.MapClaims{"sub": "ops", "iss": "issuer", "exp": exp, "roles": []string{"reader", "admin"}}))
+	if err != nil || !p.Admin || p.UserID != uuid.Nil {
+		t.Errorf("admin token: %+v, %v", p, err)
+	}
+
+	other, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": user.String(), "iss": "issuer", "exp": exp}).SignedString([]byte("another secret"))
+	none, _ := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"sub": user.String(), "iss": "issuer", "exp": exp}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	for name, raw := range map[string]string{
+		"expired":      sign(t, jwt.MapClaims{"sub": user.String(), "iss": "issuer", "exp": time.Now().Add(-time.Hour).Unix()}),
+		"without exp":  sign(t, jwt.MapClaims{"sub": user.String(), "iss": "issuer"}),
+		"wrong issuer": sign(t, jwt.MapClaims{"sub": user.String(), "iss": "other", "exp": exp}),
+		"no user":      sign(t, jwt.MapClaims{"sub": "ops", "iss": "issuer", "exp": exp}),
+		"wrong key":    other,
+		"unsigned":     none,
+		"not a token":  "abc",
+	} {
+		if p, err := v.Verify(raw); err == nil {
+			t.Errorf("%s accepted as %+v", name, p)
+		}
+	}
+}
+
+func TestVerifyCustomClaims(t *testing.T) {
+	v, _ := NewVerifier(Config{HS256Secret: secret, UserClaim: "uid", RolesClaim: "role", AdminRole: "root"})
+	user := uuid.New()
+	p, err := v.Verify(sign(t, jwt.MapClaims{"sub": "alice", "uid": user.String(), "role": "root", "exp": time.Now().Add(time.Hour).Unix()}))
+	if err != nil || p.UserID != user || !p.Admin || p.Subject != "alice" {
+		t.Errorf("principal %+v, %v", p, err)
+	}
+}
+
+func TestVerifyTenantClaim(t *testing.T) {
+	v, _ := NewVerifier(Config{HS256Secret: secret})
+	user, tenant := uuid.New(), uuid.New()
+	exp := time.Now().Add(time.Hour).Unix()
+	p, err := v.Verify(sign(t, jwt.MapClaims{"sub": user.String(), "tenant_id": tenant.String(), "exp": exp}))
+	if err != nil || p.TenantID != tenant {
+		t.Errorf("bound token: %+v, %v", p, err)
+	}
+	p, err = v.Verify(sign(t, jwt.MapClaims{"sub": user.String(), "exp": exp}))
+	if err != nil || p.TenantID != uuid.Nil {
+		t.Errorf("unbound token: %+v, %v", p, err)
+	}
+	if p, err := v.Verify(sign(t, jwt.MapClaims{"sub": user.String(), "tenant_id": "payments", "exp": exp})); err == nil {
+		t.Errorf("malformed tenant accepted as %+v", p)
+	}
+}
+
+func TestVerifyRS256WithJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "k1", "use": "sig", "alg": "RS256",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())},
+		{"kty": "EC", "kid": "k2"},
+	}})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	os.WriteFile(path, jwks, 0o600)
+
+	keys, err := LoadJWKS(path)
+	if err != nil || len(keys) != 1 || keys["k1"].N.Cmp(key.N) != 0 {
+		t.Fatalf("LoadJWKS: %v, %v", keys, err)
+	}
+	v, _ := NewVerifier(Config{RSAKeys: keys})
+
+	user := uuid.New()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": user.String(), "exp": time.Now().Add(time.Hour).Unix()})
+	token.Header["kid"] = "k1"
+	raw, _ := token.SignedString(key)
+	if p, err := v.Verify(raw); err != nil || p.UserID != user {
+		t.Errorf("RS256 token: %+v, %v", p, err)
+	}
+	if _, err := v.Verify(sign(t, jwt.MapClaims{"sub": user.String(), "exp": time.Now().Add(time.Hour).Unix()})); err == nil {
+		t.Error("HS256 token accepted by an RS256-only verifier")
+	}
+}
+
+func TestRestricted(t *testing.T) {
+	user := uuid.New()
+	for name, tt := range map[string]struct {
+		ctx        context.Context
+		restricted bool
+	}{
+		"no principal": {context.Background(), false},
+		"admin":        {NewContext(context.Background(), &Principal{Admin: true}), false},
+		"user":         {NewContext(context.Background(), &Principal{UserID: user}), true},
+	} {
+		own, restricted := Restricted(tt.ctx)
+		if restricted != tt.restricted || (restricted && own != user) {
+			t.Errorf("%s: Restricted = %s, %v", name, own, restricted)
+		}
+		if !CanAccess(tt.ctx, user) || CanAccess(tt.ctx, uuid.New()) == tt.restricted {
+			t.Errorf("%s: CanAccess is wrong", name)
+		}
+	}
+}
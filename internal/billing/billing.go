@@ -0,0 +1,226 @@
+package billing
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service/internal/models"
+)
+
+// MonthLayout is the YYYY-MM layout accepted by the summary query parameters.
+const MonthLayout = "2006-01"
+
+// Window is an inclusive range of calendar months.
+type Window struct {
+	From time.Time
+	To   time.Time
+}
+
+// Item is the cost of a single subscription inside a window. Price and
+// MonthlyCost are in the subscription's currency and effective in the last
+// month it is billed in the window, Cost in the summary currency.
+type Item struct {
+	SubscriptionID  uuid.UUID              `json:"subscription_id"`
+	ServiceName     string                 `json:"service_name"`
+	UserID          uuid.UUID              `json:"user_id"`
+	Price           int                    `json:"price"`
+	Currency        string                 `json:"currency"`
+	BillingInterval models.BillingInterval `json:"billing_interval"`
+	IntervalCount   int                    `json:"interval_count"`
+	MonthlyCost     float64                `json:"monthly_cost"`
+	BilledMonths    int                    `json:"billed_months"`
+	Cost            float64                `json:"cost"`
+}
+
+// Summary is the total spend over a window with a per-subscription breakdown.
+type Summary struct {
+	StartDate     string  `json:"start_date"`
+	EndDate       string  `json:"end_date"`
+	Currency      string  `json:"currency"`
+	Total         float64 `json:"total"`
+	Subscriptions []Item  `json:"subscriptions"`
+}
+
+// MonthStart truncates t to the first day of its month in UTC.
+func MonthStart(t time.Time) time.Time {
+	y, m, _ := t.UTC().Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// BillingDay returns the k-th billing day of sub, counting from the first day
+// of its start month as the 0th.
+func BillingDay(sub models.Subscription, k int) time.Time {
+	start := MonthStart(sub.StartDate.Time)
+	count := sub.IntervalCount
+	if count < 1 {
+		count = 1
+	}
+	switch sub.BillingInterval {
+	case models.IntervalWeek:
+		return start.AddDate(0, 0, 7*count*k)
+	case models.IntervalQuarter:
+		return start.AddDate(0, 3*count*k, 0)
+	case models.IntervalYear:
+		return start.AddDate(count*k, 0, 0)
+	}
+	return start.AddDate(0, count*k, 0)
+}
+
+// ParseMonth parses a YYYY-MM string into the first day of that month.
+func ParseMonth(s string) (time.Time, error) {
+	t, err := time.Parse(MonthLayout, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return MonthStart(t), nil
+}
+
+// MonthsBetween counts the calendar months from..to, both inclusive.
+// It returns 0 when to is before from.
+func MonthsBetween(from, to time.Time) int {
+	from, to = MonthStart(from), MonthStart(to)
+	if to.Before(from) {
+		return 0
+	}
+	return (to.Year()-from.Year())*12 + int(to.Month()-from.Month()) + 1
+}
+
+// Round rounds an amount to two decimal places.
+func Round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// MonthlyCost normalizes the subscription price to a monthly amount according
+// to its billing interval. One-off subscriptions are charged in full in their
+// start month, so their monthly cost is the whole price.
+func MonthlyCost(sub models.Subscription) float64 {
+	return monthlyCost(sub, sub.Price)
+}
+
+// monthlyCost normalizes amount, a price of sub, to a monthly amount.
+func monthlyCost(sub models.Subscription, amount int) float64 {
+	count := sub.IntervalCount
+	if count < 1 {
+		count = 1
+	}
+	price := float64(amount)
+
+	switch sub.BillingInterval {
+	case models.IntervalWeek:
+		return price * 52 / 12 / float64(count)
+	case models.IntervalQuarter:
+		return price / float64(3*count)
+	case models.IntervalYear:
+		return price / float64(12*count)
+	case models.IntervalOnce:
+		return price
+	default:
+		return price / float64(count)
+	}
+}
+
+// ActiveMonths returns the first and last month the subscription is active
+// inside the window. An open-ended subscription runs to the window end and a
+// one-off subscription is only active in its start month.
+func ActiveMonths(sub models.Subscription, w Window) (time.Time, time.Time, bool) {
+	first := sub.StartDate.Time
+	last := w.To
+	if sub.BillingInterval == models.IntervalOnce {
+		last = first
+	} else if sub.EndDate != nil && sub.EndDate.Before(last) {
+		last = sub.EndDate.Time
+	}
+	if first.Before(w.From) {
+		first = w.From
+	}
+	if last.After(w.To) {
+		last = w.To
+	}
+	if last.Before(first) {
+		return time.Time{}, time.Time{}, false
+	}
+	return first, last, true
+}
+
+// BilledMonths counts the months the subscription is billed inside the window.
+func BilledMonths(sub models.Subscription, w Window) int {
+	first, last, ok := ActiveMonths(sub, w)
+	if !ok {
+		return 0
+	}
+	return MonthsBetween(first, last)
+}
+
+// Cost converts the normalized monthly cost of sub, at the price effective in
+// each month it is active in the window, to currency and calls fn with each
+// month's amount.
+func Cost(sub models.Subscription, w Window, prices *Prices, rates *Rates, currency string, fn func(month time.Time, amount float64)) error {
+	first, last, ok := ActiveMonths(sub, w)
+	if !ok {
+		return nil
+	}
+	for month := first; !month.After(last); month = month.AddDate(0, 1, 0) {
+		monthly := monthlyCost(sub, prices.At(sub, month))
+		amount, err := rates.Convert(monthly, subscriptionCurrency(sub), currency, month)
+		if err != nil {
+			return err
+		}
+		fn(month, amount)
+	}
+	return nil
+}
+
+// Summarize computes the total spend of subs over the window in currency,
+// counting every month each subscription is active in it at its normalized
+// monthly cost, at the price and converted with the rate effective in that
+// month.
+func Summarize(subs []models.Subscription, w Window, prices *Prices, rates *Rates, currency string) (Summary, error) {
+	summary := Summary{
+		StartDate:     w.From.Format(MonthLayout),
+		EndDate:       w.To.Format(MonthLayout),
+		Currency:      currency,
+		Subscriptions: []Item{},
+	}
+
+	for _, sub := range subs {
+		months := 0
+		cost := 0.0
+		var last time.Time
+		err := Cost(sub, w, prices, rates, currency, func(month time.Time, amount float64) {
+			months++
+			cost += amount
+			last = month
+		})
+		if err != nil {
+			return Summary{}, err
+		}
+		if months == 0 {
+			continue
+		}
+		summary.Total += cost
+		price := prices.At(sub, last)
+		summary.Subscriptions = append(summary.Subscriptions, Item{
+			SubscriptionID:  sub.ID,
+			ServiceName:     sub.ServiceName,
+			UserID:          sub.UserID,
+			Price:           price,
+			Currency:        subscriptionCurrency(sub),
+			BillingInterval: sub.BillingInterval,
+			IntervalCount:   sub.IntervalCount,
+			MonthlyCost:     Round(monthlyCost(sub, price)),
+			BilledMonths:    months,
+			Cost:            Round(cost),
+		})
+	}
+	summary.Total = Round(summary.Total)
+	return summary, nil
+}
+
+func subscriptionCurrency(sub models.Subscription) string {
+	if sub.Currency == "" {
+		return models.DefaultCurrency
+	}
+	return sub.Currency
+}
@@ -0,0 +1,98 @@
+package billing
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service/internal/models"
+)
+
+// BreakdownEntry is the spend of one service (and optionally one user) in a month.
+type BreakdownEntry struct {
+	ServiceName string     `json:"service_name"`
+	UserID      *uuid.UUID `json:"user_id,omitempty"`
+	Total       float64    `json:"total"`
+}
+
+// MonthPoint is a single point of the breakdown time series.
+type MonthPoint struct {
+	Month    string           `json:"month"`
+	Total    float64          `json:"total"`
+	Services []BreakdownEntry `json:"services"`
+}
+
+// Breakdown is the spend over a window grouped by calendar month and service.
+type Breakdown struct {
+	StartDate string       `json:"start_date"`
+	EndDate   string       `json:"end_date"`
+	Currency  string       `json:"currency"`
+	Total     float64      `json:"total"`
+	Series    []MonthPoint `json:"series"`
+}
+
+type breakdownKey struct {
+	service string
+	user    uuid.UUID
+}
+
+// BreakdownByMonth groups the spend of subs over the window in currency by
+// month and service name, and additionally by user when byUser is set. Every
+// month of the window is present in the series, even when nothing was billed
+// in it.
+func BreakdownByMonth(subs []models.Subscription, w Window, prices *Prices, rates *Rates, currency string, byUser bool) (Breakdown, error) {
+	breakdown := Breakdown{
+		StartDate: w.From.Format(MonthLayout),
+		EndDate:   w.To.Format(MonthLayout),
+		Currency:  currency,
+		Series:    []MonthPoint{},
+	}
+
+	months := MonthsBetween(w.From, w.To)
+	totals := make([]map[breakdownKey]float64, months)
+	for i := range totals {
+		totals[i] = map[breakdownKey]float64{}
+	}
+
+	for _, sub := range subs {
+		key := breakdownKey{service: sub.ServiceName}
+		if byUser {
+			key.user = sub.UserID
+		}
+		err := Cost(sub, w, prices, rates, currency, func(month time.Time, amount float64) {
+			totals[MonthsBetween(w.From, month)-1][key] += amount
+		})
+		if err != nil {
+			return Breakdown{}, err
+		}
+	}
+
+	for i, byKey := range totals {
+		point := MonthPoint{
+			Month:    w.From.AddDate(0, i, 0).Format(MonthLayout),
+			Services: make([]BreakdownEntry, 0, len(byKey)),
+		}
+		for key, total := range byKey {
+			entry := BreakdownEntry{ServiceName: key.service, Total: Round(total)}
+			if byUser {
+				user := key.user
+				entry.UserID = &user
+			}
+			point.Services = append(point.Services, entry)
+			point.Total += total
+		}
+		sort.Slice(point.Services, func(a, b int) bool {
+			sa, sb := point.Services[a], point.Services[b]
+			if sa.ServiceName != sb.ServiceName {
+				return sa.ServiceName < sb.ServiceName
+			}
+			return sa.UserID != nil && sb.UserID != nil && sa.UserID.String() < sb.UserID.String()
+		})
+		breakdown.Total += point.Total
+		point.Total = Round(point.Total)
+		breakdown.Series = append(breakdown.Series, point)
+	}
+	breakdown.Total = Round(breakdown.Total)
+	return breakdown, nil
+}
@@ -0,0 +1,77 @@
+package billing
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"service/internal/models"
+)
+
+// BudgetMonth is the spend counted against a budget in one month.
+type BudgetMonth struct {
+	Month      string  `json:"month"`
+	Spend      float64 `json:"spend"`
+	Remaining  float64 `json:"remaining"`
+	OverBudget bool    `json:"over_budget"`
+}
+
+// BudgetStatus compares the spend of the subscriptions a budget covers with
+// its monthly limit.
+type BudgetStatus struct {
+	BudgetID   uuid.UUID     `json:"budget_id"`
+	Category   string        `json:"category,omitempty"`
+	Currency   string        `json:"currency"`
+	Limit      int           `json:"limit"`
+	OverBudget bool          `json:"over_budget"`
+	Months     []BudgetMonth `json:"months"`
+}
+
+// BudgetCovers reports whether sub counts against the budget: it belongs to
+// the budget's user and, for a category budget, to its category.
+func BudgetCovers(budget models.Budget, sub models.Subscription) bool {
+	return sub.UserID == budget.UserID && (budget.Category == "" || budget.Category == sub.Category)
+}
+
+// CheckBudget computes the spend of the subscriptions the budget covers in
+// every month of the window, the way Summarize computes it for a one-month
+// window, in the budget's currency.
+func CheckBudget(budget models.Budget, subs []models.Subscription, w Window, prices *Prices, rates *Rates) (BudgetStatus, error) {
+	status := BudgetStatus{
+		BudgetID: budget.ID,
+		Category: budget.Category,
+		Currency: budget.Currency,
+		Limit:    budget.Amount,
+		Months:   []BudgetMonth{},
+	}
+
+	var covered []models.Subscription
+	for _, sub := range subs {
+		if BudgetCovers(budget, sub) {
+			covered = append(covered, sub)
+		}
+	}
+
+	for month := MonthStart(w.From); !month.After(w.To); month = month.AddDate(0, 1, 0) {
+		summary, err := Summarize(covered, Window{From: month, To: month}, prices, rates, budget.Currency)
+		if err != nil {
+			return BudgetStatus{}, err
+		}
+		over := summary.Total > float64(budget.Amount)
+		status.OverBudget = status.OverBudget || over
+		status.Months = append(status.Months, BudgetMonth{
+			Month:      month.Format(MonthLayout),
+			Spend:      summary.Total,
+			Remaining:  Round(float64(budget.Amount) - summary.Total),
+			OverBudget: over,
+		})
+	}
+	return status, nil
+}
+
+// BudgetWindow is the current and the next month, the months budgets are
+// checked for.
+func BudgetWindow(now time.Time) Window {
+	month := MonthStart(now)
+	return Window{From: month, To: month.AddDate(0, 1, 0)}
+}
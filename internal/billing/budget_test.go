@@ -0,0 +1,61 @@
+package billing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service/internal/models"
+)
+
+func TestCheckBudget(t *testing.T) {
+	user := uuid.New()
+	july := models.NewMonth(time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC))
+	august := models.NewMonth(time.Date(2025, time.August, 1, 0, 0, 0, 0, time.UTC))
+	subs := []models.Subscription{
+		{UserID: user, Category: "streaming", Price: 600, Currency: "RUB", BillingInterval: models.IntervalMonth, IntervalCount: 1, StartDate: july},
+		{UserID: user, Category: "streaming", Price: 500, Currency: "RUB", BillingInterval: models.IntervalMonth, IntervalCount: 1, StartDate: august},
+		{UserID: user, Category: "music", Price: 300, Currency: "RUB", BillingInterval: models.IntervalMonth, IntervalCount: 1, StartDate: july},
+		{UserID: uuid.New(), Category: "streaming", Price: 9000, Currency: "RUB", BillingInterval: models.IntervalMonth, IntervalCount: 1, StartDate: july},
+	}
+	budget := models.Budget{ID: uuid.New(), UserID: user, Category: "streaming", Amount: 1000, Currency: "RUB"}
+
+	status, err := CheckBudget(budget, subs, BudgetWindow(time.Date(2025, time.July, 20, 0, 0, 0, 0, time.UTC)), nil, NewRates(nil))
+	if err != nil {
+		t.Fatalf("CheckBudget: %v", err)
+	}
+	if !status.OverBudget || status.Limit != 1000 || len(status.Months) != 2 {
+		t.Fatalf("status %+v", status)
+	}
+	want := []BudgetMonth{
+		{Month: "2025-07", Spend: 600, Remaining: 400},
+		{Month: "2025-08", Spend: 1100, Remaining: -100, OverBudget: true},
+	}
+	for i, month := range status.Months {
+		if month != want[i] {
+			t.Errorf("month %d is %+v, want %+v", i, month, want[i])
+		}
+	}
+
+	budget.Category = ""
+	budget.Currency = "USD"
+	if _, err := CheckBudget(budget, subs, BudgetWindow(july.Time), nil, NewRates(nil)); err == nil {
+		t.Error("a budget in a currency without rates was checked")
+	}
+}
+
+func TestBudgetCovers(t *testing.T) {
+	user := uuid.New()
+	sub := models.Subscription{UserID: user, Category: "streaming"}
+	for budget, want := range map[models.Budget]bool{
+		{UserID: user}:                        true,
+		{UserID: user, Category: "streaming"}: true,
+		{UserID: user, Category: "music"}:     false,
+		{UserID: uuid.New()}:                  false,
+	} {
+		if got := BudgetCovers(budget, sub); got != want {
+			t.Errorf("BudgetCovers(%+v) = %v, want %v", budget, got, want)
+		}
+	}
+}
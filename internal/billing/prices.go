@@ -0,0 +1,56 @@
+package billing
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service/internal/models"
+)
+
+// Prices is the price timeline of a set of subscriptions, built from their
+// price changes. A nil *Prices has no changes, so every subscription is
+// billed at its own Price.
+type Prices struct {
+	changes map[uuid.UUID][]models.PriceChange
+}
+
+// NewPrices indexes price changes by subscription and effective month.
+func NewPrices(changes []models.PriceChange) *Prices {
+	p := &Prices{changes: map[uuid.UUID][]models.PriceChange{}}
+	for _, c := range changes {
+		p.changes[c.SubscriptionID] = append(p.changes[c.SubscriptionID], c)
+	}
+	for _, list := range p.changes {
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].EffectiveFrom.Before(list[j].EffectiveFrom.Time)
+		})
+	}
+	return p
+}
+
+// At returns the price of sub in month: that of its latest change effective
+// in or before the month, or sub.Price before its first change.
+func (p *Prices) At(sub models.Subscription, month time.Time) int {
+	price := sub.Price
+	if p == nil {
+		return price
+	}
+	month = MonthStart(month)
+	for _, c := range p.changes[sub.ID] {
+		if c.EffectiveFrom.After(month) {
+			break
+		}
+		price = c.Price
+	}
+	return price
+}
+
+// Changes returns the price changes of sub ordered by effective month.
+func (p *Prices) Changes(sub models.Subscription) []models.PriceChange {
+	if p == nil {
+		return nil
+	}
+	return p.changes[sub.ID]
+}
@@ -0,0 +1,83 @@
+package billing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service/internal/models"
+)
+
+func month(y int, m time.Month) time.Time {
+	return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPricesAt(t *testing.T) {
+	sub := models.Subscription{ID: uuid.New(), Price: 100}
+	prices := NewPrices([]models.PriceChange{
+		{SubscriptionID: sub.ID, Price: 300, EffectiveFrom: models.NewMonth(month(2026, time.June))},
+		{SubscriptionID: sub.ID, Price: 200, EffectiveFrom: models.NewMonth(month(2026, time.March))},
+		{SubscriptionID: uuid.New(), Price: 999, EffectiveFrom: models.NewMonth(month(2026, time.February))},
+	})
+
+	for at, want := range map[time.Time]int{
+		month(2026, time.January):                            100,
+		month(2026, time.February):                           100,
+		month(2026, time.March):                              200,
+		time.Date(2026, time.May, 31, 23, 0, 0, 0, time.UTC): 200,
+		month(2026, time.June):                               300,
+		month(2027, time.January):                            300,
+	} {
+		if got := prices.At(sub, at); got != want {
+			t.Errorf("price in %s is %d, want %d", at.Format(MonthLayout), got, want)
+		}
+	}
+	var none *Prices
+	if got := none.At(sub, month(2026, time.June)); got != 100 {
+		t.Errorf("nil prices give %d, want the subscription's price", got)
+	}
+}
+
+func TestSummarizeAtEffectivePrices(t *testing.T) {
+	sub := models.Subscription{
+		ID: uuid.New(), ServiceName: "Netflix", Price: 100, Currency: "RUB",
+		BillingInterval: models.IntervalMonth, IntervalCount: 1,
+		StartDate: models.NewMonth(month(2026, time.January)),
+	}
+	prices := NewPrices([]models.PriceChange{
+		{SubscriptionID: sub.ID, Price: 250, EffectiveFrom: models.NewMonth(month(2026, time.March))},
+	})
+	w := Window{From: month(2026, time.January), To: month(2026, time.April)}
+
+	var costs []float64
+	if err := Cost(sub, w, prices, NewRates(nil), "RUB", func(_ time.Time, amount float64) {
+		costs = append(costs, amount)
+	}); err != nil {
+		t.Fatalf("Cost: %v", err)
+	}
+	want := []float64{100, 100, 250, 250}
+	if len(costs) != len(want) {
+		t.Fatalf("costs %v, want %v", costs, want)
+	}
+	for i := range want {
+		if costs[i] != want[i] {
+			t.Errorf("costs %v, want %v", costs, want)
+			break
+		}
+	}
+
+	summary, err := Summarize([]models.Subscription{sub}, w, prices, NewRates(nil), "RUB")
+	if err != nil {
+		t.Fatalf("Summarize: %v", err)
+	}
+	item := summary.Subscriptions[0]
+	if summary.Total != 700 || item.Price != 250 || item.MonthlyCost != 250 || item.BilledMonths != 4 {
+		t.Errorf("summary %+v", summary)
+	}
+
+	past, _ := Summarize([]models.Subscription{sub}, Window{From: month(2026, time.January), To: month(2026, time.February)}, prices, NewRates(nil), "RUB")
+	if past.Total != 200 || past.Subscriptions[0].Price != 100 {
+		t.Errorf("summary before the change %+v", past)
+	}
+}
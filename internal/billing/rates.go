@@ -0,0 +1,173 @@
+package billing
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"service/internal/models"
+)
+
+// ErrNoRate is returned when no exchange rate can convert between two currencies.
+var ErrNoRate = errors.New("no exchange rate")
+
+type currencyPair struct {
+	base  string
+	quote string
+}
+
+type ratePoint struct {
+	effective time.Time
+	rate      float64
+}
+
+// Rates converts amounts between currencies using effective-dated exchange rates.
+type Rates struct {
+	pairs      map[currencyPair][]ratePoint
+	currencies []string
+}
+
+// NewRates indexes the given exchange rates by currency pair and date.
+func NewRates(rates []models.ExchangeRate) *Rates {
+	r := &Rates{pairs: map[currencyPair][]ratePoint{}}
+	seen := map[string]bool{}
+	for _, rate := range rates {
+		pair := currencyPair{base: rate.BaseCurrency, quote: rate.QuoteCurrency}
+		r.pairs[pair] = append(r.pairs[pair], ratePoint{effective: rate.EffectiveDate, rate: rate.Rate})
+		for _, code := range []string{rate.BaseCurrency, rate.QuoteCurrency} {
+			if !seen[code] {
+				seen[code] = true
+				r.currencies = append(r.currencies, code)
+			}
+		}
+	}
+	sort.Strings(r.currencies)
+	for _, points := range r.pairs {
+		sort.Slice(points, func(i, j int) bool { return points[i].effective.Before(points[j].effective) })
+	}
+	return r
+}
+
+// Convert converts amount from one currency to another using the rate
+// effective in the given month, that is the latest rate set before the
+// following month starts. Inverse rates and a single intermediate currency
+// are used when no direct rate is loaded.
+func (r *Rates) Convert(amount float64, from, to string, month time.Time) (float64, error) {
+	if from == to {
+		return amount, nil
+	}
+	if r == nil {
+		r = &Rates{}
+	}
+	cutoff := MonthStart(month).AddDate(0, 1, 0)
+
+	if rate, ok := r.rate(from, to, cutoff); ok {
+		return amount * rate, nil
+	}
+	for _, via := range r.currencies {
+		if via == from || via == to {
+			continue
+		}
+		first, ok := r.rate(from, via, cutoff)
+		if !ok {
+			continue
+		}
+		if second, ok := r.rate(via, to, cutoff); ok {
+			return amount * first * second, nil
+		}
+	}
+	return 0, fmt.Errorf("%w from %s to %s effective %s", ErrNoRate, from, to, MonthStart(month).Format(MonthLayout))
+}
+
+func (r *Rates) rate(from, to string, cutoff time.Time) (float64, bool) {
+	if rate, ok := r.effective(currencyPair{base: from, quote: to}, cutoff); ok {
+		return rate, true
+	}
+	if rate, ok := r.effective(currencyPair{base: to, quote: from}, cutoff); ok {
+		return 1 / rate, true
+	}
+	return 0, false
+}
+
+func (r *Rates) effective(pair currencyPair, cutoff time.Time) (float64, bool) {
+	points := r.pairs[pair]
+	i := sort.Search(len(points), func(i int) bool { return !points[i].effective.Before(cutoff) })
+	if i == 0 {
+		return 0, false
+	}
+	return points[i-1].rate, true
+}
+
+// ValidCurrency reports whether code looks like an ISO 4217 currency code.
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseRatesCSV reads exchange rates from CSV with a
+// base_currency,quote_currency,rate,effective_date header. Dates are
+// YYYY-MM-DD or YYYY-MM.
+func ParseRatesCSV(r io.Reader) ([]models.ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"base_currency", "quote_currency", "rate", "effective_date"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	var rates []models.ExchangeRate
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(record[columns["rate"]]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid rate: %w", line, err)
+		}
+		effective, err := parseRateDate(strings.TrimSpace(record[columns["effective_date"]]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid effective_date: %w", line, err)
+		}
+		rates = append(rates, models.ExchangeRate{
+			BaseCurrency:  strings.ToUpper(strings.TrimSpace(record[columns["base_currency"]])),
+			QuoteCurrency: strings.ToUpper(strings.TrimSpace(record[columns["quote_currency"]])),
+			Rate:          rate,
+			EffectiveDate: effective,
+		})
+	}
+	return rates, nil
+}
+
+func parseRateDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(MonthLayout, s)
+}
@@ -0,0 +1,241 @@
+// Package calendar renders subscriptions as an RFC 5545 iCalendar feed of
+// their renewals and end dates.
+package calendar
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service/internal/billing"
+	"service/internal/models"
+)
+
+// ContentType is the media type of the feed.
+const ContentType = "text/calendar; charset=utf-8"
+
+const (
+	prodID    = "-//subscription-service//renewals//EN"
+	uidDomain = "subscription-service"
+	dateOnly  = "20060102"
+	dateTime  = "20060102T150405Z"
+	maxLine   = 75
+)
+
+// Token is the secret a user's feed URL carries. It is an HMAC of the tenant
+// and user IDs and the user's feed nonce, so it is stable across restarts.
+// Rotating the nonce revokes the URL of one user and changing the secret
+// revokes every issued URL. Tokens of the default tenant cover the user ID
+// alone and an empty nonce adds nothing, so URLs issued before tenants and
+// nonces existed keep working.
+func Token(secret []byte, tenantID, userID uuid.UUID, nonce string) string {
+	mac := hmac.New(sha256.New, secret)
+	if tenantID != models.DefaultTenantID {
+		mac.Write(tenantID[:])
+	}
+	mac.Write(userID[:])
+	mac.Write([]byte(nonce))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ValidToken reports whether token is the feed token of userID in tenantID.
+func ValidToken(secret []byte, tenantID, userID uuid.UUID, nonce, token string) bool {
+	return hmac.Equal([]byte(token), []byte(Token(secret, tenantID, userID, nonce)))
+}
+
+// NewNonce returns a random feed nonce.
+func NewNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Write renders the feed of subs. Every subscription gets an all-day event on
+// its first billing day, repeating with its billing interval until its end
+// month, and one-off subscriptions a single event. A price change ends the
+// repeating event before the first billing day it applies to and starts
+// another at the new price. Subscriptions with an end date also get an event
+// on the last day of their end month.
+func Write(w io.Writer, name string, subs []models.Subscription, prices *billing.Prices, now time.Time) error {
+	cw := &contentWriter{w: w}
+	stamp := now.UTC().Format(dateTime)
+
+	cw.line("BEGIN:VCALENDAR")
+	cw.line("VERSION:2.0")
+	cw.line("PRODID:" + prodID)
+	cw.line("CALSCALE:GREGORIAN")
+	cw.line("METHOD:PUBLISH")
+	cw.line("X-WR-CALNAME:" + escape(name))
+
+	for _, sub := range subs {
+		segs := segments(sub, prices)
+		for i, seg := range segs {
+			var until time.Time
+			if i+1 < len(segs) {
+				until = segs[i+1].start.AddDate(0, 0, -1)
+			} else if sub.EndDate != nil {
+				until = lastDay(sub.EndDate.Time)
+			}
+			price := fmt.Sprintf("%d %s", seg.price, sub.Currency)
+
+			uid := sub.ID.String() + "-renewal"
+			if i > 0 {
+				uid += "-" + seg.start.Format(dateOnly)
+			}
+			cw.line("BEGIN:VEVENT")
+			cw.line("UID:" + uid + "@" + uidDomain)
+			cw.line("DTSTAMP:" + stamp)
+			cw.line("DTSTART;VALUE=DATE:" + seg.start.Format(dateOnly))
+			cw.line("DTEND;VALUE=DATE:" + seg.start.AddDate(0, 0, 1).Format(dateOnly))
+			if rule := recurrence(sub, until); rule != "" {
+				cw.line("RRULE:" + rule)
+				cw.line("SUMMARY:" + escape(sub.ServiceName+" renewal ("+price+")"))
+			} else {
+				cw.line("SUMMARY:" + escape(sub.ServiceName+" payment ("+price+")"))
+			}
+			cw.line("DESCRIPTION:" + escape(describe(sub, seg, until)))
+			cw.line("TRANSP:TRANSPARENT")
+			cw.line("END:VEVENT")
+		}
+
+		if sub.EndDate != nil && sub.BillingInterval != models.IntervalOnce {
+			last := lastDay(sub.EndDate.Time)
+			cw.line("BEGIN:VEVENT")
+			cw.line("UID:" + sub.ID.String() + "-end@" + uidDomain)
+			cw.line("DTSTAMP:" + stamp)
+			cw.line("DTSTART;VALUE=DATE:" + last.Format(dateOnly))
+			cw.line("DTEND;VALUE=DATE:" + last.AddDate(0, 0, 1).Format(dateOnly))
+			cw.line("SUMMARY:" + escape(sub.ServiceName+" ends"))
+			cw.line("DESCRIPTION:" + escape(describe(sub, segs[len(segs)-1], last)))
+			cw.line("TRANSP:TRANSPARENT")
+			cw.line("END:VEVENT")
+		}
+	}
+
+	cw.line("END:VCALENDAR")
+	return cw.err
+}
+
+// segment is a run of billing days charged the same price.
+type segment struct {
+	start time.Time
+	price int
+}
+
+// segments splits the billing days of sub at its price changes. Each segment
+// starts on the first billing day in or after the month its change takes
+// effect; changes that take effect before the same billing day collapse into
+// the latest of them.
+func segments(sub models.Subscription, prices *billing.Prices) []segment {
+	segs := []segment{{start: billing.BillingDay(sub, 0), price: sub.Price}}
+	if sub.BillingInterval == models.IntervalOnce {
+		return segs
+	}
+
+	k := 0
+	for _, c := range prices.Changes(sub) {
+		for billing.BillingDay(sub, k).Before(c.EffectiveFrom.Time) {
+			k++
+		}
+		start := billing.BillingDay(sub, k)
+		if sub.EndDate != nil && start.After(lastDay(sub.EndDate.Time)) {
+			break
+		}
+		if last := &segs[len(segs)-1]; last.start.Equal(start) {
+			last.price = c.Price
+			continue
+		}
+		segs = append(segs, segment{start: start, price: c.Price})
+	}
+	return segs
+}
+
+// recurrence returns the RRULE of the subscription's renewals up to until,
+// or "" for a one-off payment. A zero until repeats forever.
+func recurrence(sub models.Subscription, until time.Time) string {
+	count := sub.IntervalCount
+	if count < 1 {
+		count = 1
+	}
+
+	var rule string
+	switch sub.BillingInterval {
+	case models.IntervalOnce:
+		return ""
+	case models.IntervalWeek:
+		rule = fmt.Sprintf("FREQ=WEEKLY;INTERVAL=%d", count)
+	case models.IntervalQuarter:
+		rule = fmt.Sprintf("FREQ=MONTHLY;INTERVAL=%d", 3*count)
+	case models.IntervalYear:
+		rule = fmt.Sprintf("FREQ=YEARLY;INTERVAL=%d", count)
+	default:
+		rule = fmt.Sprintf("FREQ=MONTHLY;INTERVAL=%d", count)
+	}
+	if !until.IsZero() {
+		rule += ";UNTIL=" + until.Format(dateOnly)
+	}
+	return rule
+}
+
+// describe summarizes the segment of sub billed up to until, or with no end
+// when until is zero.
+func describe(sub models.Subscription, seg segment, until time.Time) string {
+	if sub.BillingInterval == models.IntervalOnce {
+		return fmt.Sprintf("%s: one-off payment of %d %s in %s", sub.ServiceName, seg.price, sub.Currency, sub.StartDate)
+	}
+	period := string(sub.BillingInterval)
+	if sub.IntervalCount > 1 {
+		period = fmt.Sprintf("%d %ss", sub.IntervalCount, sub.BillingInterval)
+	}
+	text := fmt.Sprintf("%s: %d %s per %s since %s", sub.ServiceName, seg.price, sub.Currency, period, models.NewMonth(seg.start))
+	if !until.IsZero() {
+		text += " until " + models.NewMonth(until).String()
+	}
+	return text
+}
+
+func lastDay(month time.Time) time.Time {
+	return billing.MonthStart(month).AddDate(0, 1, -1)
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escape escapes a TEXT property value.
+func escape(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// contentWriter writes content lines terminated by CRLF and folded at 75
+// octets without splitting UTF-8 sequences. The first error is kept.
+type contentWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (cw *contentWriter) line(s string) {
+	if cw.err != nil {
+		return
+	}
+	var b strings.Builder
+	width := 0
+	for _, r := range s {
+		size := len(string(r))
+		if width+size > maxLine {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+	_, cw.err = io.WriteString(cw.w, b.String())
+}
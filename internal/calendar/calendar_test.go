@@ -0,0 +1,127 @@
+package calendar
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service/internal/billing"
+	"service/internal/models"
+)
+
+func month(y int, m time.Month) models.Month {
+	return models.NewMonth(time.Date(y, m, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func TestToken(t *testing.T) {
+	tenant, user := models.DefaultTenantID, uuid.New()
+	token := Token([]byte("secret"), tenant, user, "")
+	if token != Token([]byte("secret"), tenant, user, "") {
+		t.Error("token is not stable")
+	}
+	if !ValidToken([]byte("secret"), tenant, user, "", token) {
+		t.Error("token rejected")
+	}
+	nonce, err := NewNonce()
+	if err != nil || nonce == "" {
+		t.Fatalf("NewNonce: %q, %v", nonce, err)
+	}
+	for name, ok := range map[string]bool{
+		"other secret": ValidToken([]byte("rotated"), tenant, user, "", token),
+		"other tenant": ValidToken([]byte("secret"), uuid.New(), user, "", token),
+		"other user":   ValidToken([]byte("secret"), tenant, uuid.New(), "", token),
+		"other nonce":  ValidToken([]byte("secret"), tenant, user, nonce, token),
+		"empty token":  ValidToken([]byte("secret"), tenant, user, "", ""),
+	} {
+		if ok {
+			t.Errorf("%s accepted", name)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	end := month(2025, time.December)
+	subs := []models.Subscription{
+		{ID: uuid.New(), ServiceName: "Netflix, Premium; 4K", Price: 400, Currency: "RUB", BillingInterval: models.IntervalQuarter, IntervalCount: 1, StartDate: month(2025, time.July), EndDate: &end},
+		{ID: uuid.New(), ServiceName: "Course", Price: 9000, Currency: "RUB", BillingInterval: models.IntervalOnce, StartDate: month(2025, time.March), EndDate: &end},
+		{ID: uuid.New(), ServiceName: strings.Repeat("Ж", 60), Price: 1, Currency: "RUB", BillingInterval: models.IntervalWeek, IntervalCount: 2, StartDate: month(2025, time.January)},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, "Subscriptions", subs, nil, time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	feed := buf.String()
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"DTSTAMP:20250601T120000Z\r\n",
+		"UID:" + subs[0].ID.String() + "-renewal@subscription-service\r\n",
+		"DTSTART;VALUE=DATE:20250701\r\n",
+		"RRULE:FREQ=MONTHLY;INTERVAL=3;UNTIL=20251231\r\n",
+		`SUMMARY:Netflix\, Premium\; 4K renewal (400 RUB)` + "\r\n",
+		"UID:" + subs[0].ID.String() + "-end@subscription-service\r\n",
+		"DTSTART;VALUE=DATE:20251231\r\n",
+		"SUMMARY:Course payment (9000 RUB)\r\n",
+		"RRULE:FREQ=WEEKLY;INTERVAL=2\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(feed, want) {
+			t.Errorf("feed lacks %q", want)
+		}
+	}
+	if strings.Contains(feed, "UID:"+subs[1].ID.String()+"-end@") {
+		t.Error("one-off subscription got an end event")
+	}
+	if n := strings.Count(feed, "BEGIN:VEVENT"); n != 4 {
+		t.Errorf("%d events, want 4", n)
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(feed, "\r\n"), "\r\n") {
+		if len(line) > maxLine {
+			t.Errorf("line of %d octets: %q", len(line), line)
+		}
+		if strings.ContainsRune(line, '�') {
+			t.Errorf("folding split a UTF-8 sequence: %q", line)
+		}
+	}
+	unfolded := strings.ReplaceAll(feed, "\r\n ", "")
+	if !strings.Contains(unfolded, "SUMMARY:"+strings.Repeat("Ж", 60)+" renewal (1 RUB)") {
+		t.Error("folded summary does not unfold to the original")
+	}
+}
+
+func TestWriteSplitsAtPriceChanges(t *testing.T) {
+	end := month(2025, time.December)
+	sub := models.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 400, Currency: "RUB", BillingInterval: models.IntervalMonth, IntervalCount: 1, StartDate: month(2025, time.July), EndDate: &end}
+	prices := billing.NewPrices([]models.PriceChange{
+		{SubscriptionID: sub.ID, Price: 450, EffectiveFrom: month(2025, time.September)},
+		{SubscriptionID: sub.ID, Price: 500, EffectiveFrom: month(2025, time.November)},
+	})
+	var buf bytes.Buffer
+	if err := Write(&buf, "Subscriptions", []models.Subscription{sub}, prices, time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	feed := strings.ReplaceAll(buf.String(), "\r\n ", "")
+
+	for _, want := range []string{
+		"UID:" + sub.ID.String() + "-renewal@subscription-service\r\n",
+		"RRULE:FREQ=MONTHLY;INTERVAL=1;UNTIL=20250831\r\n",
+		"SUMMARY:Netflix renewal (400 RUB)\r\n",
+		"UID:" + sub.ID.String() + "-renewal-20250901@subscription-service\r\n",
+		"RRULE:FREQ=MONTHLY;INTERVAL=1;UNTIL=20251031\r\n",
+		"SUMMARY:Netflix renewal (450 RUB)\r\n",
+		"UID:" + sub.ID.String() + "-renewal-20251101@subscription-service\r\n",
+		"RRULE:FREQ=MONTHLY;INTERVAL=1;UNTIL=20251231\r\n",
+		"SUMMARY:Netflix renewal (500 RUB)\r\n",
+	} {
+		if !strings.Contains(feed, want) {
+			t.Errorf("feed lacks %q", want)
+		}
+	}
+	if n := strings.Count(feed, "BEGIN:VEVENT"); n != 4 {
+		t.Errorf("%d events, want three renewal runs and the end", n)
+	}
+}
@@ -14,12 +14,15 @@ import (
 var (
 	db     *gorm.DB
 	dbOnce sync.Once
+
+	systemDB     *gorm.DB
+	systemDBOnce sync.Once
 )
 
 func InitDB(dsn string) *gorm.DB {
 	dbOnce.Do(func() {
 		var err error
-		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 		if err != nil {
 			log.Fatalf("[error] Database connection failed: %v", err)
 		}
@@ -33,6 +36,32 @@ func InitDB(dsn string) *gorm.DB {
 	return db
 }
 
+// InitSystemDB connects with the role used by background jobs and API key
+// lookups, which must bypass row-level security. Without a dsn of its own it
+// shares the connection opened by InitDB.
+func InitSystemDB(dsn string) *gorm.DB {
+	systemDBOnce.Do(func() {
+		if dsn == "" {
+			systemDB = GetDB()
+			return
+		}
+		var err error
+		systemDB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
+		if err != nil {
+			log.Fatalf("[error] System database connection failed: %v", err)
+		}
+	})
+	return systemDB
+}
+
+// BypassesRLS reports whether the role of conn is exempt from row-level
+// security.
+func BypassesRLS(conn *gorm.DB) (bool, error) {
+	var bypass bool
+	err := conn.Raw("SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user").Scan(&bypass).Error
+	return bypass, err
+}
+
 func runMigrations(gormDB *gorm.DB) error {
 	sqlDB, err := gormDB.DB()
 	if err != nil {
@@ -70,8 +99,13 @@ func GetDB() *gorm.DB {
 	return db
 }
 
-// CloseDB closes the underlying SQL connection
+// CloseDB closes the underlying SQL connections
 func CloseDB() {
+	if systemDB != nil && systemDB != db {
+		if sqlDB, err := systemDB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
 	if db != nil {
 		sqlDB, err := db.DB()
 		if err != nil {
@@ -0,0 +1,162 @@
+// Package export writes subscriptions as downloadable files, one row at a
+// time so that exports of any size can be streamed.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+
+	"service/internal/models"
+)
+
+// Format is an export file format.
+type Format string
+
+const (
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+	XLSX   Format = "xlsx"
+)
+
+var contentTypes = map[Format]string{
+	CSV:    "text/csv",
+	NDJSON: "application/x-ndjson",
+	XLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// Valid reports whether the format is supported.
+func (f Format) Valid() bool {
+	_, ok := contentTypes[f]
+	return ok
+}
+
+// ContentType is the media type of the format.
+func (f Format) ContentType() string {
+	return contentTypes[f]
+}
+
+// Negotiate picks the format for an Accept header, preferring earlier media
+// ranges. It returns false when none of them is supported; an empty header or
+// */* selects CSV.
+func Negotiate(accept string) (Format, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return CSV, true
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "*/*", "text/*", "text/csv":
+			return CSV, true
+		case "application/x-ndjson", "application/jsonl", "application/json-seq":
+			return NDJSON, true
+		case contentTypes[XLSX]:
+			return XLSX, true
+		}
+	}
+	return "", false
+}
+
+// Writer writes subscriptions in an export format. Close must be called to
+// complete the file.
+type Writer interface {
+	Write(sub models.Subscription) error
+	Close() error
+}
+
+// NewWriter returns a Writer for the format.
+func NewWriter(f Format, w io.Writer) (Writer, error) {
+	switch f {
+	case CSV:
+		return newCSVWriter(w)
+	case NDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w)}, nil
+	case XLSX:
+		return newXLSXWriter(w)
+	}
+	return nil, fmt.Errorf("unsupported export format %q", f)
+}
+
+// header names the columns of the tabular formats. They match the columns
+// accepted by the CSV import, plus the subscription ID.
+var header = []string{"id", "service_name", "price", "currency", "billing_interval", "interval_count", "user_id", "start", "end", "category"}
+
+// row returns the cells of a subscription in header order. Numbers are kept
+// as int so spreadsheets treat them as such; text is passed through text.
+func row(sub models.Subscription) []any {
+	end := ""
+	if sub.EndDate != nil {
+		end = sub.EndDate.String()
+	}
+	return []any{
+		sub.ID.String(),
+		text(sub.ServiceName),
+		sub.Price,
+		text(sub.Currency),
+		string(sub.BillingInterval),
+		sub.IntervalCount,
+		sub.UserID.String(),
+		sub.StartDate.String(),
+		end,
+		text(sub.Category),
+	}
+}
+
+// text returns a user-supplied cell so spreadsheets show it rather than
+// evaluate it: a value that would be read as a formula gets a ' prefix.
+func text(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w)}
+	if err := cw.w.Write(header); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *csvWriter) Write(sub models.Subscription) error {
+	cells := row(sub)
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		switch v := cell.(type) {
+		case int:
+			record[i] = strconv.Itoa(v)
+		default:
+			record[i] = v.(string)
+		}
+	}
+	return cw.w.Write(record)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (nw *ndjsonWriter) Write(sub models.Subscription) error {
+	return nw.enc.Encode(sub)
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,180 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service/internal/models"
+)
+
+func subscription(name string, price int, end bool) models.Subscription {
+	sub := models.Subscription{
+		ID:              uuid.New(),
+		ServiceName:     name,
+		Category:        "streaming",
+		Price:           price,
+		Currency:        "RUB",
+		BillingInterval: models.IntervalMonth,
+		IntervalCount:   1,
+		UserID:          uuid.New(),
+		StartDate:       models.NewMonth(time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)),
+	}
+	if end {
+		month := models.NewMonth(time.Date(2025, time.December, 1, 0, 0, 0, 0, time.UTC))
+		sub.EndDate = &month
+	}
+	return sub
+}
+
+func TestNegotiate(t *testing.T) {
+	for accept, want := range map[string]Format{
+		"":                                       CSV,
+		"*/*":                                    CSV,
+		"application/x-ndjson, text/csv":         NDJSON,
+		"application/xml, " + contentTypes[XLSX]: XLSX,
+		"text/csv;q=0.5":                         CSV,
+	} {
+		if got, ok := Negotiate(accept); !ok || got != want {
+			t.Errorf("Negotiate(%q) = %q, %v; want %q", accept, got, ok, want)
+		}
+	}
+	if got, ok := Negotiate("application/pdf"); ok {
+		t.Errorf("Negotiate(application/pdf) = %q", got)
+	}
+}
+
+func export(t *testing.T, f Format, subs ...models.Subscription) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(f, &buf)
+	if err != nil {
+		t.Fatalf("NewWriter(%s): %v", f, err)
+	}
+	for _, sub := range subs {
+		if err := w.Write(sub); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestCSVWriter(t *testing.T) {
+	open, ended := subscription("Netflix, Inc.", 400, false), subscription("Spotify", 200, true)
+	records, err := csv.NewReader(bytes.NewReader(export(t, CSV, open, ended))).ReadAll()
+	if err != nil {
+		t.Fatalf("read export: %v", err)
+	}
+	if len(records) != 3 || strings.Join(records[0], ",") != strings.Join(header, ",") {
+		t.Fatalf("records %q", records)
+	}
+	want := []string{open.ID.String(), "Netflix, Inc.", "400", "RUB", "month", "1", open.UserID.String(), "07-2025", "", "streaming"}
+	if strings.Join(records[1], "|") != strings.Join(want, "|") {
+		t.Errorf("row %q, want %q", records[1], want)
+	}
+	if records[2][8] != "12-2025" {
+		t.Errorf("end %q, want 12-2025", records[2][8])
+	}
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(string(export(t, NDJSON, subscription("A", 1, false), subscription("B", 2, true)))), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("lines %q", lines)
+	}
+	var sub models.Subscription
+	if err := json.Unmarshal([]byte(lines[1]), &sub); err != nil || sub.ServiceName != "B" || sub.EndDate == nil {
+		t.Errorf("decoded %+v, %v", sub, err)
+	}
+}
+
+func TestXLSXWriter(t *testing.T) {
+	data := export(t, XLSX, subscription("Tom & Jerry <TV>", 400, false))
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open workbook: %v", err)
+	}
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(content)
+	}
+	for _, part := range xlsxParts {
+		if parts[part.name] == "" {
+			t.Errorf("missing part %s", part.name)
+		}
+	}
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<c r="A1" t="inlineStr"><is><t>id</t></is></c>`,
+		`<c r="B2" t="inlineStr"><is><t>Tom &amp; Jerry &lt;TV&gt;</t></is></c>`,
+		`<c r="C2"><v>400</v></c>`,
+		`</sheetData></worksheet>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet lacks %s:\n%s", want, sheet)
+		}
+	}
+	if strings.Contains(sheet, `r="I2"`) {
+		t.Errorf("empty end written as a cell")
+	}
+}
+
+func TestFormulaCellsAreEscaped(t *testing.T) {
+	sub := subscription("=HYPERLINK(\"http://evil\")", 400, false)
+	sub.Category = "@SUM(A1)"
+	sub.Currency = "-1"
+	records, err := csv.NewReader(bytes.NewReader(export(t, CSV, sub))).ReadAll()
+	if err != nil {
+		t.Fatalf("read export: %v", err)
+	}
+	if got := records[1]; got[1] != `'=HYPERLINK("http://evil")` || got[2] != "400" || got[3] != "'-1" || got[9] != "'@SUM(A1)" {
+		t.Errorf("row %q", got)
+	}
+	for in, want := range map[string]string{
+		"+1":          "'+1",
+		"\tNetflix":   "'\tNetflix",
+		"\rNetflix":   "'\rNetflix",
+		"Netflix":     "Netflix",
+		"Tom = Jerry": "Tom = Jerry",
+		"":            "",
+	} {
+		if got := text(in); got != want {
+			t.Errorf("text(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	data := export(t, XLSX, sub)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open workbook: %v", err)
+	}
+	for _, f := range zr.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, _ := f.Open()
+		sheet, _ := io.ReadAll(rc)
+		rc.Close()
+		for _, want := range []string{`<t>&#39;=HYPERLINK(&#34;http://evil&#34;)</t>`, `<c r="C2"><v>400</v></c>`, `<t>&#39;@SUM(A1)</t>`} {
+			if !strings.Contains(string(sheet), want) {
+				t.Errorf("sheet lacks %s:\n%s", want, sheet)
+			}
+		}
+	}
+}
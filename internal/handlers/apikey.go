@@ -0,0 +1,235 @@
+package handlers
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "go.uber.org/zap"
+
+    "service/internal/apierror"
+    "service/internal/auth"
+    "service/internal/logger"
+    "service/internal/models"
+    "service/internal/repository"
+    "service/internal/validation"
+)
+
+// APIKeyHandler serves the API key management endpoints.
+type APIKeyHandler struct {
+    keys repository.APIKeyRepository
+}
+
+func NewAPIKeyHandler(keys repository.APIKeyRepository) *APIKeyHandler {
+    return &APIKeyHandler{keys: keys}
+}
+
+// APIKeyInput issues an API key. Without a UserID the key sees the data of
+// every user.
+type APIKeyInput struct {
+    Name      string         `example:"nightly-billing-export"`
+    Scopes    []models.Scope `swaggertype:"array,string" example:"subscriptions:read,summary:read"`
+    UserID    *uuid.UUID     `swaggertype:"string"`
+    ExpiresAt *time.Time
+}
+
+// IssuedAPIKey is a new or rotated API key together with its secret value,
+// which is shown only in this response.
+type IssuedAPIKey struct {
+    models.APIKey
+    Key string `example:"sk_3f9a1c2e4b5d.6Jq0..."`
+}
+
+// @Summary Create an API key
+// @Description Issue an API key for a service caller, sent in the X-API-Key header. Scopes limit the endpoints it may call: subscriptions:read, subscriptions:write, summary:read, budgets:read, budgets:write and exchange_rates:read. The key is returned only once; only its hash is stored.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param key body APIKeyInput true "Name, scopes, optional user and expiry"
+// @Success 201 {object} IssuedAPIKey
+// @Failure 400 {object} apierror.Problem
+// @Failure 422 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Router /api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+    var input APIKeyInput
+    if !bindJSON(c, &input) {
+        return
+    }
+
+    now := time.Now().UTC()
+    key := models.APIKey{
+        ID:        uuid.New(),
+        Name:      strings.TrimSpace(input.Name),
+        Scopes:    input.Scopes,
+        UserID:    input.UserID,
+        CreatedAt: now,
+        ExpiresAt: input.ExpiresAt,
+    }
+    if errs := validation.APIKey(&key, now); len(errs) > 0 {
+        c.Error(apierror.Invalid(http.StatusUnprocessableEntity, errs))
+        return
+    }
+    secret, err := issueAPIKey(&key)
+    if err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("generate API key: %w", err)))
+        return
+    }
+    if err := h.keys.Create(c.Request.Context(), &key); err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("create API key: %w", err)))
+        return
+    }
+
+    logger.Log.Info("API key created",
+        zap.String("id", key.ID.String()),
+        zap.String("prefix", key.Prefix),
+        zap.String("name", key.Name),
+    )
+    c.JSON(http.StatusCreated, IssuedAPIKey{APIKey: key, Key: secret})
+}
+
+// @Summary List API keys
+// @Description Get every API key, revoked ones included, without their secret values
+// @Tags api-keys
+// @Produce json
+// @Success 200 {array} models.APIKey
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Router /api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+    keys, err := h.keys.List(c.Request.Context())
+    if err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("list API keys: %w", err)))
+        return
+    }
+    if keys == nil {
+        keys = []models.APIKey{}
+    }
+    c.JSON(http.StatusOK, keys)
+}
+
+// @Summary Get an API key
+// @Description Get API key by ID, without its secret value
+// @Tags api-keys
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 200 {object} models.APIKey
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Router /api-keys/{id} [get]
+func (h *APIKeyHandler) GetAPIKey(c *gin.Context) {
+    key, ok := h.loadAPIKey(c)
+    if !ok {
+        return
+    }
+    c.JSON(http.StatusOK, key)
+}
+
+// @Summary Rotate an API key
+// @Description Replace the secret value of an API key, keeping its name, scopes and expiry. The previous value stops working at once.
+// @Tags api-keys
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 200 {object} IssuedAPIKey
+// @Failure 404 {object} apierror.Problem
+// @Failure 409 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Router /api-keys/{id}/rotate [post]
+func (h *APIKeyHandler) RotateAPIKey(c *gin.Context) {
+    key, ok := h.loadAPIKey(c)
+    if !ok {
+        return
+    }
+    if key.RevokedAt != nil {
+        c.Error(apierror.Conflict("api_key_revoked", "a revoked API key cannot be rotated"))
+        return
+    }
+
+    secret, err := issueAPIKey(key)
+    if err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("generate API key: %w", err)))
+        return
+    }
+    now := time.Now().UTC()
+    key.RotatedAt = &now
+    if err := h.keys.Update(c.Request.Context(), key); err != nil {
+        c.Error(apiKeyError(err, "rotate API key "+key.ID.String()))
+        return
+    }
+
+    logger.Log.Info("API key rotated", zap.String("id", key.ID.String()), zap.String("prefix", key.Prefix))
+    c.JSON(http.StatusOK, IssuedAPIKey{APIKey: *key, Key: secret})
+}
+
+// @Summary Revoke an API key
+// @Description Stop accepting an API key. The key stays listed with its revocation time.
+// @Tags api-keys
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 200 {object} models.APIKey
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Router /api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+    key, ok := h.loadAPIKey(c)
+    if !ok {
+        return
+    }
+    if key.RevokedAt == nil {
+        now := time.Now().UTC()
+        key.RevokedAt = &now
+        if err := h.keys.Update(c.Request.Context(), key); err != nil {
+            c.Error(apiKeyError(err, "revoke API key "+key.ID.String()))
+            return
+        }
+        logger.Log.Info("API key revoked", zap.String("id", key.ID.String()), zap.String("prefix", key.Prefix))
+    }
+    c.JSON(http.StatusOK, key)
+}
+
+// loadAPIKey fetches the key named by the id path parameter. On failure the
+// response is written and ok is false.
+func (h *APIKeyHandler) loadAPIKey(c *gin.Context) (*models.APIKey, bool) {
+    id, err := uuid.Parse(c.Param("id"))
+    if err != nil {
+        c.Error(apiKeyNotFound())
+        return nil, false
+    }
+    key, err := h.keys.Get(c.Request.Context(), id)
+    if err != nil {
+        c.Error(apiKeyError(err, "get API key "+id.String()))
+        return nil, false
+    }
+    return key, true
+}
+
+// issueAPIKey gives key a new secret value and returns it.
+func issueAPIKey(key *models.APIKey) (string, error) {
+    secret, prefix, hash, err := auth.NewAPIKey()
+    if err != nil {
+        return "", err
+    }
+    key.Prefix = prefix
+    key.Hash = hash
+    return secret, nil
+}
+
+func apiKeyNotFound() *apierror.Error {
+    return apierror.NotFound("api_key_not_found", "API key not found")
+}
+
+// apiKeyError maps a repository error of the named operation to an API error.
+func apiKeyError(err error, op string) *apierror.Error {
+    if errors.Is(err, repository.ErrNotFound) {
+        return apiKeyNotFound()
+    }
+    return apierror.Internal(fmt.Errorf("%s: %w", op, err))
+}
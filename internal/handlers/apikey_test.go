@@ -0,0 +1,104 @@
+package handlers_test
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"
+
+    "service/internal/apierror"
+    "service/internal/auth"
+    "service/internal/handlers"
+)
+
+// issueKey creates an API key as an admin and returns its secret value.
+func (s *server) issueKey(body string) handlers.IssuedAPIKey {
+    s.t.Helper()
+    var key handlers.IssuedAPIKey
+    s.expect(s.do(http.MethodPost, "/api-keys", body, "Authorization", bearer(s.t, "ops", "admin")), http.StatusCreated, &key)
+    return key
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+    s := newAuthServer(t)
+    alice, bob := uuid.New(), uuid.New()
+    asAdmin := []string{"Authorization", bearer(t, "ops", "admin")}
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(alice, "Netflix", 400), asAdmin...), http.StatusCreated, nil)
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(bob, "Spotify", 200), asAdmin...), http.StatusCreated, nil)
+
+    reader := s.issueKey(fmt.Sprintf(`{"Name":"reader","Scopes":["subscriptions:read"],"UserID":"%s"}`, alice))
+    if reader.Key == "" || reader.Hash != "" || reader.Prefix == "" {
+        t.Errorf("issued %+v", reader)
+    }
+    asReader := []string{auth.APIKeyHeader, reader.Key}
+
+    var page handlers.SubscriptionPage
+    s.expect(s.do(http.MethodGet, "/subscriptions", "", asReader...), http.StatusOK, &page)
+    if page.Total != 1 || page.Items[0].UserID != alice {
+        t.Errorf("user-bound key listed %+v", page.Items)
+    }
+    for _, req := range [][3]string{
+        {http.MethodPost, "/subscriptions", subscriptionJSON(alice, "Hulu", 100)},
+        {http.MethodPost, "/subscriptions:batch", `{"Operations":[]}`},
+        {http.MethodGet, "/subscriptions/summary", ""},
+        {http.MethodGet, "/budgets", ""},
+    } {
+        if p := s.problem(s.do(req[0], req[1], req[2], asReader...)); p.Status != http.StatusForbidden || p.Code != apierror.CodeScope {
+            t.Errorf("%s %s without the scope answered %+v", req[0], req[1], p)
+        }
+    }
+
+    service := s.issueKey(`{"Name":"billing","Scopes":["subscriptions:read","subscriptions:write","summary:read"]}`)
+    asService := []string{auth.APIKeyHeader, service.Key}
+    s.expect(s.do(http.MethodGet, "/subscriptions", "", asService...), http.StatusOK, &page)
+    if page.Total != 2 {
+        t.Errorf("unbound key listed %d subscriptions, want 2", page.Total)
+    }
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(bob, "Hulu", 100), asService...), http.StatusCreated, nil)
+    if p := s.problem(s.do(http.MethodGet, "/api-keys", "", asService...)); p.Code != apierror.CodeForbidden {
+        t.Errorf("API key on an admin route answered %+v", p)
+    }
+}
+
+func TestAPIKeyLifecycle(t *testing.T) {
+    s := newAuthServer(t)
+    asAdmin := []string{"Authorization", bearer(t, "ops", "admin")}
+    key := s.issueKey(`{"Name":"exporter","Scopes":["subscriptions:read"]}`)
+    list := func(raw string) int {
+        return s.do(http.MethodGet, "/subscriptions", "", auth.APIKeyHeader, raw).Code
+    }
+    if code := list(key.Key); code != http.StatusOK {
+        t.Fatalf("new key answered %d", code)
+    }
+
+    var rotated handlers.IssuedAPIKey
+    s.expect(s.do(http.MethodPost, "/api-keys/"+key.ID.String()+"/rotate", "", asAdmin...), http.StatusOK, &rotated)
+    if rotated.ID != key.ID || rotated.Key == key.Key || rotated.RotatedAt == nil {
+        t.Errorf("rotated %+v", rotated)
+    }
+    if code := list(key.Key); code != http.StatusUnauthorized {
+        t.Errorf("key replaced by rotation answered %d", code)
+    }
+    if code := list(rotated.Key); code != http.StatusOK {
+        t.Errorf("rotated key answered %d", code)
+    }
+
+    s.expect(s.do(http.MethodDelete, "/api-keys/"+key.ID.String(), "", asAdmin...), http.StatusOK, nil)
+    if p := s.problem(s.do(http.MethodGet, "/subscriptions", "", auth.APIKeyHeader, rotated.Key)); p.Code != apierror.CodeBadKey {
+        t.Errorf("revoked key answered %+v", p)
+    }
+    if p := s.problem(s.do(http.MethodPost, "/api-keys/"+key.ID.String()+"/rotate", "", asAdmin...)); p.Code != "api_key_revoked" {
+        t.Errorf("rotating a revoked key answered %+v", p)
+    }
+    if code := list("sk_000000000000.forged"); code != http.StatusUnauthorized {
+        t.Errorf("unknown key answered %d", code)
+    }
+
+    past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+    w := s.do(http.MethodPost, "/api-keys", `{"Name":" ","Scopes":["everything"],"ExpiresAt":"`+past+`"}`, asAdmin...)
+    if got := s.fields(w); w.Code != http.StatusUnprocessableEntity || len(got) != 3 {
+        t.Errorf("invalid key answered %d %v", w.Code, got)
+    }
+}
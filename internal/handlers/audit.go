@@ -0,0 +1,133 @@
+package handlers
+
+import (
+    "fmt"
+    "math"
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+
+    "service/internal/apierror"
+    "service/internal/auth"
+    "service/internal/models"
+    "service/internal/repository"
+    "service/internal/validation"
+)
+
+// AuditHandler serves the audit log of subscription changes.
+type AuditHandler struct {
+    audit repository.AuditRepository
+}
+
+func NewAuditHandler(audit repository.AuditRepository) *AuditHandler {
+    return &AuditHandler{audit: audit}
+}
+
+// @Summary Get the history of a subscription
+// @Description Get the audit entries of a subscription, newest first: who created, updated, deleted, restored or purged it, in which request, and the old and new value of every changed field. The history outlives the subscription. Pass the ID of the last entry as before_id to get the next page.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param limit query int false "Page size, 1-500 (default 50)"
+// @Param before_id query int false "Only entries older than the entry with this ID"
+// @Success 200 {array} models.AuditEntry
+// @Failure 400 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id}/history [get]
+func (h *AuditHandler) GetSubscriptionHistory(c *gin.Context) {
+    id, err := uuid.Parse(c.Param("id"))
+    if err != nil {
+        c.Error(subscriptionNotFound())
+        return
+    }
+    q := validation.NewQuery(c.Request.URL.Query())
+    filter := auditPage(q)
+    if len(q.Errors) > 0 {
+        c.Error(apierror.Invalid(http.StatusBadRequest, q.Errors))
+        return
+    }
+    filter.SubscriptionID = id
+    // Other users' subscriptions are reported as missing, as by
+    // GetSubscription.
+    if userID, restricted := auth.Restricted(c.Request.Context()); restricted {
+        filter.UserID = userID
+    }
+
+    entries, err := h.audit.List(c.Request.Context(), filter)
+    if err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("list history of subscription %s: %w", id, err)))
+        return
+    }
+    if len(entries) == 0 && filter.BeforeID == 0 {
+        c.Error(subscriptionNotFound())
+        return
+    }
+    if entries == nil {
+        entries = []models.AuditEntry{}
+    }
+    c.JSON(http.StatusOK, entries)
+}
+
+// @Summary Query the audit log
+// @Description Get the audit entries of every subscription change in the tenant, newest first, with optional filters. Pass the ID of the last entry as before_id to get the next page.
+// @Tags audit
+// @Produce json
+// @Param subscription_id query string false "Subscription ID"
+// @Param user_id query string false "Owner of the subscription"
+// @Param actor query string false "Token subject or api_key:<ID> of the caller that made the change"
+// @Param action query string false "create, update, delete, restore or purge"
+// @Param from query string false "Only entries created at or after this date (YYYY-MM-DD) or time (RFC 3339)"
+// @Param to query string false "Only entries created before this date (YYYY-MM-DD) or time (RFC 3339)"
+// @Param limit query int false "Page size, 1-500 (default 50)"
+// @Param before_id query int false "Only entries older than the entry with this ID"
+// @Success 200 {array} models.AuditEntry
+// @Failure 400 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Router /audit [get]
+func (h *AuditHandler) ListAuditEntries(c *gin.Context) {
+    q := validation.NewQuery(c.Request.URL.Query())
+    filter := auditPage(q)
+    filter.SubscriptionID = q.UUID("subscription_id")
+    filter.UserID = q.UUID("user_id")
+    filter.Actor = q.String("actor")
+    filter.Action = models.AuditAction(q.String("action"))
+    if filter.Action != "" && !filter.Action.Valid() {
+        q.Fail("action", string(filter.Action), validation.CodeUnsupported, "must be create, update, delete, restore or purge")
+    }
+    filter.From = q.Date("from")
+    filter.To = q.Date("to")
+    if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+        q.Fail("to", q.String("to"), validation.CodeInvalidRange, "must be after from")
+    }
+    if len(q.Errors) > 0 {
+        c.Error(apierror.Invalid(http.StatusBadRequest, q.Errors))
+        return
+    }
+
+    entries, err := h.audit.List(c.Request.Context(), filter)
+    if err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("list audit entries: %w", err)))
+        return
+    }
+    if entries == nil {
+        entries = []models.AuditEntry{}
+    }
+    c.JSON(http.StatusOK, entries)
+}
+
+// auditPage reads the paging parameters of the audit endpoints.
+func auditPage(q *validation.Query) repository.AuditFilter {
+    filter := repository.AuditFilter{Limit: defaultPageLimit}
+    if limit := q.Int("limit", 1, maxPageLimit); limit != nil {
+        filter.Limit = *limit
+    }
+    if before := q.Int("before_id", 1, math.MaxInt); before != nil {
+        filter.BeforeID = uint(*before)
+    }
+    return filter
+}
@@ -0,0 +1,104 @@
+package handlers_test
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+
+    "github.com/google/uuid"
+
+    "service/internal/models"
+)
+
+func TestSubscriptionHistory(t *testing.T) {
+    s := newServer(t)
+    user := uuid.New()
+    var sub models.Subscription
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(user, "Netflix", 100)), http.StatusCreated, &sub)
+    path := "/subscriptions/" + sub.ID.String()
+    s.expect(s.do(http.MethodPut, path, subscriptionJSON(user, "Netflix 4K", 100)), http.StatusOK, nil)
+    s.expect(s.do(http.MethodDelete, path, ""), http.StatusOK, nil)
+    s.expect(s.do(http.MethodPost, path+"/restore", ""), http.StatusOK, nil)
+
+    var history []models.AuditEntry
+    s.expect(s.do(http.MethodGet, path+"/history", ""), http.StatusOK, &history)
+    want := []models.AuditAction{models.AuditRestore, models.AuditDelete, models.AuditUpdate, models.AuditCreate}
+    if len(history) != len(want) {
+        t.Fatalf("history %+v, want %v", history, want)
+    }
+    for i, entry := range history {
+        if entry.Action != want[i] || entry.SubscriptionID != sub.ID || entry.UserID != user || entry.Actor != "anonymous" {
+            t.Errorf("entry %d: %+v, want %s", i, entry, want[i])
+        }
+    }
+    if c := history[2].Changes["ServiceName"]; string(c.Old) != `"Netflix"` || string(c.New) != `"Netflix 4K"` {
+        t.Errorf("update recorded ServiceName %s -> %s", c.Old, c.New)
+    }
+    if len(history[2].Changes) != 1 {
+        t.Errorf("update recorded %v, want only ServiceName", history[2].Changes)
+    }
+    if len(history[0].Changes) == 0 || len(history[1].Changes) == 0 {
+        t.Errorf("restore and delete recorded no fields: %+v", history[:2])
+    }
+    for _, entry := range history {
+        for _, name := range []string{"Version", "DeletedAt"} {
+            if _, ok := entry.Changes[name]; ok {
+                t.Errorf("%s entry records %s", entry.Action, name)
+            }
+        }
+    }
+
+    var page []models.AuditEntry
+    s.expect(s.do(http.MethodGet, fmt.Sprintf("%s/history?limit=2&before_id=%d", path, history[1].ID), ""), http.StatusOK, &page)
+    if len(page) != 2 || page[0].Action != models.AuditUpdate || page[1].Action != models.AuditCreate {
+        t.Errorf("second page %+v", page)
+    }
+
+    s.expect(s.do(http.MethodGet, "/subscriptions/"+uuid.NewString()+"/history", ""), http.StatusNotFound, nil)
+    s.expect(s.do(http.MethodGet, path+"/history?limit=0", ""), http.StatusBadRequest, nil)
+}
+
+func TestAuditLog(t *testing.T) {
+    s := newServer(t)
+    alice, bob := uuid.New(), uuid.New()
+    var sub models.Subscription
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(alice, "Netflix", 100)), http.StatusCreated, &sub)
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(bob, "Spotify", 200)), http.StatusCreated, nil)
+    s.expect(s.do(http.MethodDelete, "/subscriptions/"+sub.ID.String(), ""), http.StatusOK, nil)
+
+    for query, count := range map[string]int{
+        "":                                     3,
+        "?action=create":                       2,
+        "?user_id=" + alice.String():          2,
+        "?subscription_id=" + sub.ID.String(): 2,
+        "?actor=alice":                         0,
+        "?from=2020-01-01&to=2020-02-01":       0,
+    } {
+        var entries []models.AuditEntry
+        s.expect(s.do(http.MethodGet, "/audit"+query, ""), http.StatusOK, &entries)
+        if len(entries) != count {
+            t.Errorf("/audit%s listed %d entries, want %d", query, len(entries), count)
+        }
+    }
+    for _, query := range []string{"?action=rename", "?from=2020-02-01&to=2020-01-01", "?user_id=bob"} {
+        if got := s.problem(s.do(http.MethodGet, "/audit"+query, "")); got.Status != http.StatusBadRequest {
+            t.Errorf("/audit%s answered %+v", query, got)
+        }
+    }
+}
+
+func TestAuditLogRequiresAdmin(t *testing.T) {
+    s := newAuthServer(t)
+    alice := uuid.New()
+    var sub models.Subscription
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(alice, "Netflix", 100), "Authorization", bearer(t, alice.String())), http.StatusCreated, &sub)
+
+    var history []models.AuditEntry
+    s.expect(s.do(http.MethodGet, "/subscriptions/"+sub.ID.String()+"/history", "", "Authorization", bearer(t, alice.String())), http.StatusOK, &history)
+    if len(history) != 1 || history[0].Actor != alice.String() {
+        t.Errorf("history %+v, want a creation by alice", history)
+    }
+    s.expect(s.do(http.MethodGet, "/subscriptions/"+sub.ID.String()+"/history", "", "Authorization", bearer(t, uuid.NewString())), http.StatusNotFound, nil)
+    s.expect(s.do(http.MethodGet, "/audit", "", "Authorization", bearer(t, alice.String())), http.StatusForbidden, nil)
+    s.expect(s.do(http.MethodGet, "/audit", "", "Authorization", bearer(t, "ops", "admin")), http.StatusOK, nil)
+}
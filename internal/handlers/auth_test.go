@@ -0,0 +1,134 @@
+package handlers_test
+
+import (
+    "net/http"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"
+
+    "service/internal/apierror"
+    "service/internal/config"
+    "service/internal/handlers"
+    "service/internal/middleware"
+    "service/internal/models"
+    "service/internal/tenant"
+)
+
+func TestAuthenticationIsRequired(t *testing.T) {
+    s := newAuthServer(t)
+    for name, header := range map[string]string{
+        "no token":     "",
+        "basic auth":   "Basic dXNlcjpwYXNz",
+        "forged token": "Bearer eyJhbGciOiJIUzI1NiJ9.e30.c2lnbmF0dXJl",
+    } {
+        w := s.do(http.MethodGet, "/subscriptions", "", "Authorization", header)
+        p := s.problem(w)
+        if p.Status != http.StatusUnauthorized || (p.Code != apierror.CodeNoToken && p.Code != apierror.CodeBadToken) || w.Header().Get("WWW-Authenticate") == "" {
+            t.Errorf("%s answered %+v", name, p)
+        }
+    }
+
+    user := uuid.New()
+    s.expect(s.do(http.MethodGet, "/subscriptions", "", "Authorization", bearer(t, user.String())), http.StatusOK, nil)
+    if p := s.problem(s.do(http.MethodGet, "/subscriptions", "", "Authorization", bearer(t, "not-a-uuid"))); p.Code != apierror.CodeBadToken {
+        t.Errorf("token without a user answered %+v", p)
+    }
+}
+
+func TestUsersOnlySeeTheirOwnData(t *testing.T) {
+    s := newAuthServer(t)
+    alice, bob := uuid.New(), uuid.New()
+    asAlice := []string{"Authorization", bearer(t, alice.String())}
+    asBob := []string{"Authorization", bearer(t, bob.String())}
+    asAdmin := []string{"Authorization", bearer(t, "ops", "admin")}
+
+    // An omitted user defaults to the caller, another user is forbidden.
+    var sub models.Subscription
+    s.expect(s.do(http.MethodPost, "/subscriptions", `{"ServiceName":"Netflix","Price":400,"StartDate":"01-2026"}`, asAlice...), http.StatusCreated, &sub)
+    if sub.UserID != alice {
+        t.Errorf("created for %s, want the caller %s", sub.UserID, alice)
+    }
+    if p := s.problem(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(bob, "Spotify", 200), asAlice...)); p.Status != http.StatusForbidden {
+        t.Errorf("creating for another user answered %+v", p)
+    }
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(bob, "Spotify", 200), asAdmin...), http.StatusCreated, nil)
+
+    path := "/subscriptions/" + sub.ID.String()
+    for _, req := range [][2]string{{http.MethodGet, ""}, {http.MethodPut, subscriptionJSON(bob, "Netflix", 1)}, {http.MethodDelete, ""}} {
+        if p := s.problem(s.do(req[0], path, req[1], asBob...)); p.Code != "subscription_not_found" {
+            t.Errorf("%s of another user's subscription answered %+v", req[0], p)
+        }
+    }
+
+    var page handlers.SubscriptionPage
+    s.expect(s.do(http.MethodGet, "/subscriptions", "", asBob...), http.StatusOK, &page)
+    if page.Total != 1 || page.Items[0].UserID != bob {
+        t.Errorf("bob listed %+v", page.Items)
+    }
+    s.expect(s.do(http.MethodGet, "/subscriptions?user_id="+alice.String(), "", asBob...), http.StatusForbidden, nil)
+    s.expect(s.do(http.MethodGet, "/subscriptions", "", asAdmin...), http.StatusOK, &page)
+    if page.Total != 2 {
+        t.Errorf("admin listed %d subscriptions, want 2", page.Total)
+    }
+
+    s.expect(s.do(http.MethodGet, "/subscriptions/summary?user_id="+alice.String(), "", asBob...), http.StatusForbidden, nil)
+    s.expect(s.do(http.MethodGet, "/users/"+alice.String()+"/calendar", "", asBob...), http.StatusForbidden, nil)
+    s.expect(s.do(http.MethodGet, "/users/"+alice.String()+"/calendar", "", asAlice...), http.StatusOK, nil)
+
+    var budget models.Budget
+    s.expect(s.do(http.MethodPost, "/budgets", `{"Amount":1000}`, asAlice...), http.StatusCreated, &budget)
+    if p := s.problem(s.do(http.MethodGet, "/budgets/"+budget.ID.String(), "", asBob...)); p.Code != "budget_not_found" {
+        t.Errorf("another user's budget answered %+v", p)
+    }
+}
+
+func TestAdminOnlyRoutes(t *testing.T) {
+    s := newAuthServer(t)
+    asUser := []string{"Authorization", bearer(t, uuid.NewString())}
+    asAdmin := []string{"Authorization", bearer(t, "ops", "admin")}
+    webhook := `{"URL":"https://hooks.example.com/subs","Secret":"0123456789abcdef","Events":["subscription.created"]}`
+
+    if p := s.problem(s.do(http.MethodPost, "/webhooks", webhook, asUser...)); p.Status != http.StatusForbidden || p.Code != apierror.CodeForbidden {
+        t.Errorf("user registering a webhook answered %+v", p)
+    }
+    s.expect(s.do(http.MethodGet, "/exchange-rates", "", asUser...), http.StatusOK, nil)
+    s.expect(s.do(http.MethodPost, "/exchange-rates", `[]`, asUser...), http.StatusForbidden, nil)
+    s.expect(s.do(http.MethodPost, "/webhooks", webhook, asAdmin...), http.StatusCreated, nil)
+}
+
+func TestIdempotencyKeysArePerCaller(t *testing.T) {
+    s := newAuthServer(t)
+    body := `{"ServiceName":"Netflix","Price":400,"StartDate":"01-2026"}`
+    asAlice := []string{"Authorization", bearer(t, uuid.NewString()), "Idempotency-Key", "k1"}
+    asBob := []string{"Authorization", bearer(t, uuid.NewString()), "Idempotency-Key", "k1"}
+
+    var first, second models.Subscription
+    s.expect(s.do(http.MethodPost, "/subscriptions", body, asAlice...), http.StatusCreated, &first)
+    w := s.do(http.MethodPost, "/subscriptions", body, asBob...)
+    s.expect(w, http.StatusCreated, &second)
+    if second.ID == first.ID || second.UserID == first.UserID || w.Header().Get(middleware.IdempotentReplayedHeader) != "" {
+        t.Errorf("another caller reusing the key got %+v, a replay of %+v", second, first)
+    }
+
+    var replay models.Subscription
+    w = s.do(http.MethodPost, "/subscriptions", body, asAlice...)
+    s.expect(w, http.StatusCreated, &replay)
+    if replay.ID != first.ID || w.Header().Get(middleware.IdempotentReplayedHeader) != "true" {
+        t.Errorf("retry got %+v, want a replay of %+v", replay, first)
+    }
+}
+
+func TestIdempotencyKeysArePerTenant(t *testing.T) {
+    s := newServerWith(t, &config.Config{IdempotencyTTL: time.Hour})
+    s.createTenant("payments")
+    body := subscriptionJSON(uuid.New(), "Netflix", 400)
+
+    var first, second models.Subscription
+    s.expect(s.do(http.MethodPost, "/subscriptions", body, "Idempotency-Key", "k1"), http.StatusCreated, &first)
+    w := s.do(http.MethodPost, "/subscriptions", body, "Idempotency-Key", "k1", tenant.Header, "payments")
+    s.expect(w, http.StatusCreated, &second)
+    if second.ID == first.ID || second.TenantID == first.TenantID || w.Header().Get(middleware.IdempotentReplayedHeader) != "" {
+        t.Errorf("another tenant reusing the key got %+v, a replay of %+v", second, first)
+    }
+}
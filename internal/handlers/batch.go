@@ -0,0 +1,260 @@
+package handlers
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "go.uber.org/zap"
+
+    "service/internal/apierror"
+    "service/internal/logger"
+    "service/internal/middleware"
+    "service/internal/models"
+    "service/internal/validation"
+)
+
+// BatchMode selects how a batch handles failed operations.
+type BatchMode string
+
+const (
+    // BatchAtomic applies every operation or, if any fails, none of them.
+    BatchAtomic BatchMode = "atomic"
+    // BatchBestEffort applies the operations that succeed and reports the
+    // others.
+    BatchBestEffort BatchMode = "best_effort"
+)
+
+// BatchOp is the kind of a batch operation.
+type BatchOp string
+
+const (
+    BatchCreate BatchOp = "create"
+    BatchUpdate BatchOp = "update"
+    BatchDelete BatchOp = "delete"
+)
+
+const maxBatchOperations = 1000
+
+// BatchRequest is a list of subscription operations applied in one
+// transaction.
+type BatchRequest struct {
+    Mode       BatchMode        `json:"mode" enums:"atomic,best_effort" example:"atomic"`
+    Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation creates, replaces or deletes one subscription. Update and
+// delete name the subscription by ID and may carry the ETag it was read at.
+type BatchOperation struct {
+    Op           BatchOp         `json:"op" enums:"create,update,delete"`
+    ID           string          `json:"id,omitempty"`
+    IfMatch      string          `json:"if_match,omitempty"`
+    Subscription json.RawMessage `json:"subscription,omitempty" swaggertype:"object"`
+}
+
+// BatchItemResult is the outcome of one operation. Status is the HTTP status
+// the operation would have had on its own endpoint, or 424 Failed Dependency
+// for an operation rolled back because another one in an atomic batch failed.
+type BatchItemResult struct {
+    Index        int                  `json:"index"`
+    Op           BatchOp              `json:"op"`
+    Status       int                  `json:"status"`
+    ID           string               `json:"id,omitempty"`
+    Subscription *models.Subscription `json:"subscription,omitempty"`
+    Error        *apierror.Problem    `json:"error,omitempty"`
+}
+
+// BatchResult reports the outcome of every operation of a batch.
+type BatchResult struct {
+    Mode      BatchMode         `json:"mode"`
+    Succeeded int               `json:"succeeded"`
+    Failed    int               `json:"failed"`
+    Results   []BatchItemResult `json:"results"`
+}
+
+var errBatchFailed = errors.New("batch operation failed")
+
+// @Summary Apply subscription operations in bulk
+// @Description Create, replace and delete subscriptions in one transaction. In atomic mode (the default) a single failure rolls back the whole batch and the response is 422; in best_effort mode failed operations are skipped. Every operation is reported with its own status.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "Client-chosen key that makes retries of this request return the original response"
+// @Param batch body BatchRequest true "Operations to apply, at most 1000"
+// @Success 200 {object} BatchResult
+// @Failure 400 {object} apierror.Problem
+// @Failure 409 {object} apierror.Problem
+// @Failure 422 {object} BatchResult
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions:batch [post]
+func (h *SubscriptionHandler) BatchSubscriptions(c *gin.Context) {
+    var req BatchRequest
+    if !bindJSON(c, &req) {
+        return
+    }
+    if req.Mode == "" {
+        req.Mode = BatchAtomic
+    }
+    var errs validation.Errors
+    if req.Mode != BatchAtomic && req.Mode != BatchBestEffort {
+        errs.Add("mode", req.Mode, validation.CodeUnsupported, "must be atomic or best_effort")
+    }
+    if len(req.Operations) == 0 {
+        errs.Add("operations", nil, validation.CodeRequired, "must contain at least one operation")
+    } else if len(req.Operations) > maxBatchOperations {
+        errs.Add("operations", len(req.Operations), validation.CodeOutOfRange, fmt.Sprintf("must contain at most %d operations", maxBatchOperations))
+    }
+    if len(errs) > 0 {
+        c.Error(apierror.Invalid(http.StatusUnprocessableEntity, errs))
+        return
+    }
+
+    result := BatchResult{Mode: req.Mode, Results: make([]BatchItemResult, len(req.Operations))}
+    err := h.tx.InTx(c.Request.Context(), func(ctx context.Context) error {
+        for i, op := range req.Operations {
+            result.Results[i] = h.applyBatchOperation(ctx, c, i, op)
+            if result.Results[i].Error != nil {
+                result.Failed++
+            } else {
+                result.Succeeded++
+            }
+        }
+        if req.Mode == BatchAtomic && result.Failed > 0 {
+            return errBatchFailed
+        }
+        return nil
+    })
+    if err != nil && !errors.Is(err, errBatchFailed) {
+        c.Error(apierror.Internal(fmt.Errorf("apply subscription batch: %w", err)))
+        return
+    }
+
+    status := http.StatusOK
+    if errors.Is(err, errBatchFailed) {
+        status = http.StatusUnprocessableEntity
+        for i := range result.Results {
+            if result.Results[i].Error == nil {
+                result.Results[i].Status = http.StatusFailedDependency
+                result.Results[i].Subscription = nil
+            }
+        }
+        result.Succeeded = 0
+        result.Failed = len(result.Results)
+    }
+
+    logger.Log.Info("Subscription batch applied",
+        zap.String("mode", string(req.Mode)),
+        zap.Int("operations", len(req.Operations)),
+        zap.Int("succeeded", result.Succeeded),
+        zap.Int("failed", result.Failed),
+    )
+    c.JSON(status, result)
+}
+
+// applyBatchOperation runs one operation in a savepoint of the batch
+// transaction, so a failed operation leaves the others intact.
+func (h *SubscriptionHandler) applyBatchOperation(ctx context.Context, c *gin.Context, index int, op BatchOperation) BatchItemResult {
+    result := BatchItemResult{Index: index, Op: op.Op, ID: op.ID}
+
+    var sub *models.Subscription
+    var opErr *apierror.Error
+    err := h.tx.InTx(ctx, func(ctx context.Context) error {
+        sub, opErr = h.batchOperation(ctx, op)
+        if opErr != nil {
+            return opErr
+        }
+        return nil
+    })
+    if err != nil && opErr == nil {
+        opErr = apierror.Internal(err)
+    }
+
+    if opErr != nil {
+        if opErr.Cause != nil {
+            logger.Log.Error("Subscription batch operation failed",
+                zap.String("request_id", middleware.GetRequestID(c)),
+                zap.Int("index", index),
+                zap.Error(opErr.Cause),
+            )
+        }
+        problem := opErr.Problem("", "")
+        result.Status = opErr.Status
+        result.Error = &problem
+        return result
+    }
+
+    result.Status = http.StatusOK
+    if op.Op == BatchCreate {
+        result.Status = http.StatusCreated
+    }
+    result.ID = sub.ID.String()
+    if op.Op != BatchDelete {
+        result.Subscription = sub
+    }
+    return result
+}
+
+func (h *SubscriptionHandler) batchOperation(ctx context.Context, op BatchOperation) (*models.Subscription, *apierror.Error) {
+    switch op.Op {
+    case BatchCreate:
+        var sub models.Subscription
+        if err := decodeBatchSubscription(op, &sub); err != nil {
+            return nil, err
+        }
+        if err := h.createSubscription(ctx, &sub); err != nil {
+            return nil, err
+        }
+        return &sub, nil
+
+    case BatchUpdate:
+        current, err := h.getSubscription(ctx, op.ID)
+        if err != nil {
+            return nil, err
+        }
+        if err := h.ifMatch(op.IfMatch, current); err != nil {
+            return nil, err
+        }
+        var sub models.Subscription
+        if err := decodeBatchSubscription(op, &sub); err != nil {
+            return nil, err
+        }
+        sub.ID = current.ID
+        sub.Version = current.Version
+        sub.TenantID = current.TenantID
+        if err := h.replaceSubscription(ctx, &sub); err != nil {
+            return nil, err
+        }
+        return &sub, nil
+
+    case BatchDelete:
+        current, err := h.getSubscription(ctx, op.ID)
+        if err != nil {
+            return nil, err
+        }
+        if err := h.ifMatch(op.IfMatch, current); err != nil {
+            return nil, err
+        }
+        if err := h.deleteSubscription(ctx, current); err != nil {
+            return nil, err
+        }
+        return current, nil
+    }
+
+    var errs validation.Errors
+    errs.Add("op", op.Op, validation.CodeUnsupported, "must be create, update or delete")
+    return nil, apierror.Invalid(http.StatusUnprocessableEntity, errs)
+}
+
+func decodeBatchSubscription(op BatchOperation, sub *models.Subscription) *apierror.Error {
+    if len(op.Subscription) == 0 {
+        var errs validation.Errors
+        errs.Add("subscription", nil, validation.CodeRequired, "is required for "+string(op.Op))
+        return apierror.Invalid(http.StatusUnprocessableEntity, errs)
+    }
+    return decodeJSON(op.Subscription, sub)
+}
@@ -0,0 +1,117 @@
+package handlers_test
+
+import (
+    "net/http"
+    "testing"
+
+    "github.com/google/uuid"
+
+    "service/internal/handlers"
+    "service/internal/models"
+)
+
+const batchPath = "/subscriptions:batch"
+
+func TestAtomicBatchRollsBackEveryOperation(t *testing.T) {
+    s := newServer(t)
+    user := uuid.New()
+    var existing models.Subscription
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(user, "Netflix", 100)), http.StatusCreated, &existing)
+
+    body := `{"operations":[
+        {"op":"create","subscription":` + subscriptionJSON(user, "Spotify", 200) + `},
+        {"op":"update","id":"` + existing.ID.String() + `","subscription":` + subscriptionJSON(user, "Netflix 4K", 100) + `},
+        {"op":"delete","id":"` + uuid.NewString() + `"}
+    ]}`
+    var result handlers.BatchResult
+    s.expect(s.do(http.MethodPost, batchPath, body), http.StatusUnprocessableEntity, &result)
+    if result.Mode != handlers.BatchAtomic || result.Succeeded != 0 || result.Failed != 3 {
+        t.Errorf("result %+v, want every operation failed", result)
+    }
+    wantStatus := []int{http.StatusFailedDependency, http.StatusFailedDependency, http.StatusNotFound}
+    for i, item := range result.Results {
+        if item.Index != i || item.Status != wantStatus[i] {
+            t.Errorf("operation %d reported %d, want %d", i, item.Status, wantStatus[i])
+        }
+    }
+
+    var page handlers.SubscriptionPage
+    s.expect(s.do(http.MethodGet, "/subscriptions", ""), http.StatusOK, &page)
+    if page.Total != 1 || page.Items[0].ServiceName != "Netflix" {
+        t.Errorf("after a failed atomic batch the store holds %+v, want only the untouched subscription", page.Items)
+    }
+
+    body = `{"operations":[
+        {"op":"create","subscription":` + subscriptionJSON(user, "Spotify", 200) + `},
+        {"op":"update","id":"` + existing.ID.String() + `","if_match":"\"1\"","subscription":` + subscriptionJSON(user, "Netflix 4K", 100) + `}
+    ]}`
+    s.expect(s.do(http.MethodPost, batchPath, body), http.StatusOK, &result)
+    if result.Succeeded != 2 || result.Results[0].Status != http.StatusCreated || result.Results[1].Subscription.Version != 2 {
+        t.Errorf("result %+v, want both operations applied", result)
+    }
+}
+
+func TestBestEffortBatchKeepsSuccessfulOperations(t *testing.T) {
+    s := newServer(t)
+    user := uuid.New()
+    var stale, doomed models.Subscription
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(user, "Netflix", 100)), http.StatusCreated, &stale)
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(user, "Hulu", 50)), http.StatusCreated, &doomed)
+
+    body := `{"mode":"best_effort","operations":[
+        {"op":"create","subscription":` + subscriptionJSON(user, "Spotify", 200) + `},
+        {"op":"create","subscription":{"ServiceName":"","Price":-1}},
+        {"op":"update","id":"` + stale.ID.String() + `","if_match":"\"9\"","subscription":` + subscriptionJSON(user, "Netflix 4K", 100) + `},
+        {"op":"delete","id":"` + doomed.ID.String() + `"},
+        {"op":"rename"}
+    ]}`
+    var result handlers.BatchResult
+    s.expect(s.do(http.MethodPost, batchPath, body), http.StatusOK, &result)
+    if result.Succeeded != 2 || result.Failed != 3 {
+        t.Errorf("succeeded %d, failed %d; want 2 and 3", result.Succeeded, result.Failed)
+    }
+    wantStatus := []int{http.StatusCreated, http.StatusUnprocessableEntity, http.StatusPreconditionFailed, http.StatusOK, http.StatusUnprocessableEntity}
+    for i, item := range result.Results {
+        if item.Status != wantStatus[i] {
+            t.Errorf("operation %d reported %d, want %d", i, item.Status, wantStatus[i])
+        }
+        if (item.Error != nil) != (item.Status >= 400) {
+            t.Errorf("operation %d: status %d with error %+v", i, item.Status, item.Error)
+        }
+    }
+
+    var page handlers.SubscriptionPage
+    s.expect(s.do(http.MethodGet, "/subscriptions", ""), http.StatusOK, &page)
+    var names []string
+    for _, item := range page.Items {
+        names = append(names, item.ServiceName)
+        if item.ServiceName == "Netflix 4K" {
+            t.Errorf("stale update applied: %+v", item)
+        }
+    }
+    if len(names) != 2 {
+        t.Errorf("store holds %v, want Netflix and Spotify", names)
+    }
+}
+
+func TestBatchRejectsMalformedRequests(t *testing.T) {
+    s := newServer(t)
+    for _, body := range []string{
+        `{"operations":[]}`,
+        `{"mode":"eventually","operations":[{"op":"delete","id":"x"}]}`,
+    } {
+        s.expect(s.do(http.MethodPost, batchPath, body), http.StatusUnprocessableEntity, nil)
+    }
+    s.expect(s.do(http.MethodPost, batchPath, `{"operations":{}}`), http.StatusBadRequest, nil)
+}
+
+func TestUnknownCustomMethods(t *testing.T) {
+    s := newServer(t)
+    body := `{"operations":[{"op":"delete","id":"` + uuid.NewString() + `"}]}`
+    for _, path := range []string{"/subscriptions:purge", "/subscriptions:", "/subscriptionsbatch"} {
+        if p := s.problem(s.do(http.MethodPost, path, body)); p.Status != http.StatusNotFound || p.Code != "route_not_found" {
+            t.Errorf("%s answered %+v", path, p)
+        }
+    }
+    s.expect(s.do(http.MethodGet, batchPath, ""), http.StatusNotFound, nil)
+}
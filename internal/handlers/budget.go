@@ -0,0 +1,366 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "go.uber.org/zap"
+
+    "service/internal/apierror"
+    "service/internal/auth"
+    "service/internal/billing"
+    "service/internal/logger"
+    "service/internal/models"
+    "service/internal/repository"
+    "service/internal/validation"
+)
+
+// BudgetHandler serves the budget endpoints.
+type BudgetHandler struct {
+    budgets repository.BudgetRepository
+    tracker *budgetTracker
+}
+
+func NewBudgetHandler(repos repository.Repositories) *BudgetHandler {
+    return &BudgetHandler{budgets: repos.Budgets, tracker: newBudgetTracker(repos)}
+}
+
+// BudgetStatusReport is the spend against every budget of a user in the
+// current and the next month.
+type BudgetStatusReport struct {
+    UserID  uuid.UUID              `json:"user_id"`
+    Budgets []billing.BudgetStatus `json:"budgets"`
+}
+
+// @Summary Create a budget
+// @Description Set a monthly spending cap for a user, across all subscriptions or, with a Category, across the subscriptions of that category. A user has at most one budget per category.
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param budget body models.Budget true "Budget"
+// @Success 201 {object} models.Budget
+// @Failure 400 {object} apierror.Problem
+// @Failure 409 {object} apierror.Problem
+// @Failure 422 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /budgets [post]
+func (h *BudgetHandler) CreateBudget(c *gin.Context) {
+    var budget models.Budget
+    if !bindJSON(c, &budget) {
+        return
+    }
+    if !h.scopeBudget(c, &budget) {
+        return
+    }
+    normalizeBudget(&budget)
+    if errs := validation.Budget(&budget); len(errs) > 0 {
+        c.Error(apierror.Invalid(http.StatusUnprocessableEntity, errs))
+        return
+    }
+
+    budget.ID = uuid.New()
+    budget.CreatedAt = time.Now().UTC()
+    budget.UpdatedAt = budget.CreatedAt
+    if err := h.budgets.Create(c.Request.Context(), &budget); err != nil {
+        c.Error(budgetError(err, "create budget"))
+        return
+    }
+
+    logger.Log.Info("Budget created",
+        zap.String("id", budget.ID.String()),
+        zap.String("user_id", budget.UserID.String()),
+        zap.String("category", budget.Category),
+        zap.Int("amount", budget.Amount),
+        zap.String("currency", budget.Currency),
+    )
+    c.JSON(http.StatusCreated, budget)
+}
+
+// @Summary List budgets
+// @Description Get the budgets of a user, or of every user
+// @Tags budgets
+// @Produce json
+// @Param user_id query string false "User ID"
+// @Success 200 {array} models.Budget
+// @Failure 400 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /budgets [get]
+func (h *BudgetHandler) ListBudgets(c *gin.Context) {
+    q := validation.NewQuery(c.Request.URL.Query())
+    userID := q.UUID("user_id")
+    if len(q.Errors) > 0 {
+        c.Error(apierror.Invalid(http.StatusBadRequest, q.Errors))
+        return
+    }
+    userID, err := scopeUser(c.Request.Context(), userID)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    budgets, listErr := h.budgets.List(c.Request.Context(), userID)
+    if listErr != nil {
+        c.Error(apierror.Internal(fmt.Errorf("list budgets: %w", listErr)))
+        return
+    }
+    if budgets == nil {
+        budgets = []models.Budget{}
+    }
+    c.JSON(http.StatusOK, budgets)
+}
+
+// @Summary Get a budget
+// @Description Get budget by ID
+// @Tags budgets
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Success 200 {object} models.Budget
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /budgets/{id} [get]
+func (h *BudgetHandler) GetBudget(c *gin.Context) {
+    budget, ok := h.loadBudget(c)
+    if !ok {
+        return
+    }
+    c.JSON(http.StatusOK, budget)
+}
+
+// @Summary Update a budget
+// @Description Replace budget by ID. An omitted Category makes it the budget across all subscriptions.
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Param budget body models.Budget true "Budget"
+// @Success 200 {object} models.Budget
+// @Failure 400 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 409 {object} apierror.Problem
+// @Failure 422 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /budgets/{id} [put]
+func (h *BudgetHandler) UpdateBudget(c *gin.Context) {
+    current, ok := h.loadBudget(c)
+    if !ok {
+        return
+    }
+
+    var budget models.Budget
+    if !bindJSON(c, &budget) {
+        return
+    }
+    if !h.scopeBudget(c, &budget) {
+        return
+    }
+    normalizeBudget(&budget)
+    if errs := validation.Budget(&budget); len(errs) > 0 {
+        c.Error(apierror.Invalid(http.StatusUnprocessableEntity, errs))
+        return
+    }
+
+    budget.ID = current.ID
+    budget.TenantID = current.TenantID
+    budget.CreatedAt = current.CreatedAt
+    budget.UpdatedAt = time.Now().UTC()
+    if err := h.budgets.Update(c.Request.Context(), &budget); err != nil {
+        c.Error(budgetError(err, "update budget "+budget.ID.String()))
+        return
+    }
+
+    logger.Log.Info("Budget updated",
+        zap.String("id", budget.ID.String()),
+        zap.String("user_id", budget.UserID.String()),
+        zap.Int("amount", budget.Amount),
+    )
+    c.JSON(http.StatusOK, budget)
+}
+
+// @Summary Delete a budget
+// @Description Delete budget by ID
+// @Tags budgets
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /budgets/{id} [delete]
+func (h *BudgetHandler) DeleteBudget(c *gin.Context) {
+    budget, ok := h.loadBudget(c)
+    if !ok {
+        return
+    }
+    if err := h.budgets.Delete(c.Request.Context(), budget.ID); err != nil {
+        c.Error(budgetError(err, "delete budget "+budget.ID.String()))
+        return
+    }
+
+    logger.Log.Info("Budget deleted", zap.String("id", budget.ID.String()))
+    c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// @Summary Get budget status
+// @Description Get the projected spend against every budget of a user in the current and the next month, computed like the summary
+// @Tags budgets
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {object} BudgetStatusReport
+// @Failure 404 {object} apierror.Problem
+// @Failure 422 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users/{user_id}/budget-status [get]
+func (h *BudgetHandler) GetBudgetStatus(c *gin.Context) {
+    userID, err := uuid.Parse(c.Param("user_id"))
+    if err != nil {
+        c.Error(apierror.NotFound("user_not_found", "user not found"))
+        return
+    }
+    if !scopePath(c, userID) {
+        return
+    }
+
+    statuses, err := h.tracker.statuses(c.Request.Context(), userID, nil, time.Now())
+    if err != nil {
+        if errors.Is(err, billing.ErrNoRate) {
+            c.Error(apierror.Unprocessable(apierror.CodeRateMissing, err.Error()).WithCause(err))
+            return
+        }
+        c.Error(apierror.Internal(fmt.Errorf("compute budget status of %s: %w", userID, err)))
+        return
+    }
+    c.JSON(http.StatusOK, BudgetStatusReport{UserID: userID, Budgets: statuses})
+}
+
+// loadBudget fetches the budget named by the id path parameter. On failure
+// the response is written and ok is false.
+func (h *BudgetHandler) loadBudget(c *gin.Context) (*models.Budget, bool) {
+    id, err := uuid.Parse(c.Param("id"))
+    if err != nil {
+        c.Error(budgetNotFound())
+        return nil, false
+    }
+    budget, err := h.budgets.Get(c.Request.Context(), id)
+    if err != nil {
+        c.Error(budgetError(err, "get budget "+id.String()))
+        return nil, false
+    }
+    if !auth.CanAccess(c.Request.Context(), budget.UserID) {
+        c.Error(budgetNotFound())
+        return nil, false
+    }
+    return budget, true
+}
+
+// scopeBudget defaults the user of a budget to the caller and rejects budgets
+// of other users. On failure the response is written and ok is false.
+func (h *BudgetHandler) scopeBudget(c *gin.Context, budget *models.Budget) bool {
+    userID, err := scopeUser(c.Request.Context(), budget.UserID)
+    if err != nil {
+        c.Error(err)
+        return false
+    }
+    budget.UserID = userID
+    return true
+}
+
+func normalizeBudget(budget *models.Budget) {
+    budget.Category = strings.ToLower(strings.TrimSpace(budget.Category))
+    budget.Currency = strings.ToUpper(budget.Currency)
+    if budget.Currency == "" {
+        budget.Currency = models.DefaultCurrency
+    }
+}
+
+func budgetNotFound() *apierror.Error {
+    return apierror.NotFound("budget_not_found", "budget not found")
+}
+
+// budgetError maps a repository error of the named operation to an API error.
+func budgetError(err error, op string) *apierror.Error {
+    switch {
+    case errors.Is(err, repository.ErrNotFound):
+        return budgetNotFound()
+    case errors.Is(err, repository.ErrConflict):
+        return apierror.Conflict("budget_exists", "the user already has a budget for this category")
+    }
+    return apierror.Internal(fmt.Errorf("%s: %w", op, err))
+}
+
+// budgetTracker computes the spend against budgets.
+type budgetTracker struct {
+    budgets repository.BudgetRepository
+    subs    repository.SubscriptionRepository
+    prices  repository.PriceRepository
+    rates   repository.ExchangeRateRepository
+}
+
+func newBudgetTracker(repos repository.Repositories) *budgetTracker {
+    return &budgetTracker{budgets: repos.Budgets, subs: repos.Subscriptions, prices: repos.Prices, rates: repos.ExchangeRates}
+}
+
+// statuses returns the status of the user's budgets in billing.BudgetWindow,
+// or only of the budgets covering the given subscription when it is set.
+func (t *budgetTracker) statuses(ctx context.Context, userID uuid.UUID, covering *models.Subscription, now time.Time) ([]billing.BudgetStatus, error) {
+    budgets, err := t.budgets.List(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("list budgets: %w", err)
+    }
+    if covering != nil {
+        relevant := budgets[:0]
+        for _, budget := range budgets {
+            if billing.BudgetCovers(budget, *covering) {
+                relevant = append(relevant, budget)
+            }
+        }
+        budgets = relevant
+    }
+    statuses := []billing.BudgetStatus{}
+    if len(budgets) == 0 {
+        return statuses, nil
+    }
+
+    window := billing.BudgetWindow(now)
+    subs, err := t.subs.List(ctx, repository.SubscriptionFilter{
+        UserID:        userID,
+        EndsOnOrAfter: window.From,
+        StartsBefore:  window.To.AddDate(0, 1, 0),
+    })
+    if err != nil {
+        return nil, fmt.Errorf("list subscriptions: %w", err)
+    }
+    prices, err := loadPrices(ctx, t.prices, subs)
+    if err != nil {
+        return nil, fmt.Errorf("load price changes: %w", err)
+    }
+    rates, err := loadRates(ctx, t.rates, window.To)
+    if err != nil {
+        return nil, fmt.Errorf("load exchange rates: %w", err)
+    }
+
+    for _, budget := range budgets {
+        status, err := billing.CheckBudget(budget, subs, window, prices, rates)
+        if err != nil {
+            return nil, err
+        }
+        statuses = append(statuses, status)
+    }
+    return statuses, nil
+}
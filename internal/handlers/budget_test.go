@@ -0,0 +1,82 @@
+package handlers_test
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+
+    "github.com/google/uuid"
+
+    "service/internal/handlers"
+    "service/internal/models"
+)
+
+func budgetJSON(userID uuid.UUID, category string, amount int) string {
+    return fmt.Sprintf(`{"UserID":"%s","Category":%q,"Amount":%d}`, userID, category, amount)
+}
+
+func TestBudgets(t *testing.T) {
+    s := newServer(t)
+    user := uuid.New()
+
+    var budget models.Budget
+    s.expect(s.do(http.MethodPost, "/budgets", budgetJSON(user, " Streaming ", 1000)), http.StatusCreated, &budget)
+    if budget.Category != "streaming" || budget.Currency != models.DefaultCurrency {
+        t.Errorf("created %+v", budget)
+    }
+    if p := s.problem(s.do(http.MethodPost, "/budgets", budgetJSON(user, "streaming", 500))); p.Code != "budget_exists" {
+        t.Errorf("duplicate category answered %+v", p)
+    }
+    s.expect(s.do(http.MethodPost, "/budgets", budgetJSON(user, "", 5000)), http.StatusCreated, nil)
+    if got := s.fields(s.do(http.MethodPost, "/budgets", `{"Amount":0,"Currency":"rubles"}`)); len(got) != 3 {
+        t.Errorf("invalid budget reported %v", got)
+    }
+
+    var budgets []models.Budget
+    s.expect(s.do(http.MethodGet, "/budgets?user_id="+user.String(), ""), http.StatusOK, &budgets)
+    if len(budgets) != 2 {
+        t.Errorf("listed %+v", budgets)
+    }
+
+    path := "/budgets/" + budget.ID.String()
+    s.expect(s.do(http.MethodPut, path, budgetJSON(user, "streaming", 1500)), http.StatusOK, &budget)
+    s.expect(s.do(http.MethodGet, path, ""), http.StatusOK, &budget)
+    if budget.Amount != 1500 {
+        t.Errorf("updated amount %d", budget.Amount)
+    }
+    s.expect(s.do(http.MethodDelete, path, ""), http.StatusOK, nil)
+    if p := s.problem(s.do(http.MethodGet, path, "")); p.Code != "budget_not_found" {
+        t.Errorf("deleted budget answered %+v", p)
+    }
+}
+
+func TestSavedSubscriptionsReportBudgetAlerts(t *testing.T) {
+    s := newServer(t)
+    user := uuid.New()
+    var budget models.Budget
+    s.expect(s.do(http.MethodPost, "/budgets", budgetJSON(user, "streaming", 500)), http.StatusCreated, &budget)
+
+    streaming := `{"ServiceName":"Netflix","Category":"Streaming","Price":%d,"UserID":"%s","StartDate":"01-2026"}`
+    var saved handlers.SavedSubscription
+    s.expect(s.do(http.MethodPost, "/subscriptions", fmt.Sprintf(streaming, 400, user)), http.StatusCreated, &saved)
+    if saved.Category != "streaming" || len(saved.BudgetAlerts) != 0 {
+        t.Errorf("within budget: %+v", saved)
+    }
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(user, "Gym", 900)), http.StatusCreated, &saved)
+    if len(saved.BudgetAlerts) != 0 {
+        t.Errorf("uncovered subscription alerted %+v", saved.BudgetAlerts)
+    }
+    s.expect(s.do(http.MethodPost, "/subscriptions", fmt.Sprintf(streaming, 200, user)), http.StatusCreated, &saved)
+    if len(saved.BudgetAlerts) != 1 || saved.BudgetAlerts[0].BudgetID != budget.ID || !saved.BudgetAlerts[0].OverBudget {
+        t.Errorf("over budget: %+v", saved.BudgetAlerts)
+    }
+
+    var report handlers.BudgetStatusReport
+    s.expect(s.do(http.MethodGet, "/users/"+user.String()+"/budget-status", ""), http.StatusOK, &report)
+    if len(report.Budgets) != 1 || len(report.Budgets[0].Months) != 2 || report.Budgets[0].Months[0].Spend != 600 {
+        t.Errorf("status %+v", report)
+    }
+    if p := s.problem(s.do(http.MethodGet, "/users/nobody/budget-status", "")); p.Status != http.StatusNotFound {
+        t.Errorf("unknown user answered %+v", p)
+    }
+}
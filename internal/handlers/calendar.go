@@ -0,0 +1,188 @@
+package handlers
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "go.uber.org/zap"
+
+    "service/internal/apierror"
+    "service/internal/calendar"
+    "service/internal/logger"
+    "service/internal/models"
+    "service/internal/repository"
+    "service/internal/tenant"
+)
+
+// CalendarHandler serves the per-user iCalendar feeds.
+type CalendarHandler struct {
+    subs   repository.SubscriptionRepository
+    prices repository.PriceRepository
+    feeds  repository.CalendarFeedRepository
+    secret []byte
+}
+
+func NewCalendarHandler(subs repository.SubscriptionRepository, prices repository.PriceRepository, feeds repository.CalendarFeedRepository, secret []byte) *CalendarHandler {
+    return &CalendarHandler{subs: subs, prices: prices, feeds: feeds, secret: secret}
+}
+
+// CalendarLink is the address of a user's calendar feed.
+type CalendarLink struct {
+    Token string `json:"token"`
+    Path  string `json:"path" example:"/users/60601fee-2bf1-4721-ae6f-7636e79a0cba/subscriptions.ics?token=..."`
+}
+
+// @Summary Get calendar feed link
+// @Description Get the secret address of a user's iCalendar feed of subscription renewals, to add to a calendar app
+// @Tags calendar
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {object} CalendarLink
+// @Failure 404 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users/{user_id}/calendar [get]
+func (h *CalendarHandler) GetCalendarLink(c *gin.Context) {
+    userID, err := uuid.Parse(c.Param("user_id"))
+    if err != nil {
+        c.Error(calendarNotFound())
+        return
+    }
+    if !scopePath(c, userID) {
+        return
+    }
+    nonce, err := h.nonce(c.Request.Context(), userID)
+    if err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("get calendar feed of %s: %w", userID, err)))
+        return
+    }
+    c.JSON(http.StatusOK, h.link(c.Request.Context(), userID, nonce))
+}
+
+// @Summary Rotate calendar feed link
+// @Description Replace the secret address of a user's iCalendar feed, revoking the previous one
+// @Tags calendar
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {object} CalendarLink
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users/{user_id}/calendar/rotate [post]
+func (h *CalendarHandler) RotateCalendarLink(c *gin.Context) {
+    userID, err := uuid.Parse(c.Param("user_id"))
+    if err != nil {
+        c.Error(calendarNotFound())
+        return
+    }
+    if !scopePath(c, userID) {
+        return
+    }
+    nonce, err := calendar.NewNonce()
+    if err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("generate calendar nonce: %w", err)))
+        return
+    }
+    feed := &models.CalendarFeed{UserID: userID, Nonce: nonce, RotatedAt: time.Now().UTC()}
+    if err := h.feeds.Save(c.Request.Context(), feed); err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("save calendar feed of %s: %w", userID, err)))
+        return
+    }
+
+    logger.Log.Info("Calendar link rotated", zap.String("user_id", userID.String()))
+    c.JSON(http.StatusOK, h.link(c.Request.Context(), userID, nonce))
+}
+
+// @Summary Get calendar feed
+// @Description Get an RFC 5545 feed with recurring events for the renewals of each of the user's subscriptions, split at price changes, and an event for each end date
+// @Tags calendar
+// @Produce text/calendar
+// @Param user_id path string true "User ID"
+// @Param token query string true "Feed token from the calendar link"
+// @Param tenant query string false "Tenant ID from the calendar link, the default tenant when omitted"
+// @Success 200 {string} string "iCalendar feed"
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /users/{user_id}/subscriptions.ics [get]
+func (h *CalendarHandler) GetCalendar(c *gin.Context) {
+    // An unknown user and a wrong token look the same, so the feed of a user
+    // cannot be probed for.
+    userID, err := uuid.Parse(c.Param("user_id"))
+    if err != nil {
+        c.Error(calendarNotFound())
+        return
+    }
+    tenantID := models.DefaultTenantID
+    if raw := c.Query("tenant"); raw != "" {
+        if tenantID, err = uuid.Parse(raw); err != nil {
+            c.Error(calendarNotFound())
+            return
+        }
+    }
+    ctx := tenant.NewContext(c.Request.Context(), tenantID)
+    nonce, err := h.nonce(ctx, userID)
+    if err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("get calendar feed of %s: %w", userID, err)))
+        return
+    }
+    if !calendar.ValidToken(h.secret, tenantID, userID, nonce, c.Query("token")) {
+        c.Error(calendarNotFound())
+        return
+    }
+
+    subs, err := h.subs.List(ctx, repository.SubscriptionFilter{UserID: userID})
+    if err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("list subscriptions of %s for calendar: %w", userID, err)))
+        return
+    }
+    prices, err := loadPrices(ctx, h.prices, subs)
+    if err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("list price changes of %s for calendar: %w", userID, err)))
+        return
+    }
+
+    var feed bytes.Buffer
+    if err := calendar.Write(&feed, "Subscriptions", subs, prices, time.Now()); err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("render calendar of %s: %w", userID, err)))
+        return
+    }
+
+    logger.Log.Info("Calendar served", zap.String("user_id", userID.String()), zap.Int("subscriptions", len(subs)))
+    c.Header("Cache-Control", "private, max-age=3600")
+    c.Data(http.StatusOK, calendar.ContentType, feed.Bytes())
+}
+
+// nonce returns the feed nonce of the user in the tenant of ctx, empty for
+// users whose feed was never rotated.
+func (h *CalendarHandler) nonce(ctx context.Context, userID uuid.UUID) (string, error) {
+    feed, err := h.feeds.Get(ctx, userID)
+    switch {
+    case errors.Is(err, repository.ErrNotFound):
+        return "", nil
+    case err != nil:
+        return "", err
+    }
+    return feed.Nonce, nil
+}
+
+// link builds the feed address of the user in the tenant of ctx.
+func (h *CalendarHandler) link(ctx context.Context, userID uuid.UUID, nonce string) CalendarLink {
+    tenantID, _ := tenant.FromContext(ctx)
+    token := calendar.Token(h.secret, tenantID, userID, nonce)
+    path := fmt.Sprintf("/users/%s/subscriptions.ics?token=%s", userID, token)
+    if tenantID != models.DefaultTenantID {
+        path += "&tenant=" + tenantID.String()
+    }
+    return CalendarLink{Token: token, Path: path}
+}
+
+func calendarNotFound() *apierror.Error {
+    return apierror.NotFound("calendar_not_found", "calendar not found")
+}
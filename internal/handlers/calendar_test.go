@@ -0,0 +1,76 @@
+package handlers_test
+
+import (
+    "net/http"
+    "strings"
+    "testing"
+
+    "github.com/google/uuid"
+
+    "service/internal/calendar"
+    "service/internal/config"
+    "service/internal/handlers"
+    "service/internal/models"
+)
+
+func TestCalendarFeed(t *testing.T) {
+    s := newServerWith(t, &config.Config{CalendarSecret: "secret"})
+    user := uuid.New()
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(user, "Netflix", 400)), http.StatusCreated, nil)
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(uuid.New(), "Hulu", 100)), http.StatusCreated, nil)
+
+    var link handlers.CalendarLink
+    s.expect(s.do(http.MethodGet, "/users/"+user.String()+"/calendar", ""), http.StatusOK, &link)
+    if link.Token != calendar.Token([]byte("secret"), models.DefaultTenantID, user, "") || !strings.HasSuffix(link.Path, "?token="+link.Token) {
+        t.Errorf("link %+v", link)
+    }
+
+    w := s.do(http.MethodGet, link.Path, "")
+    s.expect(w, http.StatusOK, nil)
+    if ct := w.Header().Get("Content-Type"); ct != calendar.ContentType {
+        t.Errorf("content type %q", ct)
+    }
+    if feed := w.Body.String(); !strings.Contains(feed, "Netflix renewal") || strings.Contains(feed, "Hulu") {
+        t.Errorf("feed of another user's subscriptions:\n%s", feed)
+    }
+
+    for _, path := range []string{
+        "/users/" + user.String() + "/subscriptions.ics?token=wrong",
+        "/users/" + uuid.NewString() + "/subscriptions.ics?token=" + link.Token,
+        "/users/not-a-uuid/subscriptions.ics?token=" + link.Token,
+        "/users/not-a-uuid/calendar",
+    } {
+        if p := s.problem(s.do(http.MethodGet, path, "")); p.Status != http.StatusNotFound || p.Code != "calendar_not_found" {
+            t.Errorf("%s answered %+v", path, p)
+        }
+    }
+}
+
+func TestRotateCalendarLink(t *testing.T) {
+    s := newServerWith(t, &config.Config{CalendarSecret: "secret"})
+    user := uuid.New()
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(user, "Netflix", 400)), http.StatusCreated, nil)
+
+    var old, rotated, current handlers.CalendarLink
+    s.expect(s.do(http.MethodGet, "/users/"+user.String()+"/calendar", ""), http.StatusOK, &old)
+    s.expect(s.do(http.MethodPost, "/users/"+user.String()+"/calendar/rotate", ""), http.StatusOK, &rotated)
+    s.expect(s.do(http.MethodGet, "/users/"+user.String()+"/calendar", ""), http.StatusOK, &current)
+    if rotated.Token == old.Token || current != rotated {
+        t.Errorf("links before %+v, rotated %+v, after %+v", old, rotated, current)
+    }
+    s.expect(s.do(http.MethodGet, old.Path, ""), http.StatusNotFound, nil)
+    s.expect(s.do(http.MethodGet, rotated.Path, ""), http.StatusOK, nil)
+}
+
+func TestCalendarNeedsSecret(t *testing.T) {
+    s := newServer(t)
+    user := uuid.NewString()
+    for _, path := range []string{
+        "/users/" + user + "/calendar",
+        "/users/" + user + "/subscriptions.ics?token=" + calendar.Token(nil, models.DefaultTenantID, uuid.MustParse(user), ""),
+    } {
+        if w := s.do(http.MethodGet, path, ""); w.Code != http.StatusNotFound {
+            t.Errorf("%s answered %d without a secret", path, w.Code)
+        }
+    }
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+    "fmt"
+    "net/http"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+    "go.uber.org/zap"
+
+    "service/internal/apierror"
+    "service/internal/billing"
+    "service/internal/logger"
+    "service/internal/models"
+    "service/internal/repository"
+    "service/internal/validation"
+)
+
+// ExchangeRateHandler serves the exchange rate endpoints.
+type ExchangeRateHandler struct {
+    rates repository.ExchangeRateRepository
+}
+
+func NewExchangeRateHandler(rates repository.ExchangeRateRepository) *ExchangeRateHandler {
+    return &ExchangeRateHandler{rates: rates}
+}
+
+// @Summary Load exchange rates
+// @Description Create or replace exchange rates, either as a JSON array or as CSV with a base_currency,quote_currency,rate,effective_date header. The rates are shared by every tenant, so only admins bound to no tenant may change them.
+// @Tags exchange-rates
+// @Accept json
+// @Accept text/csv
+// @Produce json
+// @Param rates body []models.ExchangeRate true "Exchange rates"
+// @Success 200 {object} map[string]int
+// @Failure 400 {object} apierror.Problem
+// @Failure 422 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Router /exchange-rates [post]
+func (h *ExchangeRateHandler) UpsertExchangeRates(c *gin.Context) {
+    var rates []models.ExchangeRate
+
+    if strings.HasPrefix(c.ContentType(), "text/csv") {
+        parsed, err := billing.ParseRatesCSV(c.Request.Body)
+        if err != nil {
+            c.Error(apierror.BadRequest(apierror.CodeMalformed, err.Error()).WithCause(err))
+            return
+        }
+        rates = parsed
+    } else if !bindJSON(c, &rates) {
+        return
+    }
+
+    var errs validation.Errors
+    for i := range rates {
+        rates[i].BaseCurrency = strings.ToUpper(rates[i].BaseCurrency)
+        rates[i].QuoteCurrency = strings.ToUpper(rates[i].QuoteCurrency)
+        errs = append(errs, validation.ExchangeRate(fmt.Sprintf("[%d].", i), &rates[i])...)
+    }
+    if len(errs) > 0 {
+        c.Error(apierror.Invalid(http.StatusUnprocessableEntity, errs))
+        return
+    }
+
+    if err := h.rates.Upsert(c.Request.Context(), rates); err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("save exchange rates: %w", err)))
+        return
+    }
+
+    logger.Log.Info("Exchange rates loaded", zap.Int("count", len(rates)))
+    c.JSON(http.StatusOK, gin.H{"loaded": len(rates)})
+}
+
+// @Summary List exchange rates
+// @Description Get exchange rates with optional currency filters
+// @Tags exchange-rates
+// @Produce json
+// @Param base_currency query string false "Base currency"
+// @Param quote_currency query string false "Quote currency"
+// @Success 200 {array} models.ExchangeRate
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /exchange-rates [get]
+func (h *ExchangeRateHandler) ListExchangeRates(c *gin.Context) {
+    rates, err := h.rates.List(c.Request.Context(), repository.ExchangeRateFilter{
+        BaseCurrency:  strings.ToUpper(c.Query("base_currency")),
+        QuoteCurrency: strings.ToUpper(c.Query("quote_currency")),
+    })
+    if err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("list exchange rates: %w", err)))
+        return
+    }
+    c.JSON(http.StatusOK, rates)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+    "fmt"
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "go.uber.org/zap"
+
+    "service/internal/apierror"
+    "service/internal/export"
+    "service/internal/logger"
+    "service/internal/models"
+    "service/internal/validation"
+)
+
+// exportFlushRows is how many rows are written between flushes of the
+// response.
+const exportFlushRows = 500
+
+// @Summary Export subscriptions
+// @Description Download every subscription matching the list filters as CSV, JSON Lines or XLSX, ordered by start date. Price is the base price at the start month; price changes are listed at /subscriptions/{id}/prices. The format is taken from the format parameter or else negotiated from the Accept header, defaulting to CSV. Rows are streamed as they are read.
+// @Tags subscriptions
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "Export format" Enums(csv, ndjson, xlsx)
+// @Param user_id query string false "User ID"
+// @Param service_name query []string false "Service names, matched case-insensitively by substring; repeat or comma-separate for any of several" collectionFormat(multi)
+// @Param min_price query int false "Minimum base price, the price at the start month before any price changes"
+// @Param max_price query int false "Maximum base price, the price at the start month before any price changes"
+// @Param start_after query string false "Only subscriptions starting after this date (YYYY-MM-DD)"
+// @Param start_before query string false "Only subscriptions starting before this date (YYYY-MM-DD)"
+// @Param ends_before query string false "Only subscriptions ending before this date (YYYY-MM-DD)"
+// @Param active_on query string false "Only subscriptions active in the month of this date (YYYY-MM-DD)"
+// @Param has_end_date query bool false "Only subscriptions with (true) or without (false) an end date"
+// @Param include_deleted query bool false "Also export subscriptions in the trash"
+// @Success 200 {file} file
+// @Failure 400 {object} apierror.Problem
+// @Failure 406 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/export [get]
+func (h *SubscriptionHandler) ExportSubscriptions(c *gin.Context) {
+    q := validation.NewQuery(c.Request.URL.Query())
+    filter := listFilter(q)
+    format := export.Format(q.String("format"))
+    if format != "" && !format.Valid() {
+        q.Fail("format", string(format), validation.CodeUnsupported, "must be csv, ndjson or xlsx")
+    }
+    if len(q.Errors) > 0 {
+        c.Error(apierror.Invalid(http.StatusBadRequest, q.Errors))
+        return
+    }
+    if !scopeFilter(c, &filter) {
+        return
+    }
+    if format == "" {
+        negotiated, ok := export.Negotiate(c.GetHeader("Accept"))
+        if !ok {
+            c.Error(apierror.New(http.StatusNotAcceptable, "export_format_not_acceptable", "the Accept header names no supported export format, use text/csv, application/x-ndjson or the XLSX media type"))
+            return
+        }
+        format = negotiated
+    }
+
+    c.Header("Content-Type", format.ContentType())
+    c.Header("Content-Disposition", `attachment; filename="subscriptions.`+string(format)+`"`)
+    c.Status(http.StatusOK)
+
+    writer, err := export.NewWriter(format, c.Writer)
+    if err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("start %s export: %w", format, err)))
+        return
+    }
+
+    rows := 0
+    err = h.subs.Each(c.Request.Context(), filter, func(sub models.Subscription) error {
+        if err := writer.Write(sub); err != nil {
+            return err
+        }
+        rows++
+        if rows%exportFlushRows == 0 {
+            c.Writer.Flush()
+        }
+        return nil
+    })
+    if err == nil {
+        err = writer.Close()
+    }
+    if err != nil {
+        if !c.Writer.Written() {
+            c.Writer.Header().Del("Content-Disposition")
+        }
+        c.Error(apierror.Internal(fmt.Errorf("export subscriptions as %s after %d rows: %w", format, rows, err)))
+        return
+    }
+
+    logger.Log.Info("Subscriptions exported",
+        zap.String("format", string(format)),
+        zap.Int("rows", rows),
+        zap.String("user_id", c.Query("user_id")),
+    )
+}
@@ -0,0 +1,47 @@
+package handlers_test
+
+import (
+    "encoding/csv"
+    "net/http"
+    "strings"
+    "testing"
+
+    "github.com/google/uuid"
+)
+
+func TestExportSubscriptions(t *testing.T) {
+    s := newServer(t)
+    user, other := uuid.New(), uuid.New()
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(user, "Netflix", 400)), http.StatusCreated, nil)
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(user, "Spotify", 200)), http.StatusCreated, nil)
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(other, "Hulu", 100)), http.StatusCreated, nil)
+
+    w := s.do(http.MethodGet, "/subscriptions/export?user_id="+user.String(), "")
+    s.expect(w, http.StatusOK, nil)
+    if ct, cd := w.Header().Get("Content-Type"), w.Header().Get("Content-Disposition"); ct != "text/csv" || !strings.Contains(cd, "subscriptions.csv") {
+        t.Errorf("headers %q, %q", ct, cd)
+    }
+    records, err := csv.NewReader(w.Body).ReadAll()
+    if err != nil || len(records) != 3 {
+        t.Fatalf("records %q, %v", records, err)
+    }
+
+    w = s.do(http.MethodGet, "/subscriptions/export", "", "Accept", "application/x-ndjson")
+    s.expect(w, http.StatusOK, nil)
+    if lines := strings.Count(w.Body.String(), "\n"); lines != 3 || w.Header().Get("Content-Type") != "application/x-ndjson" {
+        t.Errorf("ndjson export of %d lines: %s", lines, w.Body)
+    }
+
+    w = s.do(http.MethodGet, "/subscriptions/export?format=xlsx", "", "Accept", "text/csv")
+    s.expect(w, http.StatusOK, nil)
+    if !strings.HasPrefix(w.Body.String(), "PK") {
+        t.Errorf("xlsx export is not a zip archive")
+    }
+
+    if p := s.problem(s.do(http.MethodGet, "/subscriptions/export", "", "Accept", "application/pdf")); p.Status != http.StatusNotAcceptable {
+        t.Errorf("unsupported Accept answered %+v", p)
+    }
+    if got := s.fields(s.do(http.MethodGet, "/subscriptions/export?format=pdf&min_price=x", "")); len(got) != 2 {
+        t.Errorf("bad query reported %v", got)
+    }
+}
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+    "context"
+    "encoding/csv"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "go.uber.org/zap"
+
+    "service/internal/apierror"
+    "service/internal/logger"
+    "service/internal/models"
+    "service/internal/validation"
+)
+
+const (
+    maxImportRows  = 10000
+    maxImportBytes = 10 << 20
+)
+
+// importColumns maps the accepted CSV header names, case-insensitively, to
+// the column they fill.
+var importColumns = map[string]string{
+    "service_name":     "service_name",
+    "service":          "service_name",
+    "price":            "price",
+    "user_id":          "user_id",
+    "user":             "user_id",
+    "start":            "start",
+    "start_date":       "start",
+    "end":              "end",
+    "end_date":         "end",
+    "currency":         "currency",
+    "billing_interval": "billing_interval",
+    "interval":         "billing_interval",
+    "interval_count":   "interval_count",
+    "category":         "category",
+}
+
+var requiredImportColumns = []string{"service_name", "price", "user_id", "start"}
+
+// ImportRowError lists the problems of one CSV row. Row is the line number in
+// the file, the header being line 1.
+type ImportRowError struct {
+    Row    int               `json:"row"`
+    Errors validation.Errors `json:"errors"`
+}
+
+// ImportResult reports the outcome of a CSV import. Subscriptions are the
+// created ones, or the ones that would be created on a dry run.
+type ImportResult struct {
+    DryRun        bool                  `json:"dry_run"`
+    Rows          int                   `json:"rows"`
+    Created       int                   `json:"created"`
+    Subscriptions []models.Subscription `json:"subscriptions"`
+    Errors        []ImportRowError      `json:"errors"`
+}
+
+var errImportFailed = errors.New("import has invalid rows")
+
+// @Summary Import subscriptions from CSV
+// @Description Create subscriptions from CSV with a header naming the service_name, price, user_id, start and optional end, currency, billing_interval, interval_count and category columns. Months are MM-YYYY or YYYY-MM. Every row is validated like a created subscription; the rows are created in one transaction only if all of them are valid. With dry_run nothing is stored and the response previews the result. Imports over 10000 rows or 10 MB are rejected with 413.
+// @Tags subscriptions
+// @Accept text/csv
+// @Accept multipart/form-data
+// @Produce json
+// @Param dry_run query bool false "Validate and preview without creating anything"
+// @Param file formData file false "CSV file, when uploading as multipart/form-data"
+// @Success 200 {object} ImportResult
+// @Success 201 {object} ImportResult
+// @Failure 400 {object} apierror.Problem
+// @Failure 413 {object} apierror.Problem
+// @Failure 422 {object} ImportResult
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/import [post]
+func (h *SubscriptionHandler) ImportSubscriptions(c *gin.Context) {
+    q := validation.NewQuery(c.Request.URL.Query())
+    dryRun := q.Bool("dry_run")
+    if len(q.Errors) > 0 {
+        c.Error(apierror.Invalid(http.StatusBadRequest, q.Errors))
+        return
+    }
+
+    body, ok := importBody(c)
+    if !ok {
+        return
+    }
+    defer body.Close()
+
+    subs, rows, errs, err := parseImportCSV(body)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    result := ImportResult{
+        DryRun:        dryRun != nil && *dryRun,
+        Rows:          len(rows),
+        Subscriptions: []models.Subscription{},
+        Errors:        errs,
+    }
+    // A dry run only validates the rows, so it writes nothing and takes no
+    // locks. Imported rows always get new IDs, so they cannot conflict with
+    // stored subscriptions.
+    if result.DryRun {
+        for i := range subs {
+            if subs[i] == nil {
+                continue
+            }
+            if err := h.prepareSubscription(c.Request.Context(), subs[i]); err != nil {
+                result.Errors = append(result.Errors, ImportRowError{Row: rows[i], Errors: importErrors(err)})
+                continue
+            }
+            result.Subscriptions = append(result.Subscriptions, *subs[i])
+        }
+        c.JSON(http.StatusOK, result)
+        return
+    }
+
+    txErr := h.tx.InTx(c.Request.Context(), func(ctx context.Context) error {
+        for i := range subs {
+            if subs[i] == nil {
+                continue
+            }
+            if err := h.createSubscription(ctx, subs[i]); err != nil {
+                if err.Status >= http.StatusInternalServerError {
+                    return err
+                }
+                result.Errors = append(result.Errors, ImportRowError{Row: rows[i], Errors: importErrors(err)})
+                continue
+            }
+            result.Subscriptions = append(result.Subscriptions, *subs[i])
+        }
+        if len(result.Errors) > 0 {
+            return errImportFailed
+        }
+        return nil
+    })
+
+    status := http.StatusCreated
+    switch {
+    case errors.Is(txErr, errImportFailed):
+        status = http.StatusUnprocessableEntity
+        result.Subscriptions = []models.Subscription{}
+    case txErr != nil:
+        c.Error(apierror.Internal(fmt.Errorf("import subscriptions: %w", txErr)))
+        return
+    default:
+        result.Created = len(result.Subscriptions)
+    }
+
+    logger.Log.Info("Subscriptions imported",
+        zap.Int("rows", result.Rows),
+        zap.Int("created", result.Created),
+        zap.Int("invalid_rows", len(result.Errors)),
+    )
+    c.JSON(status, result)
+}
+
+// importBody returns the CSV sent either as the request body or as the file
+// field of a multipart form. Reading more than maxImportBytes of the request
+// fails with an *http.MaxBytesError, so oversized imports are rejected rather
+// than cut short. On failure the response is written and ok is false.
+func importBody(c *gin.Context) (io.ReadCloser, bool) {
+    c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+    if !strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+        return c.Request.Body, true
+    }
+    header, err := c.FormFile("file")
+    var tooLarge *http.MaxBytesError
+    if errors.As(err, &tooLarge) {
+        c.Error(importTooLarge())
+        return nil, false
+    }
+    if err != nil {
+        var errs validation.Errors
+        errs.Add("file", nil, validation.CodeRequired, "must be a CSV file")
+        c.Error(apierror.Invalid(http.StatusBadRequest, errs))
+        return nil, false
+    }
+    file, err := header.Open()
+    if err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("open uploaded file: %w", err)))
+        return nil, false
+    }
+    return file, true
+}
+
+// parseImportCSV reads the subscriptions of an import. Rows that cannot be
+// parsed or fail validation are reported in errs and left nil in subs; rows
+// holds the line number of every subscription.
+func parseImportCSV(r io.Reader) (subs []*models.Subscription, rows []int, errs []ImportRowError, err *apierror.Error) {
+    reader := csv.NewReader(r)
+    reader.TrimLeadingSpace = true
+    reader.FieldsPerRecord = -1
+
+    header, readErr := reader.Read()
+    if readErr != nil {
+        return nil, nil, nil, csvReadError(readErr, "the CSV header could not be read")
+    }
+    columns := map[string]int{}
+    var headerErrs validation.Errors
+    for i, name := range header {
+        name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+        column, ok := importColumns[name]
+        if !ok {
+            headerErrs.Add(name, nil, validation.CodeUnsupported, "is not a known column")
+            continue
+        }
+        columns[column] = i
+    }
+    for _, name := range requiredImportColumns {
+        if _, ok := columns[name]; !ok {
+            headerErrs.Add(name, nil, validation.CodeRequired, "column is missing from the header")
+        }
+    }
+    if len(headerErrs) > 0 {
+        return nil, nil, nil, apierror.Invalid(http.StatusBadRequest, headerErrs)
+    }
+
+    for {
+        record, readErr := reader.Read()
+        if readErr == io.EOF {
+            break
+        }
+        if readErr != nil {
+            return nil, nil, nil, csvReadError(readErr, "the body is not valid CSV: "+readErr.Error())
+        }
+        line, _ := reader.FieldPos(0)
+        if len(subs) == maxImportRows {
+            return nil, nil, nil, apierror.New(http.StatusRequestEntityTooLarge, "import_too_large", fmt.Sprintf("an import can contain at most %d rows", maxImportRows))
+        }
+
+        sub, rowErrs := parseImportRow(columns, record)
+        if len(rowErrs) == 0 {
+            applyDefaults(sub)
+            rowErrs = validation.Subscription(sub)
+        }
+        if len(rowErrs) > 0 {
+            errs = append(errs, ImportRowError{Row: line, Errors: rowErrs})
+            sub = nil
+        }
+        subs = append(subs, sub)
+        rows = append(rows, line)
+    }
+    if errs == nil {
+        errs = []ImportRowError{}
+    }
+    return subs, rows, errs, nil
+}
+
+// csvReadError reports a failure to read the CSV: 413 when the body is over
+// maxImportBytes, otherwise 400 with message.
+func csvReadError(err error, message string) *apierror.Error {
+    var tooLarge *http.MaxBytesError
+    if errors.As(err, &tooLarge) {
+        return importTooLarge()
+    }
+    return apierror.BadRequest(apierror.CodeMalformed, message).WithCause(err)
+}
+
+func importTooLarge() *apierror.Error {
+    return apierror.New(http.StatusRequestEntityTooLarge, "import_too_large", fmt.Sprintf("an import can be at most %d bytes", maxImportBytes))
+}
+
+func parseImportRow(columns map[string]int, record []string) (*models.Subscription, validation.Errors) {
+    field := func(name string) string {
+        i, ok := columns[name]
+        if !ok || i >= len(record) {
+            return ""
+        }
+        return strings.TrimSpace(record[i])
+    }
+
+    var errs validation.Errors
+    sub := &models.Subscription{
+        ServiceName:     field("service_name"),
+        Category:        field("category"),
+        Currency:        field("currency"),
+        BillingInterval: models.BillingInterval(strings.ToLower(field("billing_interval"))),
+    }
+
+    if raw := field("price"); raw != "" {
+        price, err := strconv.Atoi(raw)
+        if err != nil {
+            errs.Add("price", raw, validation.CodeInvalidType, "must be an integer")
+        }
+        sub.Price = price
+    }
+    if raw := field("interval_count"); raw != "" {
+        count, err := strconv.Atoi(raw)
+        if err != nil {
+            errs.Add("interval_count", raw, validation.CodeInvalidType, "must be an integer")
+        }
+        sub.IntervalCount = count
+    }
+    if raw := field("user_id"); raw != "" {
+        id, err := uuid.Parse(raw)
+        if err != nil {
+            errs.Add("user_id", raw, validation.CodeInvalidFormat, "must be a UUID")
+        }
+        sub.UserID = id
+    }
+    if raw := field("start"); raw != "" {
+        start, err := models.ParseMonth(raw)
+        if err != nil {
+            errs.Add("start", raw, validation.CodeInvalidFormat, "must be a MM-YYYY or YYYY-MM month")
+        }
+        sub.StartDate = start
+    }
+    if raw := field("end"); raw != "" {
+        end, err := models.ParseMonth(raw)
+        if err != nil {
+            errs.Add("end", raw, validation.CodeInvalidFormat, "must be a MM-YYYY or YYYY-MM month")
+        }
+        sub.EndDate = &end
+    }
+    return sub, errs
+}
+
+// importErrors turns a failure to create a row into row-level errors.
+func importErrors(err *apierror.Error) validation.Errors {
+    if len(err.Fields) > 0 {
+        return err.Fields
+    }
+    var errs validation.Errors
+    errs.Add("", nil, err.Code, err.Detail)
+    return errs
+}
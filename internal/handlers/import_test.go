@@ -0,0 +1,143 @@
+package handlers_test
+
+import (
+    "bytes"
+    "fmt"
+    "mime/multipart"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/google/uuid"
+
+    "service/internal/handlers"
+    "service/internal/models"
+)
+
+func (s *server) importCSV(query, csv string) *httptest.ResponseRecorder {
+    return s.do(http.MethodPost, "/subscriptions/import"+query, csv, "Content-Type", "text/csv")
+}
+
+func (s *server) count() int64 {
+    var page handlers.SubscriptionPage
+    s.expect(s.do(http.MethodGet, "/subscriptions", ""), http.StatusOK, &page)
+    return page.Total
+}
+
+func TestImportSubscriptions(t *testing.T) {
+    s := newServer(t)
+    user := uuid.NewString()
+    csv := "\ufeffService,Price,User_ID,Start,End\n" +
+        "Netflix,400," + user + ",07-2025,\n" +
+        "Spotify,200," + user + ",2025-08,12-2025\n"
+
+    var preview handlers.ImportResult
+    s.expect(s.importCSV("?dry_run=true", csv), http.StatusOK, &preview)
+    if !preview.DryRun || preview.Rows != 2 || preview.Created != 0 || len(preview.Subscriptions) != 2 {
+        t.Errorf("dry run reported %+v", preview)
+    }
+    if n := s.count(); n != 0 {
+        t.Fatalf("dry run stored %d subscriptions", n)
+    }
+    var entries []models.AuditEntry
+    s.expect(s.do(http.MethodGet, "/audit", ""), http.StatusOK, &entries)
+    if len(entries) != 0 {
+        t.Errorf("dry run recorded %+v", entries)
+    }
+
+    var result handlers.ImportResult
+    s.expect(s.importCSV("", csv), http.StatusCreated, &result)
+    if result.Created != 2 || result.Subscriptions[1].EndDate == nil || result.Subscriptions[1].StartDate.String() != "08-2025" {
+        t.Errorf("import reported %+v", result)
+    }
+    if n := s.count(); n != 2 {
+        t.Errorf("import stored %d subscriptions, want 2", n)
+    }
+}
+
+func TestImportRejectsInvalidRows(t *testing.T) {
+    s := newServer(t)
+    user := uuid.NewString()
+    csv := "service_name,price,user_id,start\n" +
+        "Netflix,400," + user + ",07-2025\n" +
+        ",abc,not-a-uuid,2025-13\n" +
+        "Spotify,-5," + user + ",07-2025\n"
+
+    var result handlers.ImportResult
+    s.expect(s.importCSV("", csv), http.StatusUnprocessableEntity, &result)
+    if result.Created != 0 || len(result.Subscriptions) != 0 || len(result.Errors) != 2 {
+        t.Fatalf("import reported %+v", result)
+    }
+    if result.Errors[0].Row != 3 || len(result.Errors[0].Errors) != 3 || result.Errors[1].Row != 4 {
+        t.Errorf("row errors %+v", result.Errors)
+    }
+    if n := s.count(); n != 0 {
+        t.Errorf("a failed import stored %d subscriptions", n)
+    }
+
+    w := s.importCSV("", "service_name,colour\n")
+    if got := s.fields(w); w.Code != http.StatusBadRequest || got["colour"] == "" || got["price"] == "" {
+        t.Errorf("bad header answered %d %v", w.Code, got)
+    }
+    s.expect(s.importCSV("?dry_run=maybe", csv), http.StatusBadRequest, nil)
+}
+
+func TestImportLimitsRows(t *testing.T) {
+    s := newServer(t)
+    var csv strings.Builder
+    csv.WriteString("service_name,price,user_id,start\n")
+    user := uuid.NewString()
+    for i := 0; i <= 10000; i++ {
+        fmt.Fprintf(&csv, "Service %d,100,%s,01-2026\n", i, user)
+    }
+    w := s.importCSV("", csv.String())
+    if p := s.problem(w); w.Code != http.StatusRequestEntityTooLarge || p.Code != "import_too_large" {
+        t.Errorf("oversized import answered %d %+v", w.Code, p)
+    }
+}
+
+func TestImportLimitsBytes(t *testing.T) {
+    s := newServer(t)
+    var csv strings.Builder
+    csv.WriteString("service_name,price,user_id,start,category\n")
+    user := uuid.NewString()
+    padding := strings.Repeat("x", 2000)
+    for i := 0; csv.Len() <= 10<<20; i++ {
+        fmt.Fprintf(&csv, "Service %d,100,%s,01-2026,%s\n", i, user, padding)
+    }
+
+    w := s.importCSV("", csv.String())
+    if p := s.problem(w); w.Code != http.StatusRequestEntityTooLarge || p.Code != "import_too_large" {
+        t.Errorf("oversized body answered %d %+v", w.Code, p)
+    }
+
+    var body bytes.Buffer
+    form := multipart.NewWriter(&body)
+    file, _ := form.CreateFormFile("file", "subscriptions.csv")
+    file.Write([]byte(csv.String()))
+    form.Close()
+    w = s.do(http.MethodPost, "/subscriptions/import", body.String(), "Content-Type", form.FormDataContentType())
+    if p := s.problem(w); w.Code != http.StatusRequestEntityTooLarge || p.Code != "import_too_large" {
+        t.Errorf("oversized upload answered %d %+v", w.Code, p)
+    }
+    if n := s.count(); n != 0 {
+        t.Errorf("oversized imports stored %d subscriptions", n)
+    }
+}
+
+func TestImportAcceptsMultipartUploads(t *testing.T) {
+    s := newServer(t)
+    var body bytes.Buffer
+    form := multipart.NewWriter(&body)
+    file, _ := form.CreateFormFile("file", "subscriptions.csv")
+    fmt.Fprintf(file, "service_name,price,user_id,start\nNetflix,400,%s,07-2025\n", uuid.New())
+    form.Close()
+
+    var result handlers.ImportResult
+    s.expect(s.do(http.MethodPost, "/subscriptions/import", body.String(), "Content-Type", form.FormDataContentType()), http.StatusCreated, &result)
+    if result.Created != 1 {
+        t.Errorf("multipart import reported %+v", result)
+    }
+    s.expect(s.do(http.MethodPost, "/subscriptions/import", "--x--\r\n", "Content-Type", "multipart/form-data; boundary=x"), http.StatusBadRequest, nil)
+}
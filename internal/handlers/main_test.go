@@ -0,0 +1,117 @@
+package handlers_test
+
+import (
+    "encoding/json"
+    "net/http/httptest"
+    "os"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/golang-jwt/jwt/v5"
+    "go.uber.org/zap"
+
+    "service/internal/apierror"
+    "service/internal/config"
+    "service/internal/logger"
+    "service/internal/repository"
+    "service/internal/routes"
+)
+
+func TestMain(m *testing.M) {
+    gin.SetMode(gin.TestMode)
+    logger.Log = zap.NewNop()
+    os.Exit(m.Run())
+}
+
+// server serves the API from in-memory repositories, wired the way
+// cmd/main.go wires the Postgres ones.
+type server struct {
+    t      *testing.T
+    engine *gin.Engine
+    repos  repository.Repositories
+}
+
+func newServer(t *testing.T) *server {
+    return newServerWith(t, &config.Config{})
+}
+
+func newServerWith(t *testing.T, cfg *config.Config) *server {
+    s := &server{t: t, engine: gin.New(), repos: repository.NewMemory()}
+    routes.SetupRoutes(s.engine, s.repos, cfg)
+    return s
+}
+
+// jwtSecret signs the tokens of newAuthServer.
+const jwtSecret = "0123456789abcdef0123456789abcdef"
+
+// newAuthServer is a server requiring HS256 bearer tokens signed with
+// jwtSecret.
+func newAuthServer(t *testing.T) *server {
+    return newServerWith(t, &config.Config{JWTSecret: jwtSecret, JWTUserClaim: "sub", JWTRolesClaim: "roles", JWTAdminRole: "admin", IdempotencyTTL: time.Hour, CalendarSecret: "secret"})
+}
+
+// bearer returns an Authorization header value for a token of sub with the
+// given roles.
+func bearer(t *testing.T, sub string, roles ...string) string {
+    t.Helper()
+    claims := jwt.MapClaims{"sub": sub, "roles": roles, "exp": time.Now().Add(time.Hour).Unix()}
+    raw, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(jwtSecret))
+    if err != nil {
+        t.Fatalf("sign token: %v", err)
+    }
+    return "Bearer " + raw
+}
+
+// do sends a request with a JSON body, or none when body is empty, and
+// extra headers given as name, value pairs.
+func (s *server) do(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+    req := httptest.NewRequest(method, path, strings.NewReader(body))
+    if body != "" {
+        req.Header.Set("Content-Type", "application/json")
+    }
+    for i := 0; i+1 < len(headers); i += 2 {
+        req.Header.Set(headers[i], headers[i+1])
+    }
+    w := httptest.NewRecorder()
+    s.engine.ServeHTTP(w, req)
+    return w
+}
+
+// expect fails the test unless w has the given status, then decodes its
+// body into into unless it is nil.
+func (s *server) expect(w *httptest.ResponseRecorder, status int, into any) {
+    s.t.Helper()
+    if w.Code != status {
+        s.t.Fatalf("status %d, want %d: %s", w.Code, status, w.Body)
+    }
+    if into != nil {
+        if err := json.Unmarshal(w.Body.Bytes(), into); err != nil {
+            s.t.Fatalf("decode %s: %v", w.Body, err)
+        }
+    }
+}
+
+// problem decodes a problem+json response.
+func (s *server) problem(w *httptest.ResponseRecorder) apierror.Problem {
+    s.t.Helper()
+    if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, apierror.ContentType) {
+        s.t.Errorf("content type %q, want %s", ct, apierror.ContentType)
+    }
+    var p apierror.Problem
+    if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+        s.t.Fatalf("decode %s: %v", w.Body, err)
+    }
+    return p
+}
+
+// fields returns the code of every rejected field in a validation problem.
+func (s *server) fields(w *httptest.ResponseRecorder) map[string]string {
+    s.t.Helper()
+    got := map[string]string{}
+    for _, fe := range s.problem(w).Errors {
+        got[fe.Field] = fe.Code
+    }
+    return got
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+
+    "service/internal/apierror"
+    "service/internal/models"
+)
+
+// etag is the strong entity tag of the current version of a subscription.
+func etag(sub *models.Subscription) string {
+    return `"` + strconv.Itoa(sub.Version) + `"`
+}
+
+// checkIfMatch evaluates the If-Match header against the current version of
+// sub. On failure the response is written and ok is false.
+func (h *SubscriptionHandler) checkIfMatch(c *gin.Context, sub *models.Subscription) bool {
+    if err := h.ifMatch(c.GetHeader("If-Match"), sub); err != nil {
+        if err.Status == http.StatusPreconditionFailed {
+            c.Header("ETag", etag(sub))
+        }
+        c.Error(err)
+        return false
+    }
+    return true
+}
+
+// ifMatch evaluates an If-Match header value against the current version of
+// sub.
+func (h *SubscriptionHandler) ifMatch(header string, sub *models.Subscription) *apierror.Error {
+    header = strings.TrimSpace(header)
+    if header == "" {
+        if h.requireIfMatch {
+            return apierror.PreconditionRequired(apierror.CodeNoIfMatch, "the If-Match header is required to modify a subscription")
+        }
+        return nil
+    }
+    if header == "*" {
+        return nil
+    }
+
+    current := etag(sub)
+    for _, tag := range strings.Split(header, ",") {
+        if strings.TrimSpace(tag) == current {
+            return nil
+        }
+    }
+    return subscriptionStale()
+}
+
+func subscriptionStale() *apierror.Error {
+    return apierror.PreconditionFailed(apierror.CodeStale, "the subscription was modified since it was read")
+}
+
+// mergePatch applies an RFC 7396 JSON merge patch to the JSON object doc.
+func mergePatch(doc, patch []byte) ([]byte, error) {
+    var target map[string]any
+    if err := json.Unmarshal(doc, &target); err != nil {
+        return nil, err
+    }
+
+    var changes any
+    decoder := json.NewDecoder(bytes.NewReader(patch))
+    decoder.UseNumber()
+    if err := decoder.Decode(&changes); err != nil {
+        return nil, err
+    }
+    obj, ok := changes.(map[string]any)
+    if !ok {
+        return nil, errors.New("merge patch must be a JSON object")
+    }
+    merged, err := mergeObject(target, obj)
+    if err != nil {
+        return nil, err
+    }
+    return json.Marshal(merged)
+}
+
+// mergeObject applies patch to target. A patch key names the target key it
+// equals case-insensitively, the way encoding/json binds object keys to
+// struct fields, so {"enddate": null} clears EndDate. Two patch keys naming
+// the same target key are rejected.
+func mergeObject(target, patch map[string]any) (map[string]any, error) {
+    if target == nil {
+        target = map[string]any{}
+    }
+    names := make(map[string]string, len(patch))
+    for key := range patch {
+        name := canonicalKey(target, key)
+        if other, ok := names[name]; ok {
+            return nil, fmt.Errorf("merge patch sets %s twice, as %q and %q", name, other, key)
+        }
+        names[name] = key
+    }
+
+    for name, key := range names {
+        switch value := patch[key].(type) {
+        case nil:
+            delete(target, name)
+        case map[string]any:
+            nested, _ := target[name].(map[string]any)
+            merged, err := mergeObject(nested, value)
+            if err != nil {
+                return nil, err
+            }
+            target[name] = merged
+        default:
+            target[name] = value
+        }
+    }
+    return target, nil
+}
+
+// canonicalKey returns the key of target that key matches, exactly or else
+// case-insensitively, or key itself when none does.
+func canonicalKey(target map[string]any, key string) string {
+    if _, ok := target[key]; ok {
+        return key
+    }
+    for name := range target {
+        if strings.EqualFold(name, key) {
+            return name
+        }
+    }
+    return key
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+    "encoding/json"
+    "reflect"
+    "testing"
+)
+
+func TestMergePatch(t *testing.T) {
+    doc := `{"ServiceName":"Netflix","Price":100,"EndDate":"12-2026","Meta":{"a":1,"b":2}}`
+    tests := []struct {
+        name  string
+        patch string
+        want  string
+    }{
+        {"set", `{"Price":150}`, `{"ServiceName":"Netflix","Price":150,"EndDate":"12-2026","Meta":{"a":1,"b":2}}`},
+        {"null removes", `{"EndDate":null}`, `{"ServiceName":"Netflix","Price":100,"Meta":{"a":1,"b":2}}`},
+        {"null of missing key", `{"Category":null}`, doc},
+        {"nested", `{"Meta":{"a":null,"c":3}}`, `{"ServiceName":"Netflix","Price":100,"EndDate":"12-2026","Meta":{"b":2,"c":3}}`},
+        {"object replaces scalar", `{"Price":{"amount":1}}`, `{"ServiceName":"Netflix","Price":{"amount":1},"EndDate":"12-2026","Meta":{"a":1,"b":2}}`},
+        {"new key", `{"Category":"video"}`, `{"ServiceName":"Netflix","Price":100,"EndDate":"12-2026","Meta":{"a":1,"b":2},"Category":"video"}`},
+        {"empty", `{}`, doc},
+        {"case-insensitive null", `{"enddate":null}`, `{"ServiceName":"Netflix","Price":100,"Meta":{"a":1,"b":2}}`},
+        {"case-insensitive set", `{"price":150,"meta":{"A":null}}`, `{"ServiceName":"Netflix","Price":150,"EndDate":"12-2026","Meta":{"b":2}}`},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := mergePatch([]byte(doc), []byte(tt.patch))
+            if err != nil {
+                t.Fatalf("mergePatch: %v", err)
+            }
+            assertJSONEqual(t, got, tt.want)
+        })
+    }
+}
+
+func TestMergePatchRejects(t *testing.T) {
+    doc := `{"EndDate":"12-2026"}`
+    for name, patch := range map[string]string{
+        "not an object": `[1]`,
+        "null":          `null`,
+        "not json":      `{`,
+        "duplicate key": `{"EndDate":null,"enddate":"01-2027"}`,
+    } {
+        if _, err := mergePatch([]byte(doc), []byte(patch)); err == nil {
+            t.Errorf("%s: mergePatch accepted %s", name, patch)
+        }
+    }
+}
+
+func assertJSONEqual(t *testing.T, got []byte, want string) {
+    t.Helper()
+    var g, w any
+    if err := json.Unmarshal(got, &g); err != nil {
+        t.Fatalf("invalid JSON %s: %v", got, err)
+    }
+    if err := json.Unmarshal([]byte(want), &w); err != nil {
+        t.Fatalf("invalid JSON %s: %v", want, err)
+    }
+    if !reflect.DeepEqual(g, w) {
+        t.Errorf("got %s, want %s", got, want)
+    }
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "go.uber.org/zap"
+
+    "service/internal/apierror"
+    "service/internal/audit"
+    "service/internal/billing"
+    "service/internal/logger"
+    "service/internal/models"
+    "service/internal/repository"
+    "service/internal/validation"
+)
+
+// PriceChangeInput schedules a price change.
+type PriceChangeInput struct {
+    Price         int
+    EffectiveFrom models.Month `swaggertype:"string" example:"03-2026"`
+}
+
+// @Summary List price changes
+// @Description Get the price timeline of a subscription: its Price applies from its start month, and each change listed here from its effective month on, ordered by effective month
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {array} models.PriceChange
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id}/prices [get]
+func (h *SubscriptionHandler) ListPriceChanges(c *gin.Context) {
+    sub, ok := h.loadSubscription(c)
+    if !ok {
+        return
+    }
+    changes, err := h.prices.List(c.Request.Context(), []uuid.UUID{sub.ID})
+    if err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("list price changes of subscription %s: %w", sub.ID, err)))
+        return
+    }
+    if changes == nil {
+        changes = []models.PriceChange{}
+    }
+    c.JSON(http.StatusOK, changes)
+}
+
+// @Summary Schedule a price change
+// @Description Change the price of a subscription from a month on, until its next price change. Summaries, breakdowns and budgets count every month at the price effective in it, so past costs stay intact. The month must be after the start month and no later than the end month; a past month corrects the history. Like any update, it moves the subscription to a new version, is recorded in its history and emits subscription.updated.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param change body PriceChangeInput true "New price and the month it takes effect"
+// @Success 201 {object} models.PriceChange
+// @Failure 400 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 409 {object} apierror.Problem
+// @Failure 412 {object} apierror.Problem
+// @Failure 422 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id}/prices [post]
+func (h *SubscriptionHandler) CreatePriceChange(c *gin.Context) {
+    sub, ok := h.loadSubscription(c)
+    if !ok {
+        return
+    }
+    var input PriceChangeInput
+    if !bindJSON(c, &input) {
+        return
+    }
+
+    change := models.PriceChange{
+        ID:             uuid.New(),
+        TenantID:       sub.TenantID,
+        SubscriptionID: sub.ID,
+        Price:          input.Price,
+        EffectiveFrom:  input.EffectiveFrom,
+        CreatedAt:      time.Now().UTC(),
+    }
+    if errs := validation.PriceChange(&change, sub); len(errs) > 0 {
+        c.Error(apierror.Invalid(http.StatusUnprocessableEntity, errs))
+        return
+    }
+    err := h.tx.InTx(c.Request.Context(), func(ctx context.Context) error {
+        if err := h.prices.Create(ctx, &change); err != nil {
+            return err
+        }
+        return h.repriced(ctx, sub, nil, &change)
+    })
+    if err != nil {
+        switch {
+        case errors.Is(err, repository.ErrConflict):
+            c.Error(apierror.Conflict("price_change_exists", "the subscription already has a price change effective in this month"))
+        case errors.Is(err, repository.ErrNotFound):
+            c.Error(subscriptionNotFound())
+        case errors.Is(err, repository.ErrStale):
+            c.Error(subscriptionStale())
+        default:
+            c.Error(apierror.Internal(fmt.Errorf("create price change of subscription %s: %w", sub.ID, err)))
+        }
+        return
+    }
+
+    logger.Log.Info("Price change scheduled",
+        zap.String("subscription_id", sub.ID.String()),
+        zap.Int("price", change.Price),
+        zap.String("effective_from", change.EffectiveFrom.String()),
+    )
+    c.JSON(http.StatusCreated, change)
+}
+
+// @Summary Cancel a price change
+// @Description Remove a price change of a subscription, so the price before it applies until the next one. Like any update, it moves the subscription to a new version, is recorded in its history and emits subscription.updated.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param price_id path string true "Price change ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} apierror.Problem
+// @Failure 412 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id}/prices/{price_id} [delete]
+func (h *SubscriptionHandler) DeletePriceChange(c *gin.Context) {
+    sub, ok := h.loadSubscription(c)
+    if !ok {
+        return
+    }
+    id, err := uuid.Parse(c.Param("price_id"))
+    if err != nil {
+        c.Error(priceChangeNotFound())
+        return
+    }
+    err = h.tx.InTx(c.Request.Context(), func(ctx context.Context) error {
+        changes, err := h.prices.List(ctx, []uuid.UUID{sub.ID})
+        if err != nil {
+            return err
+        }
+        for i := range changes {
+            if changes[i].ID != id {
+                continue
+            }
+            if err := h.prices.Delete(ctx, sub.ID, id); err != nil {
+                return err
+            }
+            return h.repriced(ctx, sub, &changes[i], nil)
+        }
+        return errPriceChangeNotFound
+    })
+    if err != nil {
+        switch {
+        case errors.Is(err, errPriceChangeNotFound):
+            c.Error(priceChangeNotFound())
+        case errors.Is(err, repository.ErrNotFound):
+            c.Error(subscriptionNotFound())
+        case errors.Is(err, repository.ErrStale):
+            c.Error(subscriptionStale())
+        default:
+            c.Error(apierror.Internal(fmt.Errorf("delete price change %s: %w", id, err)))
+        }
+        return
+    }
+
+    logger.Log.Info("Price change deleted", zap.String("subscription_id", sub.ID.String()), zap.String("id", id.String()))
+    c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// errPriceChangeNotFound tells a missing price change from a missing
+// subscription.
+var errPriceChangeNotFound = errors.New("price change not found")
+
+// repriced stores sub at a new version after one of its price changes was
+// scheduled (after) or cancelled (before), and records and announces it like
+// any other update, in the transaction of ctx.
+func (h *SubscriptionHandler) repriced(ctx context.Context, sub *models.Subscription, before, after *models.PriceChange) error {
+    if err := h.subs.Update(ctx, sub); err != nil {
+        return err
+    }
+    entry, err := audit.NewPriceEntry(ctx, sub, before, after, time.Now())
+    if err != nil {
+        return err
+    }
+    if err := h.audit.Append(ctx, entry); err != nil {
+        return err
+    }
+    return h.emit(ctx, models.EventSubscriptionUpdated, sub)
+}
+
+// loadPrices loads the price timeline of subs.
+func loadPrices(ctx context.Context, repo repository.PriceRepository, subs []models.Subscription) (*billing.Prices, error) {
+    if len(subs) == 0 {
+        return billing.NewPrices(nil), nil
+    }
+    ids := make([]uuid.UUID, len(subs))
+    for i, sub := range subs {
+        ids[i] = sub.ID
+    }
+    changes, err := repo.List(ctx, ids)
+    if err != nil {
+        return nil, err
+    }
+    return billing.NewPrices(changes), nil
+}
+
+func priceChangeNotFound() *apierror.Error {
+    return apierror.NotFound("price_change_not_found", "price change not found")
+}
@@ -0,0 +1,121 @@
+package handlers_test
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/google/uuid"
+
+    "service/internal/audit"
+    "service/internal/billing"
+    "service/internal/models"
+)
+
+func TestPriceChanges(t *testing.T) {
+    s := newServer(t)
+    var sub models.Subscription
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(uuid.New(), "Netflix", 100)), http.StatusCreated, &sub)
+    path := "/subscriptions/" + sub.ID.String() + "/prices"
+
+    var change models.PriceChange
+    s.expect(s.do(http.MethodPost, path, `{"Price":250,"EffectiveFrom":"03-2026"}`), http.StatusCreated, &change)
+    if change.SubscriptionID != sub.ID || change.Price != 250 {
+        t.Errorf("price change %+v", change)
+    }
+    if p := s.problem(s.do(http.MethodPost, path, `{"Price":300,"EffectiveFrom":"03-2026"}`)); p.Status != http.StatusConflict || p.Code != "price_change_exists" {
+        t.Errorf("second change in the month answered %+v", p)
+    }
+    for body, field := range map[string]string{
+        `{"Price":-1,"EffectiveFrom":"04-2026"}`: "Price",
+        `{"Price":300,"EffectiveFrom":"01-2026"}`: "EffectiveFrom",
+        `{"Price":300}`:                           "EffectiveFrom",
+    } {
+        w := s.do(http.MethodPost, path, body)
+        if got := s.fields(w); w.Code != http.StatusUnprocessableEntity || got[field] == "" {
+            t.Errorf("%s answered %d rejecting %v", body, w.Code, got)
+        }
+    }
+
+    var repriced models.Subscription
+    w := s.do(http.MethodGet, "/subscriptions/"+sub.ID.String(), "")
+    s.expect(w, http.StatusOK, &repriced)
+    if repriced.Version != sub.Version+1 || w.Header().Get("ETag") != `"2"` {
+        t.Errorf("repriced subscription at version %d, ETag %s", repriced.Version, w.Header().Get("ETag"))
+    }
+
+    var changes []models.PriceChange
+    s.expect(s.do(http.MethodGet, path, ""), http.StatusOK, &changes)
+    if len(changes) != 1 || changes[0].ID != change.ID {
+        t.Errorf("changes %+v", changes)
+    }
+
+    summary := func() billing.Summary {
+        var summary billing.Summary
+        s.expect(s.do(http.MethodGet, "/subscriptions/summary?start_date=2026-01&end_date=2026-04", ""), http.StatusOK, &summary)
+        return summary
+    }
+    if got := summary(); got.Total != 700 || got.Subscriptions[0].Price != 250 {
+        t.Errorf("summary %+v, want two months at 100 and two at 250", got)
+    }
+
+    s.expect(s.do(http.MethodDelete, path+"/"+change.ID.String(), ""), http.StatusOK, nil)
+    if got := summary(); got.Total != 400 {
+        t.Errorf("summary after deleting the change %+v, want 400", got)
+    }
+    var history []models.AuditEntry
+    s.expect(s.do(http.MethodGet, "/subscriptions/"+sub.ID.String()+"/history", ""), http.StatusOK, &history)
+    if len(history) != 3 || history[0].Action != models.AuditUpdate || history[1].Action != models.AuditUpdate {
+        t.Fatalf("history %+v, want the creation and two updates", history)
+    }
+    if c := history[0].Changes[audit.PriceChangeField]; c.Old == nil || c.New != nil {
+        t.Errorf("cancellation recorded %s -> %s", c.Old, c.New)
+    }
+    if c := history[1].Changes[audit.PriceChangeField]; c.Old != nil || c.New == nil {
+        t.Errorf("scheduling recorded %s -> %s", c.Old, c.New)
+    }
+
+    for _, id := range []string{change.ID.String(), "not-a-uuid"} {
+        if p := s.problem(s.do(http.MethodDelete, path+"/"+id, "")); p.Status != http.StatusNotFound || p.Code != "price_change_not_found" {
+            t.Errorf("deleting %s answered %+v", id, p)
+        }
+    }
+    s.expect(s.do(http.MethodGet, "/subscriptions/"+uuid.NewString()+"/prices", ""), http.StatusNotFound, nil)
+}
+
+func TestUpdatesKeepBilledPrices(t *testing.T) {
+    s := newServer(t)
+    user := uuid.New()
+    var sub models.Subscription
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(user, "Netflix", 100)), http.StatusCreated, &sub)
+    path := "/subscriptions/" + sub.ID.String()
+
+    w := s.do(http.MethodPut, path, subscriptionJSON(user, "Netflix", 150))
+    if got := s.fields(w); w.Code != http.StatusUnprocessableEntity || got["Price"] != "immutable" {
+        t.Errorf("repricing a billed subscription answered %d %v", w.Code, got)
+    }
+
+    s.expect(s.do(http.MethodPost, path+"/prices", `{"Price":250,"EffectiveFrom":"06-2026"}`), http.StatusCreated, nil)
+    patch := func(body string) *httptest.ResponseRecorder {
+        return s.do(http.MethodPatch, path, body, "Content-Type", "application/merge-patch+json")
+    }
+    for body, field := range map[string]string{
+        `{"EndDate":"03-2026"}`:      "EndDate",
+        `{"StartDate":"07-2026"}`:    "StartDate",
+        `{"BillingInterval":"once"}`: "BillingInterval",
+    } {
+        w := patch(body)
+        if got := s.fields(w); w.Code != http.StatusUnprocessableEntity || got[field] == "" {
+            t.Errorf("%s answered %d %v", body, w.Code, got)
+        }
+    }
+    s.expect(patch(`{"EndDate":"06-2026"}`), http.StatusOK, nil)
+
+    future := `{"ServiceName":"Hulu","Price":100,"UserID":"` + user.String() + `","StartDate":"01-2099"}`
+    s.expect(s.do(http.MethodPost, "/subscriptions", future), http.StatusCreated, &sub)
+    var repriced models.Subscription
+    s.expect(s.do(http.MethodPatch, "/subscriptions/"+sub.ID.String(), `{"Price":120}`, "Content-Type", "application/merge-patch+json"), http.StatusOK, &repriced)
+    if repriced.Price != 120 {
+        t.Errorf("unbilled subscription repriced to %d, want 120", repriced.Price)
+    }
+}
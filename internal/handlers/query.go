@@ -0,0 +1,175 @@
+package handlers
+
+import (
+    "encoding/json"
+    "errors"
+    "net/http"
+    "reflect"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/gin-gonic/gin/binding"
+
+    "service/internal/apierror"
+    "service/internal/billing"
+    "service/internal/models"
+    "service/internal/repository"
+    "service/internal/validation"
+)
+
+// bindJSON decodes the request body into dst. On failure the response is
+// written and ok is false.
+func bindJSON(c *gin.Context, dst any) bool {
+    body, err := c.GetRawData()
+    if err != nil {
+        c.Error(apierror.BadRequest(apierror.CodeMalformed, "request body could not be read").WithCause(err))
+        return false
+    }
+    return bindBody(c, body, dst)
+}
+
+// bindBody decodes body into dst, reporting failures like bindJSON.
+func bindBody(c *gin.Context, body []byte, dst any) bool {
+    if err := decodeJSON(body, dst); err != nil {
+        c.Error(err)
+        return false
+    }
+    return true
+}
+
+// decodeJSON decodes body into dst. Mistyped fields are reported as field
+// errors, anything else as a malformed request.
+func decodeJSON(body []byte, dst any) *apierror.Error {
+    err := binding.JSON.BindBody(body, dst)
+    if err == nil {
+        return nil
+    }
+
+    var typeErr *json.UnmarshalTypeError
+    if errors.As(err, &typeErr) {
+        var errs validation.Errors
+        if typeErr.Type == reflect.TypeOf(models.Month{}) {
+            errs.Add(monthField(body, typeErr), typeErr.Value, validation.CodeInvalidFormat, "must be a MM-YYYY or YYYY-MM month")
+        } else {
+            errs.Add(typeErr.Field, typeErr.Value, validation.CodeInvalidType, "must be of type "+typeErr.Type.String())
+        }
+        return apierror.Invalid(http.StatusBadRequest, errs)
+    }
+    return apierror.BadRequest(apierror.CodeMalformed, "request body is not valid JSON for this resource").WithCause(err)
+}
+
+// monthField names the field of a month decoding error. encoding/json does not
+// attach the field to errors returned by custom unmarshalers, so the top-level
+// object is searched for the offending value.
+func monthField(body []byte, typeErr *json.UnmarshalTypeError) string {
+    if typeErr.Field != "" {
+        return typeErr.Field
+    }
+    var fields map[string]json.RawMessage
+    if json.Unmarshal(body, &fields) != nil {
+        return ""
+    }
+    for name, raw := range fields {
+        if string(raw) == typeErr.Value {
+            return name
+        }
+    }
+    return ""
+}
+
+func subscriptionNotFound() *apierror.Error {
+    return apierror.NotFound("subscription_not_found", "subscription not found")
+}
+
+// applyDefaults lower-cases the category and defaults an omitted billing
+// period to a single month and an omitted currency to models.DefaultCurrency.
+func applyDefaults(sub *models.Subscription) {
+    sub.Category = strings.ToLower(strings.TrimSpace(sub.Category))
+    sub.Currency = strings.ToUpper(sub.Currency)
+    if sub.Currency == "" {
+        sub.Currency = models.DefaultCurrency
+    }
+    if sub.BillingInterval == "" {
+        sub.BillingInterval = models.IntervalMonth
+    }
+    if sub.IntervalCount == 0 {
+        sub.IntervalCount = 1
+    }
+}
+
+// subscriptionFilter builds the filter shared by the list and summary
+// endpoints from the query.
+func subscriptionFilter(q *validation.Query) repository.SubscriptionFilter {
+    return repository.SubscriptionFilter{
+        UserID:       q.UUID("user_id"),
+        ServiceNames: q.List("service_name"),
+    }
+}
+
+// listFilter extends subscriptionFilter with the filters only the list
+// endpoint supports.
+func listFilter(q *validation.Query) repository.SubscriptionFilter {
+    filter := subscriptionFilter(q)
+
+    filter.MinBasePrice = q.Int("min_price", 0, maxInt)
+    filter.MaxBasePrice = q.Int("max_price", 0, maxInt)
+    if filter.MinBasePrice != nil && filter.MaxBasePrice != nil && *filter.MinBasePrice > *filter.MaxBasePrice {
+        q.Fail("max_price", *filter.MaxBasePrice, validation.CodeInvalidRange, "must not be less than min_price")
+    }
+
+    filter.StartsAfter = q.Date("start_after")
+    filter.StartsBefore = q.Date("start_before")
+    filter.EndsBefore = q.Date("ends_before")
+    if activeOn := q.Date("active_on"); !activeOn.IsZero() {
+        filter.ActiveOn = billing.MonthStart(activeOn)
+    }
+    if !filter.StartsAfter.IsZero() && !filter.StartsBefore.IsZero() && !filter.StartsAfter.Before(filter.StartsBefore) {
+        q.Fail("start_before", q.String("start_before"), validation.CodeInvalidRange, "must be after start_after")
+    }
+
+    filter.HasEndDate = q.Bool("has_end_date")
+    if includeDeleted := q.Bool("include_deleted"); includeDeleted != nil && *includeDeleted {
+        filter.Deleted = repository.IncludeDeleted
+    }
+    return filter
+}
+
+// pageRequest reads the limit, cursor and sort query parameters.
+func pageRequest(q *validation.Query) repository.PageRequest {
+    req := repository.PageRequest{
+        Sort:   repository.SortStartDate,
+        Limit:  defaultPageLimit,
+        Cursor: q.String("cursor"),
+    }
+    if limit := q.Int("limit", 1, maxPageLimit); limit != nil {
+        req.Limit = *limit
+    }
+    if raw := q.String("sort"); raw != "" {
+        req.Desc = strings.HasPrefix(raw, "-")
+        req.Sort = repository.SortField(strings.TrimPrefix(raw, "-"))
+        if !req.Sort.Valid() {
+            q.Fail("sort", raw, validation.CodeUnsupported, "must be start_date, price or service_name, optionally prefixed with -")
+        }
+    }
+    return req
+}
+
+// summaryWindow reads the start_date and end_date months of the summary
+// endpoints. The start is zero when omitted; the end defaults to the current
+// month.
+func summaryWindow(q *validation.Query) billing.Window {
+    window := billing.Window{
+        From: q.Month("start_date"),
+        To:   q.Month("end_date"),
+    }
+    if q.String("end_date") == "" {
+        window.To = billing.MonthStart(time.Now())
+    }
+    if !window.From.IsZero() && !window.To.IsZero() && window.To.Before(window.From) {
+        q.Fail("end_date", q.String("end_date"), validation.CodeInvalidRange, "must not be before start_date")
+    }
+    return window
+}
+
+const maxInt = int(^uint(0) >> 1)
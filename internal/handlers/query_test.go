@@ -0,0 +1,74 @@
+package handlers_test
+
+import (
+    "net/http"
+    "testing"
+
+    "github.com/google/uuid"
+
+    "service/internal/handlers"
+)
+
+func TestListSubscriptionsQueryFilters(t *testing.T) {
+    s := newServer(t)
+    user := uuid.New()
+    for _, body := range []string{
+        `{"ServiceName":"Netflix","Price":100,"UserID":"` + user.String() + `","StartDate":"01-2025","EndDate":"06-2025"}`,
+        `{"ServiceName":"Spotify","Price":200,"UserID":"` + user.String() + `","StartDate":"01-2026"}`,
+        `{"ServiceName":"YouTube","Price":300,"UserID":"` + user.String() + `","StartDate":"03-2026","EndDate":"12-2026"}`,
+    } {
+        s.expect(s.do(http.MethodPost, "/subscriptions", body), http.StatusCreated, nil)
+    }
+
+    tests := []struct {
+        query string
+        want  []string
+    }{
+        {"service_name=netflix,spotify", []string{"Netflix", "Spotify"}},
+        {"service_name=netflix&service_name=TUBE", []string{"Netflix", "YouTube"}},
+        {"min_price=150", []string{"Spotify", "YouTube"}},
+        {"max_price=200", []string{"Netflix", "Spotify"}},
+        {"min_price=150&max_price=250", []string{"Spotify"}},
+        {"start_after=2025-12-31", []string{"Spotify", "YouTube"}},
+        {"start_before=2026-02-01", []string{"Netflix", "Spotify"}},
+        {"ends_before=2026-01-01", []string{"Netflix"}},
+        {"active_on=2026-04-15", []string{"Spotify", "YouTube"}},
+        {"has_end_date=false", []string{"Spotify"}},
+        {"has_end_date=true&min_price=200", []string{"YouTube"}},
+    }
+    for _, tt := range tests {
+        var page handlers.SubscriptionPage
+        s.expect(s.do(http.MethodGet, "/subscriptions?"+tt.query, ""), http.StatusOK, &page)
+        var got []string
+        for _, item := range page.Items {
+            got = append(got, item.ServiceName)
+        }
+        if len(got) != len(tt.want) {
+            t.Errorf("?%s listed %v, want %v", tt.query, got, tt.want)
+            continue
+        }
+        for i := range got {
+            if got[i] != tt.want[i] {
+                t.Errorf("?%s listed %v, want %v", tt.query, got, tt.want)
+                break
+            }
+        }
+    }
+
+    for _, query := range []string{
+        "min_price=-1",
+        "max_price=ten",
+        "min_price=5&max_price=1",
+        "start_after=2026-02-01&start_before=2026-01-01",
+        "active_on=yesterday",
+        "has_end_date=maybe",
+    } {
+        s.expect(s.do(http.MethodGet, "/subscriptions?"+query, ""), http.StatusBadRequest, nil)
+    }
+
+    w := s.do(http.MethodGet, "/subscriptions?user_id=nope&limit=0&has_end_date=maybe", "")
+    s.expect(w, http.StatusBadRequest, nil)
+    if got := s.fields(w); len(got) != 3 {
+        t.Errorf("reported %v, want user_id, limit and has_end_date", got)
+    }
+}
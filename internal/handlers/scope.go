@@ -0,0 +1,53 @@
+package handlers
+
+import (
+    "context"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+
+    "service/internal/apierror"
+    "service/internal/auth"
+    "service/internal/repository"
+)
+
+// scopeUser resolves the user a request acts on. Admins and unauthenticated
+// deployments get the requested user, which may be uuid.Nil for all users.
+// Other callers act on their own user: an omitted user defaults to them and
+// any other user is forbidden.
+func scopeUser(ctx context.Context, requested uuid.UUID) (uuid.UUID, *apierror.Error) {
+    own, restricted := auth.Restricted(ctx)
+    if !restricted || requested == own {
+        return requested, nil
+    }
+    if requested == uuid.Nil {
+        return own, nil
+    }
+    return uuid.Nil, forbiddenUser()
+}
+
+func forbiddenUser() *apierror.Error {
+    return apierror.Forbidden(apierror.CodeForbidden, "the data of other users is only available to admins")
+}
+
+// scopeFilter restricts a subscription filter to the users the caller may
+// see. On failure the response is written and ok is false.
+func scopeFilter(c *gin.Context, filter *repository.SubscriptionFilter) bool {
+    userID, err := scopeUser(c.Request.Context(), filter.UserID)
+    if err != nil {
+        c.Error(err)
+        return false
+    }
+    filter.UserID = userID
+    return true
+}
+
+// scopePath checks that the caller may access the user named by the user_id
+// path parameter. On failure the response is written and ok is false.
+func scopePath(c *gin.Context, userID uuid.UUID) bool {
+    if !auth.CanAccess(c.Request.Context(), userID) {
+        c.Error(forbiddenUser())
+        return false
+    }
+    return true
+}
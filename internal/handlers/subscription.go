@@ -1,46 +1,136 @@
 package handlers
 
 import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "errors"
     "net/http"
     "time"
 
     "github.com/gin-gonic/gin"
     "github.com/google/uuid"
 
-    "service/internal/database"
+    "service/internal/apierror"
+    "service/internal/audit"
+    "service/internal/auth"
+    "service/internal/billing"
     "service/internal/models"
     "service/internal/logger"
+    "service/internal/middleware"
+    "service/internal/repository"
+    "service/internal/tenant"
+    "service/internal/validation"
+    "service/internal/webhooks"
     "go.uber.org/zap"
 )
 
- 
+// SubscriptionHandler serves the subscription endpoints.
+type SubscriptionHandler struct {
+    tx    repository.Transactor
+    subs  repository.SubscriptionRepository
+    rates repository.ExchangeRateRepository
+    // prices holds the price changes the costs are computed with.
+    prices repository.PriceRepository
+    // outbox receives the lifecycle events sent to webhooks.
+    outbox repository.OutboxRepository
+    // audit records every change with its author.
+    audit repository.AuditRepository
+    budgets *budgetTracker
+    // requireIfMatch rejects writes without an If-Match header.
+    requireIfMatch bool
+}
+
+func NewSubscriptionHandler(repos repository.Repositories, requireIfMatch bool) *SubscriptionHandler {
+    return &SubscriptionHandler{
+        tx:             repos.Tx,
+        subs:           repos.Subscriptions,
+        rates:          repos.ExchangeRates,
+        prices:         repos.Prices,
+        outbox:         repos.Outbox,
+        audit:          repos.Audit,
+        budgets:        newBudgetTracker(repos),
+        requireIfMatch: requireIfMatch,
+    }
+}
+
+// SavedSubscription is a created or updated subscription together with the
+// budgets of its user that it counts against and that are over their limit in
+// the current or the next month.
+type SavedSubscription struct {
+    models.Subscription
+    BudgetAlerts []billing.BudgetStatus `json:",omitempty"`
+}
+
+// SubscriptionView is a subscription as returned by the list endpoint, with
+// the price effective in the current month, or the nearest month it is
+// billed in, converted when a target currency was requested.
+type SubscriptionView struct {
+    models.Subscription
+    ConvertedPrice    *float64 `json:",omitempty"`
+    ConvertedCurrency string   `json:",omitempty"`
+}
+
+// SubscriptionPage is a page of the subscription list.
+type SubscriptionPage struct {
+    Items      []SubscriptionView `json:"items"`
+    NextCursor string             `json:"next_cursor,omitempty"`
+    Total      int64              `json:"total"`
+}
+
+const (
+    defaultPageLimit = 50
+    maxPageLimit     = 500
+)
+
 // @Summary Create a subscription
-// @Description Create a new subscription for a user
+// @Description Create a new subscription for a user. BudgetAlerts lists the user's budgets covering the subscription that are over their limit in the current or the next month.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Client-chosen key that makes retries of this request return the original response"
 // @Param subscription body models.Subscription true "Subscription info"
-// @Success 201 {object} models.Subscription
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Success 201 {object} SavedSubscription
+// @Header 201 {string} ETag "Version of the subscription"
+// @Failure 400 {object} apierror.Problem
+// @Failure 409 {object} apierror.Problem
+// @Failure 422 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /subscriptions [post]
-func CreateSubscription(c *gin.Context) {
-    db := database.GetDB()
+func (h *SubscriptionHandler) CreateSubscription(c *gin.Context) {
     var sub models.Subscription
-    if err := c.ShouldBindJSON(&sub); err != nil {
-        logger.Log.Error("Failed to bind subscription JSON", zap.Error(err))
-        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    if !bindJSON(c, &sub) {
+        return
+    }
+    if err := h.createSubscription(c.Request.Context(), &sub); err != nil {
+        c.Error(err)
         return
     }
+    h.writeSaved(c, http.StatusCreated, &sub)
+}
 
-    if sub.ID == uuid.Nil {
-        sub.ID = uuid.New()
+// createSubscription validates and stores a new subscription.
+func (h *SubscriptionHandler) createSubscription(ctx context.Context, sub *models.Subscription) *apierror.Error {
+    if err := h.prepareSubscription(ctx, sub); err != nil {
+        return err
     }
 
-    if err := db.Create(&sub).Error; err != nil {
-        logger.Log.Error("Failed to create subscription", zap.Error(err))
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-        return
+    err := h.tx.InTx(ctx, func(ctx context.Context) error {
+        if err := h.subs.Create(ctx, sub); err != nil {
+            return err
+        }
+        if err := h.record(ctx, models.AuditCreate, nil, sub); err != nil {
+            return err
+        }
+        return h.emit(ctx, models.EventSubscriptionCreated, sub)
+    })
+    if err != nil {
+        if errors.Is(err, repository.ErrConflict) {
+            return apierror.Conflict("subscription_exists", "a subscription with this ID already exists")
+        }
+        return apierror.Internal(fmt.Errorf("create subscription: %w", err))
     }
 
     logger.Log.Info("Subscription created",
@@ -48,181 +138,653 @@ func CreateSubscription(c *gin.Context) {
         zap.String("service", sub.ServiceName),
         zap.String("user_id", sub.UserID.String()),
         zap.Float64("price", float64(sub.Price)),
+        zap.String("currency", sub.Currency),
     )
-    c.JSON(http.StatusCreated, sub)
+    return nil
 }
 
- // @Summary Get a subscription
+// prepareSubscription validates a new subscription and fills in what the
+// server assigns, leaving it ready to store.
+func (h *SubscriptionHandler) prepareSubscription(ctx context.Context, sub *models.Subscription) *apierror.Error {
+    userID, apiErr := scopeUser(ctx, sub.UserID)
+    if apiErr != nil {
+        return apiErr
+    }
+    sub.UserID = userID
+    applyDefaults(sub)
+    if errs := validation.Subscription(sub); len(errs) > 0 {
+        return apierror.Invalid(http.StatusUnprocessableEntity, errs)
+    }
+
+    if sub.ID == uuid.Nil {
+        sub.ID = uuid.New()
+    }
+    if tenantID, ok := tenant.FromContext(ctx); ok {
+        sub.TenantID = tenantID
+    }
+    sub.Version = 1
+    return nil
+}
+
+// @Summary Get a subscription
 // @Description Get subscription by ID
 // @Tags subscriptions
 // @Produce json
 // @Param id path string true "Subscription ID"
 // @Success 200 {object} models.Subscription
-// @Failure 404 {object} map[string]string
+// @Header 200 {string} ETag "Version of the subscription"
+// @Failure 404 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /subscriptions/{id} [get]
-func GetSubscription(c *gin.Context) {
-	db := database.GetDB()
-    id := c.Param("id")
-    var sub models.Subscription
-
-    if err := db.First(&sub, "id = ?", id).Error; err != nil {
-        c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+func (h *SubscriptionHandler) GetSubscription(c *gin.Context) {
+    sub, ok := h.loadSubscription(c)
+    if !ok {
         return
     }
+    c.Header("ETag", etag(sub))
     c.JSON(http.StatusOK, sub)
 }
 
+// loadSubscription fetches the subscription named by the id path parameter.
+// On failure the response is written and ok is false.
+func (h *SubscriptionHandler) loadSubscription(c *gin.Context) (*models.Subscription, bool) {
+    sub, err := h.getSubscription(c.Request.Context(), c.Param("id"))
+    if err != nil {
+        c.Error(err)
+        return nil, false
+    }
+    return sub, true
+}
+
+func (h *SubscriptionHandler) getSubscription(ctx context.Context, rawID string) (*models.Subscription, *apierror.Error) {
+    id, err := uuid.Parse(rawID)
+    if err != nil {
+        return nil, subscriptionNotFound()
+    }
+
+    sub, err := h.subs.Get(ctx, id)
+    if err != nil {
+        if !errors.Is(err, repository.ErrNotFound) {
+            return nil, apierror.Internal(fmt.Errorf("get subscription %s: %w", id, err))
+        }
+        return nil, subscriptionNotFound()
+    }
+    // Other users' subscriptions are reported as missing rather than
+    // forbidden, so their IDs cannot be probed for.
+    if !auth.CanAccess(ctx, sub.UserID) {
+        return nil, subscriptionNotFound()
+    }
+    return sub, nil
+}
+
 // @Summary Update a subscription
-// @Description Update subscription by ID
+// @Description Replace subscription by ID. Omitted optional fields are cleared. The Price of a subscription billed before the current month is rejected with 422 unless it is unchanged; schedule price changes with POST /subscriptions/{id}/prices instead. The dates and billing interval must keep the subscription's price changes valid. BudgetAlerts lists the user's budgets covering the subscription that are over their limit in the current or the next month.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param id path string true "Subscription ID"
+// @Param If-Match header string false "ETag of the version being replaced"
 // @Param subscription body models.Subscription true "Subscription info"
-// @Success 200 {object} models.Subscription
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Success 200 {object} SavedSubscription
+// @Header 200 {string} ETag "Version of the subscription"
+// @Failure 400 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 412 {object} apierror.Problem
+// @Failure 422 {object} apierror.Problem
+// @Failure 428 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /subscriptions/{id} [put] 
-func UpdateSubscription(c *gin.Context) {
-    db := database.GetDB()
-    id := c.Param("id")
-    var sub models.Subscription
-
-    if err := db.First(&sub, "id = ?", id).Error; err != nil {
-        logger.Log.Warn("Subscription not found for update", zap.String("id", id))
-        c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+func (h *SubscriptionHandler) UpdateSubscription(c *gin.Context) {
+    sub, ok := h.loadSubscription(c)
+    if !ok || !h.checkIfMatch(c, sub) {
         return
     }
 
     var input models.Subscription
-    if err := c.ShouldBindJSON(&input); err != nil {
-        logger.Log.Error("Failed to bind subscription JSON for update", zap.Error(err))
-        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    if !bindJSON(c, &input) {
+        return
+    }
+    input.ID = sub.ID
+    input.Version = sub.Version
+    input.TenantID = sub.TenantID
+    h.saveSubscription(c, &input)
+}
+
+// @Summary Patch a subscription
+// @Description Partially update subscription by ID with a JSON merge patch (RFC 7396). Fields set to null are cleared, omitted fields are kept. The rules of the full update apply to Price and to the dates. BudgetAlerts lists the user's budgets covering the subscription that are over their limit in the current or the next month.
+// @Tags subscriptions
+// @Accept json
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param If-Match header string false "ETag of the version being patched"
+// @Param patch body models.Subscription true "Fields to change"
+// @Success 200 {object} SavedSubscription
+// @Header 200 {string} ETag "Version of the subscription"
+// @Failure 400 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 412 {object} apierror.Problem
+// @Failure 422 {object} apierror.Problem
+// @Failure 428 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id} [patch]
+func (h *SubscriptionHandler) PatchSubscription(c *gin.Context) {
+    sub, ok := h.loadSubscription(c)
+    if !ok || !h.checkIfMatch(c, sub) {
+        return
+    }
+
+    patch, err := c.GetRawData()
+    if err != nil {
+        c.Error(apierror.BadRequest(apierror.CodeMalformed, "request body could not be read").WithCause(err))
+        return
+    }
+    current, err := json.Marshal(sub)
+    if err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("encode subscription %s: %w", sub.ID, err)))
+        return
+    }
+    merged, err := mergePatch(current, patch)
+    if err != nil {
+        c.Error(apierror.BadRequest(apierror.CodeMalformed, "request body is not a JSON merge patch object").WithCause(err))
         return
     }
 
-    sub.ServiceName = input.ServiceName
-    sub.Price = input.Price
-    sub.UserID = input.UserID
-    sub.StartDate = input.StartDate
-    sub.EndDate = input.EndDate
+    var input models.Subscription
+    if !bindBody(c, merged, &input) {
+        return
+    }
+    input.ID = sub.ID
+    input.Version = sub.Version
+    input.TenantID = sub.TenantID
+    h.saveSubscription(c, &input)
+}
 
-    if err := db.Save(&sub).Error; err != nil {
-        logger.Log.Error("Failed to update subscription", zap.Error(err), zap.String("id", id))
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+// saveSubscription validates and stores the replacement of a subscription
+// read at sub.Version.
+func (h *SubscriptionHandler) saveSubscription(c *gin.Context, sub *models.Subscription) {
+    if err := h.replaceSubscription(c.Request.Context(), sub); err != nil {
+        c.Error(err)
         return
     }
+    h.writeSaved(c, http.StatusOK, sub)
+}
+
+// writeSaved responds with a created or updated subscription and the budgets
+// it breaches. The subscription is already stored, so failing to check the
+// budgets only leaves the alerts out.
+func (h *SubscriptionHandler) writeSaved(c *gin.Context, status int, sub *models.Subscription) {
+    saved := SavedSubscription{Subscription: *sub}
+    statuses, err := h.budgets.statuses(c.Request.Context(), sub.UserID, sub, time.Now())
+    if err != nil {
+        logger.Log.Warn("Checking budgets failed",
+            zap.String("request_id", middleware.GetRequestID(c)),
+            zap.String("subscription_id", sub.ID.String()),
+            zap.Error(err),
+        )
+    }
+    for _, budget := range statuses {
+        if budget.OverBudget {
+            saved.BudgetAlerts = append(saved.BudgetAlerts, budget)
+        }
+    }
+    if len(saved.BudgetAlerts) > 0 {
+        logger.Log.Info("Subscription puts budgets over their limit",
+            zap.String("subscription_id", sub.ID.String()),
+            zap.String("user_id", sub.UserID.String()),
+            zap.Int("budgets", len(saved.BudgetAlerts)),
+        )
+    }
+    c.Header("ETag", etag(sub))
+    c.JSON(status, saved)
+}
+
+func (h *SubscriptionHandler) replaceSubscription(ctx context.Context, sub *models.Subscription) *apierror.Error {
+    userID, apiErr := scopeUser(ctx, sub.UserID)
+    if apiErr != nil {
+        return apiErr
+    }
+    sub.UserID = userID
+    applyDefaults(sub)
+    if errs := validation.Subscription(sub); len(errs) > 0 {
+        return apierror.Invalid(http.StatusUnprocessableEntity, errs)
+    }
+
+    err := h.tx.InTx(ctx, func(ctx context.Context) error {
+        before, err := h.subs.Get(ctx, sub.ID)
+        if err != nil {
+            return err
+        }
+        changes, err := h.prices.List(ctx, []uuid.UUID{sub.ID})
+        if err != nil {
+            return err
+        }
+        if errs := validation.PriceHistory(sub, before, changes, time.Now()); len(errs) > 0 {
+            return errs
+        }
+        if err := h.subs.Update(ctx, sub); err != nil {
+            return err
+        }
+        if err := h.record(ctx, models.AuditUpdate, before, sub); err != nil {
+            return err
+        }
+        return h.emit(ctx, models.EventSubscriptionUpdated, sub)
+    })
+    if err != nil {
+        var errs validation.Errors
+        switch {
+        case errors.Is(err, repository.ErrNotFound):
+            return subscriptionNotFound()
+        case errors.Is(err, repository.ErrStale):
+            return subscriptionStale()
+        case errors.As(err, &errs):
+            return apierror.Invalid(http.StatusUnprocessableEntity, errs)
+        }
+        return apierror.Internal(fmt.Errorf("update subscription %s: %w", sub.ID, err))
+    }
 
     logger.Log.Info("Subscription updated",
-        zap.String("id", id),
+        zap.String("id", sub.ID.String()),
         zap.String("service", sub.ServiceName),
         zap.String("user_id", sub.UserID.String()),
+        zap.Int("version", sub.Version),
     )
-    c.JSON(http.StatusOK, sub)
+    return nil
 }
 
 // @Summary Delete a subscription
-// @Description Delete subscription by ID
+// @Description Move subscription by ID to the trash. It can be restored until it is purged after the retention period.
 // @Tags subscriptions
 // @Produce json
 // @Param id path string true "Subscription ID"
+// @Param If-Match header string false "ETag of the version being deleted"
 // @Success 200 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 404 {object} apierror.Problem
+// @Failure 412 {object} apierror.Problem
+// @Failure 428 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /subscriptions/{id} [delete] 
-func DeleteSubscription(c *gin.Context) {
-    db := database.GetDB()
-    id := c.Param("id")
+func (h *SubscriptionHandler) DeleteSubscription(c *gin.Context) {
+    sub, ok := h.loadSubscription(c)
+    if !ok || !h.checkIfMatch(c, sub) {
+        return
+    }
+    if err := h.deleteSubscription(c.Request.Context(), sub); err != nil {
+        c.Error(err)
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
 
-    if err := db.Delete(&models.Subscription{}, "id = ?", id).Error; err != nil {
-        logger.Log.Error("Failed to delete subscription", zap.Error(err), zap.String("id", id))
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+// deleteSubscription moves a subscription read at sub.Version to the trash.
+func (h *SubscriptionHandler) deleteSubscription(ctx context.Context, sub *models.Subscription) *apierror.Error {
+    err := h.tx.InTx(ctx, func(ctx context.Context) error {
+        if err := h.subs.Delete(ctx, sub.ID, sub.Version); err != nil {
+            return err
+        }
+        if err := h.record(ctx, models.AuditDelete, sub, nil); err != nil {
+            return err
+        }
+        return h.emit(ctx, models.EventSubscriptionDeleted, sub)
+    })
+    if err != nil {
+        switch {
+        case errors.Is(err, repository.ErrNotFound):
+            return subscriptionNotFound()
+        case errors.Is(err, repository.ErrStale):
+            return subscriptionStale()
+        }
+        return apierror.Internal(fmt.Errorf("delete subscription %s: %w", sub.ID, err))
+    }
+
+    logger.Log.Info("Subscription deleted", zap.String("id", sub.ID.String()))
+    return nil
+}
+
+// emit appends a lifecycle event about sub to the outbox. It is called in the
+// transaction of the change, so the event is sent only if the change commits.
+func (h *SubscriptionHandler) emit(ctx context.Context, eventType models.EventType, sub *models.Subscription) error {
+    event, err := webhooks.NewEvent(eventType, *sub, time.Now())
+    if err != nil {
+        return err
+    }
+    return h.outbox.Append(ctx, event)
+}
+
+// record appends the change of a subscription from before to after to the
+// audit log, in the transaction of the change like emit.
+func (h *SubscriptionHandler) record(ctx context.Context, action models.AuditAction, before, after *models.Subscription) error {
+    entry, err := audit.NewEntry(ctx, action, before, after, time.Now())
+    if err != nil {
+        return err
+    }
+    return h.audit.Append(ctx, entry)
+}
+
+// @Summary Restore a subscription
+// @Description Move a deleted subscription out of the trash
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} models.Subscription
+// @Header 200 {string} ETag "Version of the subscription"
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id}/restore [post]
+func (h *SubscriptionHandler) RestoreSubscription(c *gin.Context) {
+    id, err := uuid.Parse(c.Param("id"))
+    if err != nil {
+        c.Error(subscriptionNotFound())
         return
     }
 
-    logger.Log.Info("Subscription deleted", zap.String("id", id))
-    c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+    var sub *models.Subscription
+    err = h.tx.InTx(c.Request.Context(), func(ctx context.Context) error {
+        var err error
+        if sub, err = h.subs.Restore(ctx, id); err != nil {
+            return err
+        }
+        if !auth.CanAccess(ctx, sub.UserID) {
+            return repository.ErrNotFound
+        }
+        if err := h.record(ctx, models.AuditRestore, nil, sub); err != nil {
+            return err
+        }
+        return h.emit(ctx, models.EventSubscriptionRestored, sub)
+    })
+    if err != nil {
+        if errors.Is(err, repository.ErrNotFound) {
+            c.Error(apierror.NotFound("subscription_not_deleted", "no deleted subscription with this ID"))
+            return
+        }
+        c.Error(apierror.Internal(fmt.Errorf("restore subscription %s: %w", id, err)))
+        return
+    }
+
+    logger.Log.Info("Subscription restored", zap.String("id", id.String()))
+    c.Header("ETag", etag(sub))
+    c.JSON(http.StatusOK, sub)
 }
 
 // @Summary List subscriptions
-// @Description Get list of subscriptions with optional filters
+// @Description Get a page of subscriptions with optional filters, using cursor pagination
 // @Tags subscriptions
 // @Produce json
 // @Param user_id query string false "User ID"
-// @Param service_name query string false "Service name"
-// @Success 200 {array} models.Subscription
-// @Failure 500 {object} map[string]string
+// @Param service_name query []string false "Service names, matched case-insensitively by substring; repeat or comma-separate for any of several" collectionFormat(multi)
+// @Param min_price query int false "Minimum base price, the price at the start month before any price changes"
+// @Param max_price query int false "Maximum base price, the price at the start month before any price changes"
+// @Param start_after query string false "Only subscriptions starting after this date (YYYY-MM-DD)"
+// @Param start_before query string false "Only subscriptions starting before this date (YYYY-MM-DD)"
+// @Param ends_before query string false "Only subscriptions ending before this date (YYYY-MM-DD)"
+// @Param active_on query string false "Only subscriptions active in the month of this date (YYYY-MM-DD)"
+// @Param has_end_date query bool false "Only subscriptions with (true) or without (false) an end date"
+// @Param include_deleted query bool false "Also list subscriptions in the trash"
+// @Param currency query string false "Also convert prices to this currency (ISO 4217)"
+// @Param limit query int false "Page size, 1-500 (default 50)"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field start_date, price (the base price, before any price changes) or service_name, prefixed with - for descending order (default start_date)"
+// @Success 200 {object} SubscriptionPage
+// @Failure 400 {object} apierror.Problem
+// @Failure 422 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /subscriptions [get]
-func ListSubscriptions(c *gin.Context) {
-    db := database.GetDB()
-    var subs []models.Subscription
-    query := db
+func (h *SubscriptionHandler) ListSubscriptions(c *gin.Context) {
+    h.listSubscriptions(c, false)
+}
+
+// @Summary List deleted subscriptions
+// @Description Get a page of the subscriptions in the trash. Accepts the same filters, sorting and pagination as the subscription list.
+// @Tags subscriptions
+// @Produce json
+// @Param user_id query string false "User ID"
+// @Param service_name query []string false "Service names, matched case-insensitively by substring; repeat or comma-separate for any of several" collectionFormat(multi)
+// @Param limit query int false "Page size, 1-500 (default 50)"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field start_date, price (the base price, before any price changes) or service_name, prefixed with - for descending order (default start_date)"
+// @Success 200 {object} SubscriptionPage
+// @Failure 400 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/trash [get]
+func (h *SubscriptionHandler) ListTrash(c *gin.Context) {
+    h.listSubscriptions(c, true)
+}
 
-    if userID := c.Query("user_id"); userID != "" {
-        query = query.Where("user_id = ?", userID)
+// listSubscriptions serves a page of the subscription list, or of the trash
+// when trash is set.
+func (h *SubscriptionHandler) listSubscriptions(c *gin.Context, trash bool) {
+    q := validation.NewQuery(c.Request.URL.Query())
+    filter := listFilter(q)
+    if trash {
+        filter.Deleted = repository.OnlyDeleted
     }
-    if service := c.Query("service_name"); service != "" {
-        query = query.Where("service_name ILIKE ?", "%"+service+"%")
+    req := pageRequest(q)
+    currency := q.Currency("currency", "")
+    if len(q.Errors) > 0 {
+        c.Error(apierror.Invalid(http.StatusBadRequest, q.Errors))
+        return
+    }
+    if !scopeFilter(c, &filter) {
+        return
     }
 
-    if err := query.Find(&subs).Error; err != nil {
-        logger.Log.Error("Failed to list subscriptions", zap.Error(err))
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    page, err := h.subs.ListPage(c.Request.Context(), filter, req)
+    if err != nil {
+        if errors.Is(err, repository.ErrInvalidCursor) {
+            q.Fail("cursor", req.Cursor, validation.CodeInvalid, "must be the next_cursor of a page with the same sort")
+            c.Error(apierror.Invalid(http.StatusBadRequest, q.Errors))
+            return
+        }
+        c.Error(apierror.Internal(fmt.Errorf("list subscriptions: %w", err)))
         return
     }
 
+    subs := page.Items
+    views := make([]SubscriptionView, len(subs))
+    for i, sub := range subs {
+        views[i].Subscription = sub
+    }
+
+    if currency != "" {
+        now := billing.MonthStart(time.Now())
+        rates, err := loadRates(c.Request.Context(), h.rates, now)
+        if err != nil {
+            c.Error(apierror.Internal(fmt.Errorf("load exchange rates: %w", err)))
+            return
+        }
+        prices, err := loadPrices(c.Request.Context(), h.prices, subs)
+        if err != nil {
+            c.Error(apierror.Internal(fmt.Errorf("load price changes: %w", err)))
+            return
+        }
+        for i, sub := range subs {
+            month := now
+            if sub.EndDate != nil && sub.EndDate.Before(month) {
+                month = sub.EndDate.Time
+            }
+            if sub.StartDate.After(month) {
+                month = sub.StartDate.Time
+            }
+            converted, err := rates.Convert(float64(prices.At(sub, month)), sub.Currency, currency, month)
+            if err != nil {
+                c.Error(apierror.Unprocessable(apierror.CodeRateMissing, err.Error()).WithCause(err))
+                return
+            }
+            converted = billing.Round(converted)
+            views[i].ConvertedPrice = &converted
+            views[i].ConvertedCurrency = currency
+        }
+    }
+
     logger.Log.Info("Subscriptions listed",
+        zap.Bool("trash", trash),
         zap.Int("count", len(subs)),
+        zap.Int64("total", page.Total),
         zap.String("user_id", c.Query("user_id")),
-        zap.String("service_name", c.Query("service_name")),
+        zap.Strings("service_name", filter.ServiceNames),
+        zap.String("sort", c.Query("sort")),
+        zap.String("currency", currency),
     )
-    c.JSON(http.StatusOK, subs)
+    c.JSON(http.StatusOK, SubscriptionPage{Items: views, NextCursor: page.NextCursor, Total: page.Total})
 }
 
 // @Summary Get summary
-// @Description Get total price summary of subscriptions
+// @Description Get total spend of subscriptions over a month range, counting every month each subscription is active in it at the price effective in that month, normalized to a monthly amount by billing interval
 // @Tags subscriptions
 // @Produce json
 // @Param user_id query string false "User ID"
-// @Param service_name query string false "Service name"
-// @Param start_date query string false "Start date YYYY-MM"
-// @Param end_date query string false "End date YYYY-MM"
-// @Success 200 {object} map[string]int64
-// @Failure 500 {object} map[string]string
+// @Param service_name query []string false "Service names, matched case-insensitively by substring; repeat or comma-separate for any of several" collectionFormat(multi)
+// @Param start_date query string false "Start date YYYY-MM (defaults to the earliest subscription start)"
+// @Param end_date query string false "End date YYYY-MM (defaults to the current month)"
+// @Param currency query string false "Currency to report amounts in (ISO 4217, defaults to RUB)"
+// @Success 200 {object} billing.Summary
+// @Failure 400 {object} apierror.Problem
+// @Failure 422 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /subscriptions/summary [get]
-func GetSummary(c *gin.Context) {
-    db := database.GetDB()
-    var total int64
-    query := db.Model(&models.Subscription{})
-
-    if userID := c.Query("user_id"); userID != "" {
-        query = query.Where("user_id = ?", userID)
-    }
-    if service := c.Query("service_name"); service != "" {
-        query = query.Where("service_name ILIKE ?", "%"+service+"%")
+func (h *SubscriptionHandler) GetSummary(c *gin.Context) {
+    q := validation.NewQuery(c.Request.URL.Query())
+    currency := q.Currency("currency", models.DefaultCurrency)
+    subs, window, prices, rates, ok := h.loadSummarySubscriptions(c, q)
+    if !ok {
+        return
     }
-    if start := c.Query("start_date"); start != "" {
-        t, _ := time.Parse("2006-01", start)
-        query = query.Where("start_date >= ?", t)
+
+    summary, err := billing.Summarize(subs, window, prices, rates, currency)
+    if err != nil {
+        c.Error(apierror.Unprocessable(apierror.CodeRateMissing, err.Error()).WithCause(err))
+        return
     }
-    if end := c.Query("end_date"); end != "" {
-        t, _ := time.Parse("2006-01", end)
-        query = query.Where("end_date <= ?", t)
+
+    logger.Log.Info("Summary calculated",
+        zap.Float64("total", summary.Total),
+        zap.Int("subscriptions", len(summary.Subscriptions)),
+        zap.String("user_id", c.Query("user_id")),
+        zap.String("service_name", c.Query("service_name")),
+        zap.String("currency", summary.Currency),
+        zap.String("start_date", summary.StartDate),
+        zap.String("end_date", summary.EndDate),
+    )
+
+    c.JSON(http.StatusOK, summary)
+}
+
+// @Summary Get spending breakdown
+// @Description Get spend grouped by calendar month and service name, optionally also by user, as a time series
+// @Tags subscriptions
+// @Produce json
+// @Param user_id query string false "User ID"
+// @Param service_name query []string false "Service names, matched case-insensitively by substring; repeat or comma-separate for any of several" collectionFormat(multi)
+// @Param start_date query string false "Start date YYYY-MM (defaults to the earliest subscription start)"
+// @Param end_date query string false "End date YYYY-MM (defaults to the current month)"
+// @Param currency query string false "Currency to report amounts in (ISO 4217, defaults to RUB)"
+// @Param group_by_user query bool false "Also group by user ID"
+// @Success 200 {object} billing.Breakdown
+// @Failure 400 {object} apierror.Problem
+// @Failure 422 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/summary/breakdown [get]
+func (h *SubscriptionHandler) GetSummaryBreakdown(c *gin.Context) {
+    q := validation.NewQuery(c.Request.URL.Query())
+    currency := q.Currency("currency", models.DefaultCurrency)
+    byUser := q.Bool("group_by_user")
+    subs, window, prices, rates, ok := h.loadSummarySubscriptions(c, q)
+    if !ok {
+        return
     }
 
-    if err := query.Select("SUM(price)").Scan(&total).Error; err != nil {
-        logger.Log.Error("Failed to calculate summary", zap.Error(err))
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    breakdown, err := billing.BreakdownByMonth(subs, window, prices, rates, currency, byUser != nil && *byUser)
+    if err != nil {
+        c.Error(apierror.Unprocessable(apierror.CodeRateMissing, err.Error()).WithCause(err))
         return
     }
 
-    logger.Log.Info("Summary calculated",
-        zap.Int64("total", total),
+    logger.Log.Info("Summary breakdown calculated",
+        zap.Float64("total", breakdown.Total),
+        zap.Int("months", len(breakdown.Series)),
+        zap.Bool("group_by_user", byUser != nil && *byUser),
         zap.String("user_id", c.Query("user_id")),
         zap.String("service_name", c.Query("service_name")),
-        zap.String("start_date", c.Query("start_date")),
-        zap.String("end_date", c.Query("end_date")),
+        zap.String("currency", breakdown.Currency),
+        zap.String("start_date", breakdown.StartDate),
+        zap.String("end_date", breakdown.EndDate),
     )
 
-    c.JSON(http.StatusOK, gin.H{"total": total})
+    c.JSON(http.StatusOK, breakdown)
+}
+
+// loadSummarySubscriptions resolves the summary window from the query and
+// loads every subscription active in it together with their price changes
+// and the exchange rates needed to convert their costs. Query errors
+// collected so far are reported too. On failure the response is written and
+// ok is false.
+func (h *SubscriptionHandler) loadSummarySubscriptions(c *gin.Context, q *validation.Query) ([]models.Subscription, billing.Window, *billing.Prices, *billing.Rates, bool) {
+    filter := subscriptionFilter(q)
+    window := summaryWindow(q)
+    if len(q.Errors) > 0 {
+        c.Error(apierror.Invalid(http.StatusBadRequest, q.Errors))
+        return nil, window, nil, nil, false
+    }
+    if !scopeFilter(c, &filter) {
+        return nil, window, nil, nil, false
+    }
+
+    filter.EndsOnOrAfter = window.From
+    filter.StartsBefore = window.To.AddDate(0, 1, 0)
+
+    subs, err := h.subs.List(c.Request.Context(), filter)
+    if err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("load subscriptions for summary: %w", err)))
+        return nil, window, nil, nil, false
+    }
+
+    if window.From.IsZero() {
+        window.From = window.To
+        for _, sub := range subs {
+            if sub.StartDate.Before(window.From) {
+                window.From = sub.StartDate.Time
+            }
+        }
+    }
+
+    prices, err := loadPrices(c.Request.Context(), h.prices, subs)
+    if err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("load price changes: %w", err)))
+        return nil, window, nil, nil, false
+    }
+    rates, err := loadRates(c.Request.Context(), h.rates, window.To)
+    if err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("load exchange rates: %w", err)))
+        return nil, window, nil, nil, false
+    }
+    return subs, window, prices, rates, true
+}
+
+// loadRates loads every exchange rate that can be effective up to and
+// including the given month.
+func loadRates(ctx context.Context, repo repository.ExchangeRateRepository, month time.Time) (*billing.Rates, error) {
+    rates, err := repo.List(ctx, repository.ExchangeRateFilter{
+        EffectiveBefore: billing.MonthStart(month).AddDate(0, 1, 0),
+    })
+    if err != nil {
+        return nil, err
+    }
+    return billing.NewRates(rates), nil
 }
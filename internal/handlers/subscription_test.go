@@ -0,0 +1,265 @@
+package handlers_test
+
+import (
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/google/uuid"
+
+    "service/internal/apierror"
+    "service/internal/billing"
+    "service/internal/config"
+    "service/internal/handlers"
+    "service/internal/models"
+)
+
+func subscriptionJSON(userID uuid.UUID, service string, price int) string {
+    return fmt.Sprintf(`{"ServiceName":%q,"Price":%d,"UserID":"%s","StartDate":"01-2026"}`, service, price, userID)
+}
+
+func TestSubscriptionLifecycle(t *testing.T) {
+    s := newServer(t)
+    user := uuid.New()
+
+    var created models.Subscription
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(user, "Netflix", 100)), http.StatusCreated, &created)
+    if created.ID == uuid.Nil || created.Currency != models.DefaultCurrency || created.BillingInterval != models.IntervalMonth {
+        t.Fatalf("created %+v, want an ID and the default currency and interval", created)
+    }
+    path := "/subscriptions/" + created.ID.String()
+
+    var got models.Subscription
+    s.expect(s.do(http.MethodGet, path, ""), http.StatusOK, &got)
+    if got.ServiceName != "Netflix" || got.Price != 100 || got.UserID != user {
+        t.Errorf("got %+v", got)
+    }
+    if w := s.do(http.MethodGet, path, ""); !strings.Contains(w.Body.String(), `"StartDate":"01-2026"`) {
+        t.Errorf("start date not rendered as MM-YYYY: %s", w.Body)
+    }
+
+    s.expect(s.do(http.MethodPut, path, subscriptionJSON(user, "Netflix 4K", 100)), http.StatusOK, &got)
+    s.expect(s.do(http.MethodGet, path, ""), http.StatusOK, &got)
+    if got.ServiceName != "Netflix 4K" {
+        t.Errorf("service after update = %q, want Netflix 4K", got.ServiceName)
+    }
+
+    s.expect(s.do(http.MethodDelete, path, ""), http.StatusOK, nil)
+    w := s.do(http.MethodGet, path, "", "X-Request-ID", "trace-1")
+    s.expect(w, http.StatusNotFound, nil)
+    if p := s.problem(w); p.Status != http.StatusNotFound || p.Instance != path || p.RequestID != "trace-1" || p.Type != apierror.TypePrefix+p.Code {
+        t.Errorf("problem %+v", p)
+    }
+    s.expect(s.do(http.MethodDelete, path, ""), http.StatusNotFound, nil)
+    s.expect(s.do(http.MethodPut, path, subscriptionJSON(user, "Netflix", 150)), http.StatusNotFound, nil)
+}
+
+func TestConditionalUpdates(t *testing.T) {
+    s := newServer(t)
+    user := uuid.New()
+    patch := func(path, body string, headers ...string) *httptest.ResponseRecorder {
+        return s.do(http.MethodPatch, path, body, append([]string{"Content-Type", "application/merge-patch+json"}, headers...)...)
+    }
+
+    w := s.do(http.MethodPost, "/subscriptions", subscriptionJSON(user, "Netflix", 100))
+    var sub models.Subscription
+    s.expect(w, http.StatusCreated, &sub)
+    if w.Header().Get("ETag") != `"1"` {
+        t.Errorf("ETag after create %q, want \"1\"", w.Header().Get("ETag"))
+    }
+    path := "/subscriptions/" + sub.ID.String()
+
+    w = patch(path, `{"EndDate":"12-2026","Category":"video"}`, "If-Match", `"1"`)
+    s.expect(w, http.StatusOK, &sub)
+    if sub.Version != 2 || sub.Category != "video" || sub.EndDate == nil || sub.EndDate.String() != "12-2026" || sub.ServiceName != "Netflix" {
+        t.Errorf("patched %+v", sub)
+    }
+    if w.Header().Get("ETag") != `"2"` {
+        t.Errorf("ETag after patch %q, want \"2\"", w.Header().Get("ETag"))
+    }
+
+    w = patch(path, `{"EndDate":null}`, "If-Match", `"1"`)
+    s.expect(w, http.StatusPreconditionFailed, nil)
+    if w.Header().Get("ETag") != `"2"` {
+        t.Errorf("412 carries ETag %q, want the current \"2\"", w.Header().Get("ETag"))
+    }
+    s.expect(s.do(http.MethodPut, path, subscriptionJSON(user, "Netflix", 1), "If-Match", `"1"`), http.StatusPreconditionFailed, nil)
+    s.expect(s.do(http.MethodDelete, path, "", "If-Match", `"1"`), http.StatusPreconditionFailed, nil)
+
+    s.expect(patch(path, `{"EndDate":null}`, "If-Match", `"7", "2"`), http.StatusOK, &sub)
+    if sub.EndDate != nil || sub.Version != 3 {
+        t.Errorf("EndDate not cleared: %+v", sub)
+    }
+    s.expect(patch(path, `{"Price":-1}`, "If-Match", "*"), http.StatusUnprocessableEntity, nil)
+    s.expect(patch(path, `[1]`), http.StatusBadRequest, nil)
+    s.expect(patch("/subscriptions/"+uuid.NewString(), `{}`), http.StatusNotFound, nil)
+    s.expect(s.do(http.MethodDelete, path, "", "If-Match", `"3"`), http.StatusOK, nil)
+}
+
+func TestRequireIfMatch(t *testing.T) {
+    s := newServerWith(t, &config.Config{RequireIfMatch: true})
+    var sub models.Subscription
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(uuid.New(), "Netflix", 100)), http.StatusCreated, &sub)
+    path := "/subscriptions/" + sub.ID.String()
+
+    s.expect(s.do(http.MethodPut, path, subscriptionJSON(sub.UserID, "Netflix 4K", 100)), http.StatusPreconditionRequired, nil)
+    s.expect(s.do(http.MethodDelete, path, ""), http.StatusPreconditionRequired, nil)
+    s.expect(s.do(http.MethodPut, path, subscriptionJSON(sub.UserID, "Netflix 4K", 100), "If-Match", `"1"`), http.StatusOK, nil)
+}
+
+func TestTrashAndRestore(t *testing.T) {
+    s := newServer(t)
+    var kept, trashed models.Subscription
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(uuid.New(), "Kept", 100)), http.StatusCreated, &kept)
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(uuid.New(), "Trashed", 200)), http.StatusCreated, &trashed)
+    path := "/subscriptions/" + trashed.ID.String()
+
+    s.expect(s.do(http.MethodDelete, path, ""), http.StatusOK, nil)
+    s.expect(s.do(http.MethodGet, path, ""), http.StatusNotFound, nil)
+
+    listed := func(path string) []string {
+        var page handlers.SubscriptionPage
+        s.expect(s.do(http.MethodGet, path, ""), http.StatusOK, &page)
+        var names []string
+        for _, item := range page.Items {
+            names = append(names, item.ServiceName)
+        }
+        return names
+    }
+    if got := fmt.Sprint(listed("/subscriptions")); got != "[Kept]" {
+        t.Errorf("list shows %s, want [Kept]", got)
+    }
+    if got := fmt.Sprint(listed("/subscriptions/trash")); got != "[Trashed]" {
+        t.Errorf("trash shows %s, want [Trashed]", got)
+    }
+    if got := len(listed("/subscriptions?include_deleted=true")); got != 2 {
+        t.Errorf("list including deleted shows %d, want 2", got)
+    }
+
+    var restored models.Subscription
+    s.expect(s.do(http.MethodPost, path+"/restore", ""), http.StatusOK, &restored)
+    if restored.ID != trashed.ID || restored.DeletedAt.Valid {
+        t.Errorf("restored %+v", restored)
+    }
+    s.expect(s.do(http.MethodGet, path, ""), http.StatusOK, nil)
+    s.expect(s.do(http.MethodPost, path+"/restore", ""), http.StatusNotFound, nil)
+    if got := len(listed("/subscriptions/trash")); got != 0 {
+        t.Errorf("trash shows %d after restore, want 0", got)
+    }
+}
+
+func TestCreateSubscriptionReportsEveryInvalidField(t *testing.T) {
+    s := newServer(t)
+
+    w := s.do(http.MethodPost, "/subscriptions", `{"ServiceName":"","Price":-5,"Currency":"rubles","StartDate":"03-2026","EndDate":"01-2026"}`)
+    s.expect(w, http.StatusUnprocessableEntity, nil)
+    got := s.fields(w)
+    for _, field := range []string{"ServiceName", "Price", "Currency", "UserID", "EndDate"} {
+        if _, ok := got[field]; !ok {
+            t.Errorf("%s not reported in %v", field, got)
+        }
+    }
+
+    w = s.do(http.MethodPost, "/subscriptions", `{"ServiceName":"Netflix","Price":100,"StartDate":"2026-13"}`)
+    s.expect(w, http.StatusBadRequest, nil)
+    if got := s.fields(w); got["StartDate"] != "invalid_format" {
+        t.Errorf("malformed month reported as %v, want StartDate invalid_format", got)
+    }
+
+    w = s.do(http.MethodPost, "/subscriptions", `{"ServiceName":"Netflix","Price":"free"}`)
+    s.expect(w, http.StatusBadRequest, nil)
+    if got := s.fields(w); got["Price"] != "invalid_type" {
+        t.Errorf("wrong type reported as %v, want Price invalid_type", got)
+    }
+}
+
+func TestListSubscriptionsFilters(t *testing.T) {
+    s := newServer(t)
+    alice, bob := uuid.New(), uuid.New()
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(alice, "Netflix", 100)), http.StatusCreated, nil)
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(alice, "Spotify", 200)), http.StatusCreated, nil)
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(bob, "Netflix", 300)), http.StatusCreated, nil)
+
+    tests := []struct {
+        query string
+        want  int
+    }{
+        {"", 3},
+        {"?user_id=" + alice.String(), 2},
+        {"?service_name=netf", 2},
+        {"?user_id=" + bob.String() + "&service_name=spotify", 0},
+    }
+    for _, tt := range tests {
+        var page handlers.SubscriptionPage
+        s.expect(s.do(http.MethodGet, "/subscriptions"+tt.query, ""), http.StatusOK, &page)
+        if len(page.Items) != tt.want || page.Total != int64(tt.want) {
+            t.Errorf("GET /subscriptions%s listed %d of %d, want %d", tt.query, len(page.Items), page.Total, tt.want)
+        }
+    }
+    s.expect(s.do(http.MethodGet, "/subscriptions?user_id=nope", ""), http.StatusBadRequest, nil)
+}
+
+func TestListSubscriptionsPages(t *testing.T) {
+    s := newServer(t)
+    user := uuid.New()
+    for _, price := range []int{300, 100, 200, 100, 400} {
+        s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(user, "Netflix", price)), http.StatusCreated, nil)
+    }
+
+    var prices []int
+    path := "/subscriptions?limit=2&sort=-price"
+    for pages := 1; ; pages++ {
+        if pages > 3 {
+            t.Fatalf("more than 3 pages of 2 for 5 subscriptions")
+        }
+        var page handlers.SubscriptionPage
+        s.expect(s.do(http.MethodGet, path, ""), http.StatusOK, &page)
+        if page.Total != 5 {
+            t.Errorf("total = %d, want 5", page.Total)
+        }
+        for _, item := range page.Items {
+            prices = append(prices, item.Price)
+        }
+        if page.NextCursor == "" {
+            break
+        }
+        path = "/subscriptions?limit=2&sort=-price&cursor=" + page.NextCursor
+    }
+    if fmt.Sprint(prices) != "[400 300 200 100 100]" {
+        t.Errorf("paged through prices %v, want [400 300 200 100 100]", prices)
+    }
+
+    var first handlers.SubscriptionPage
+    s.expect(s.do(http.MethodGet, "/subscriptions?limit=2&sort=-price", ""), http.StatusOK, &first)
+    for _, query := range []string{
+        "limit=0",
+        "limit=501",
+        "sort=user_id",
+        "cursor=garbage",
+        "sort=price&cursor=" + first.NextCursor,
+    } {
+        s.expect(s.do(http.MethodGet, "/subscriptions?"+query, ""), http.StatusBadRequest, nil)
+    }
+}
+
+func TestSummaryConvertsCurrencies(t *testing.T) {
+    s := newServer(t)
+    user := uuid.New()
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(user, "Netflix", 100)), http.StatusCreated, nil)
+    usd := `{"ServiceName":"GitHub","Price":10,"Currency":"USD","BillingInterval":"year","UserID":"` + user.String() + `","StartDate":"01-2026"}`
+    s.expect(s.do(http.MethodPost, "/subscriptions", usd), http.StatusCreated, nil)
+    rates := `[{"BaseCurrency":"usd","QuoteCurrency":"RUB","Rate":120,"EffectiveDate":"2025-12-01T00:00:00Z"}]`
+    s.expect(s.do(http.MethodPost, "/exchange-rates", rates), http.StatusOK, nil)
+
+    var summary billing.Summary
+    s.expect(s.do(http.MethodGet, "/subscriptions/summary?start_date=2026-01&end_date=2026-03", ""), http.StatusOK, &summary)
+    // 3 months of 100 RUB and 3 months of 10 USD a year at 120 RUB.
+    if summary.Currency != "RUB" || summary.Total != 600 || len(summary.Subscriptions) != 2 {
+        t.Errorf("summary %+v, want 600 RUB over 2 subscriptions", summary)
+    }
+
+    s.expect(s.do(http.MethodGet, "/subscriptions/summary?start_date=2026-03&end_date=2026-01", ""), http.StatusBadRequest, nil)
+    s.expect(s.do(http.MethodGet, "/subscriptions/summary?currency=EUR&start_date=2026-01&end_date=2026-01", ""), http.StatusUnprocessableEntity, nil)
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "go.uber.org/zap"
+
+    "service/internal/apierror"
+    "service/internal/logger"
+    "service/internal/models"
+    "service/internal/repository"
+    "service/internal/validation"
+)
+
+// TenantHandler serves the tenant management endpoints.
+type TenantHandler struct {
+    tenants repository.TenantRepository
+}
+
+func NewTenantHandler(tenants repository.TenantRepository) *TenantHandler {
+    return &TenantHandler{tenants: tenants}
+}
+
+// TenantInput creates a tenant.
+type TenantInput struct {
+    Slug string `example:"payments-team"`
+    Name string `example:"Payments team"`
+}
+
+// @Summary Create a tenant
+// @Description Create an organization whose data is isolated from every other tenant. Requests act for a tenant named by the tenant_id claim of their token, the tenant of their API key or, for admins bound to no tenant, the X-Tenant-ID header holding its ID or slug.
+// @Tags tenants
+// @Accept json
+// @Produce json
+// @Param tenant body TenantInput true "Slug and name"
+// @Success 201 {object} models.Tenant
+// @Failure 400 {object} apierror.Problem
+// @Failure 409 {object} apierror.Problem
+// @Failure 422 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Router /tenants [post]
+func (h *TenantHandler) CreateTenant(c *gin.Context) {
+    var input TenantInput
+    if !bindJSON(c, &input) {
+        return
+    }
+
+    t := models.Tenant{
+        ID:        uuid.New(),
+        Slug:      strings.ToLower(strings.TrimSpace(input.Slug)),
+        Name:      strings.TrimSpace(input.Name),
+        CreatedAt: time.Now().UTC(),
+    }
+    if errs := validation.Tenant(&t); len(errs) > 0 {
+        c.Error(apierror.Invalid(http.StatusUnprocessableEntity, errs))
+        return
+    }
+    if err := h.tenants.Create(c.Request.Context(), &t); err != nil {
+        if errors.Is(err, repository.ErrConflict) {
+            c.Error(apierror.Conflict("tenant_exists", "a tenant with this slug already exists"))
+            return
+        }
+        c.Error(apierror.Internal(fmt.Errorf("create tenant: %w", err)))
+        return
+    }
+
+    logger.Log.Info("Tenant created", zap.String("id", t.ID.String()), zap.String("slug", t.Slug))
+    c.JSON(http.StatusCreated, t)
+}
+
+// @Summary List tenants
+// @Description Get every tenant
+// @Tags tenants
+// @Produce json
+// @Success 200 {array} models.Tenant
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Router /tenants [get]
+func (h *TenantHandler) ListTenants(c *gin.Context) {
+    tenants, err := h.tenants.List(c.Request.Context())
+    if err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("list tenants: %w", err)))
+        return
+    }
+    if tenants == nil {
+        tenants = []models.Tenant{}
+    }
+    c.JSON(http.StatusOK, tenants)
+}
+
+// @Summary Get a tenant
+// @Description Get tenant by ID
+// @Tags tenants
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} models.Tenant
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Router /tenants/{id} [get]
+func (h *TenantHandler) GetTenant(c *gin.Context) {
+    id, err := uuid.Parse(c.Param("id"))
+    if err != nil {
+        c.Error(tenantNotFound())
+        return
+    }
+    t, err := h.tenants.Get(c.Request.Context(), id)
+    if err != nil {
+        if errors.Is(err, repository.ErrNotFound) {
+            c.Error(tenantNotFound())
+            return
+        }
+        c.Error(apierror.Internal(fmt.Errorf("get tenant %s: %w", id, err)))
+        return
+    }
+    c.JSON(http.StatusOK, t)
+}
+
+func tenantNotFound() *apierror.Error {
+    return apierror.NotFound("tenant_not_found", "tenant not found")
+}
@@ -0,0 +1,140 @@
+package handlers_test
+
+import (
+    "net/http"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5"
+    "github.com/google/uuid"
+
+    "service/internal/config"
+    "service/internal/handlers"
+    "service/internal/models"
+    "service/internal/tenant"
+)
+
+// tenantBearer returns an Authorization header value for a token of sub
+// bound to tenantID.
+func tenantBearer(t *testing.T, sub string, tenantID uuid.UUID, roles ...string) string {
+    t.Helper()
+    claims := jwt.MapClaims{"sub": sub, "roles": roles, "tenant_id": tenantID.String(), "exp": time.Now().Add(time.Hour).Unix()}
+    raw, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(jwtSecret))
+    if err != nil {
+        t.Fatalf("sign token: %v", err)
+    }
+    return "Bearer " + raw
+}
+
+func (s *server) createTenant(slug string, headers ...string) models.Tenant {
+    s.t.Helper()
+    var created models.Tenant
+    s.expect(s.do(http.MethodPost, "/tenants", `{"Slug":"`+slug+`","Name":"Team `+slug+`"}`, headers...), http.StatusCreated, &created)
+    return created
+}
+
+func TestTenantsIsolateSubscriptions(t *testing.T) {
+    s := newServer(t)
+    payments := s.createTenant("payments")
+    user := uuid.New()
+
+    var sub models.Subscription
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(user, "Netflix", 400), tenant.Header, "payments"), http.StatusCreated, &sub)
+    if sub.TenantID != payments.ID {
+        t.Errorf("created in tenant %s, want %s", sub.TenantID, payments.ID)
+    }
+
+    var page handlers.SubscriptionPage
+    s.expect(s.do(http.MethodGet, "/subscriptions", ""), http.StatusOK, &page)
+    if page.Total != 0 {
+        t.Errorf("default tenant sees %+v", page.Items)
+    }
+    s.expect(s.do(http.MethodGet, "/subscriptions", "", tenant.Header, payments.ID.String()), http.StatusOK, &page)
+    if page.Total != 1 {
+        t.Errorf("tenant sees %d subscriptions, want 1", page.Total)
+    }
+    for _, method := range []string{http.MethodGet, http.MethodDelete} {
+        if w := s.do(method, "/subscriptions/"+sub.ID.String(), ""); w.Code != http.StatusNotFound {
+            t.Errorf("%s from the default tenant answered %d", method, w.Code)
+        }
+    }
+
+    if p := s.problem(s.do(http.MethodGet, "/subscriptions", "", tenant.Header, "nobody")); p.Status != http.StatusNotFound || p.Code != "tenant_not_found" {
+        t.Errorf("unknown tenant answered %+v", p)
+    }
+    if p := s.problem(s.do(http.MethodPost, "/tenants", `{"Slug":"payments","Name":"Again"}`)); p.Status != http.StatusConflict || p.Code != "tenant_exists" {
+        t.Errorf("duplicate slug answered %+v", p)
+    }
+    if got := s.fields(s.do(http.MethodPost, "/tenants", `{"Slug":"`+uuid.NewString()+`","Name":""}`)); got["Slug"] == "" || got["Name"] == "" {
+        t.Errorf("invalid tenant rejected fields %v", got)
+    }
+}
+
+func TestTenantBoundCallers(t *testing.T) {
+    s := newAuthServer(t)
+    admin := bearer(t, "ops", "admin")
+    payments := s.createTenant("payments", "Authorization", admin)
+    user := uuid.New()
+    member := tenantBearer(t, user.String(), payments.ID)
+
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(user, "Netflix", 400), "Authorization", member), http.StatusCreated, nil)
+    var page handlers.SubscriptionPage
+    s.expect(s.do(http.MethodGet, "/subscriptions", "", "Authorization", bearer(t, user.String())), http.StatusOK, &page)
+    if page.Total != 0 {
+        t.Errorf("default tenant caller sees %+v", page.Items)
+    }
+    s.expect(s.do(http.MethodGet, "/subscriptions", "", "Authorization", admin, tenant.Header, "payments"), http.StatusOK, &page)
+    if page.Total != 1 {
+        t.Errorf("admin acting for the tenant sees %d subscriptions, want 1", page.Total)
+    }
+
+    for name, headers := range map[string][]string{
+        "member naming another tenant": {"Authorization", member, tenant.Header, models.DefaultTenantID.String()},
+        "user naming a tenant":         {"Authorization", bearer(t, user.String()), tenant.Header, "payments"},
+    } {
+        if w := s.do(http.MethodGet, "/subscriptions", "", headers...); w.Code != http.StatusForbidden {
+            t.Errorf("%s answered %d", name, w.Code)
+        }
+    }
+    for name, token := range map[string]string{
+        "tenant admin": tenantBearer(t, "ops", payments.ID, "admin"),
+        "user":         bearer(t, user.String()),
+    } {
+        if w := s.do(http.MethodGet, "/tenants", "", "Authorization", token); w.Code != http.StatusForbidden {
+            t.Errorf("%s listing tenants answered %d", name, w.Code)
+        }
+    }
+    rates := `[{"BaseCurrency":"USD","QuoteCurrency":"RUB","Rate":90,"EffectiveDate":"2026-01-01T00:00:00Z"}]`
+    s.expect(s.do(http.MethodPost, "/exchange-rates", rates, "Authorization", tenantBearer(t, "ops", payments.ID, "admin")), http.StatusForbidden, nil)
+    s.expect(s.do(http.MethodPost, "/exchange-rates", rates, "Authorization", admin), http.StatusOK, nil)
+
+    var tenants []models.Tenant
+    s.expect(s.do(http.MethodGet, "/tenants", "", "Authorization", admin), http.StatusOK, &tenants)
+    if len(tenants) < 2 {
+        t.Errorf("tenants %+v, want the default tenant and payments", tenants)
+    }
+}
+
+func TestCalendarFeedOfTenant(t *testing.T) {
+    s := newServerWith(t, &config.Config{CalendarSecret: "secret"})
+    payments := s.createTenant("payments")
+    user := uuid.New()
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(user, "Netflix", 400), tenant.Header, "payments"), http.StatusCreated, nil)
+
+    var link handlers.CalendarLink
+    s.expect(s.do(http.MethodGet, "/users/"+user.String()+"/calendar", "", tenant.Header, "payments"), http.StatusOK, &link)
+    if !strings.HasSuffix(link.Path, "&tenant="+payments.ID.String()) {
+        t.Errorf("link %+v does not name the tenant", link)
+    }
+    w := s.do(http.MethodGet, link.Path, "")
+    s.expect(w, http.StatusOK, nil)
+    if !strings.Contains(w.Body.String(), "Netflix renewal") {
+        t.Errorf("feed misses the tenant's subscription:\n%s", w.Body)
+    }
+
+    defaultPath := strings.TrimSuffix(link.Path, "&tenant="+payments.ID.String())
+    if w := s.do(http.MethodGet, defaultPath, ""); w.Code != http.StatusNotFound {
+        t.Errorf("token accepted for the default tenant: %d", w.Code)
+    }
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "go.uber.org/zap"
+
+    "service/internal/apierror"
+    "service/internal/logger"
+    "service/internal/models"
+    "service/internal/repository"
+    "service/internal/validation"
+)
+
+// WebhookHandler serves the webhook endpoint registration and delivery log.
+type WebhookHandler struct {
+    webhooks repository.WebhookRepository
+    // allowHTTP accepts endpoint URLs without TLS.
+    allowHTTP bool
+}
+
+func NewWebhookHandler(webhooks repository.WebhookRepository, allowHTTP bool) *WebhookHandler {
+    return &WebhookHandler{webhooks: webhooks, allowHTTP: allowHTTP}
+}
+
+// WebhookEndpointInput registers a webhook endpoint.
+type WebhookEndpointInput struct {
+    URL    string             `example:"https://budget.example.com/hooks/subscriptions"`
+    Secret string             `example:"a-long-random-shared-secret"`
+    Events []models.EventType `swaggertype:"array,string" example:"subscription.created,subscription.deleted"`
+}
+
+// @Summary Register a webhook endpoint
+// @Description Register a URL to receive the given subscription lifecycle events: subscription.created, subscription.updated, subscription.deleted, subscription.restored and subscription.expired (sent once the end month is over). The URL must use https, and deliveries are only sent to public addresses. Every delivery is a JSON POST carrying the Webhook-Event, Webhook-Delivery and Webhook-Signature headers; the signature is "t=<unix seconds>,v1=<hex HMAC-SHA256 of the timestamp, a dot and the body, keyed with the secret>". Responses other than 2xx are retried with exponential backoff, up to 10 attempts.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param endpoint body WebhookEndpointInput true "Endpoint URL, signing secret and event types"
+// @Success 201 {object} models.WebhookEndpoint
+// @Failure 400 {object} apierror.Problem
+// @Failure 422 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+    var input WebhookEndpointInput
+    if !bindJSON(c, &input) {
+        return
+    }
+
+    endpoint := models.WebhookEndpoint{
+        ID:        uuid.New(),
+        URL:       input.URL,
+        Secret:    input.Secret,
+        Events:    input.Events,
+        CreatedAt: time.Now().UTC(),
+    }
+    if errs := validation.WebhookEndpoint(&endpoint, h.allowHTTP); len(errs) > 0 {
+        c.Error(apierror.Invalid(http.StatusUnprocessableEntity, errs))
+        return
+    }
+    if err := h.webhooks.CreateEndpoint(c.Request.Context(), &endpoint); err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("create webhook endpoint: %w", err)))
+        return
+    }
+
+    logger.Log.Info("Webhook endpoint registered",
+        zap.String("id", endpoint.ID.String()),
+        zap.String("url", endpoint.URL),
+    )
+    c.JSON(http.StatusCreated, endpoint)
+}
+
+// @Summary List webhook endpoints
+// @Description Get every registered webhook endpoint
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} models.WebhookEndpoint
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Router /webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+    endpoints, err := h.webhooks.ListEndpoints(c.Request.Context())
+    if err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("list webhook endpoints: %w", err)))
+        return
+    }
+    if endpoints == nil {
+        endpoints = []models.WebhookEndpoint{}
+    }
+    c.JSON(http.StatusOK, endpoints)
+}
+
+// @Summary Get a webhook endpoint
+// @Description Get webhook endpoint by ID
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook endpoint ID"
+// @Success 200 {object} models.WebhookEndpoint
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+    endpoint, ok := h.loadWebhook(c)
+    if !ok {
+        return
+    }
+    c.JSON(http.StatusOK, endpoint)
+}
+
+// @Summary Delete a webhook endpoint
+// @Description Stop sending events to a webhook endpoint and drop its delivery log
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook endpoint ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+    id, err := uuid.Parse(c.Param("id"))
+    if err != nil {
+        c.Error(webhookNotFound())
+        return
+    }
+    if err := h.webhooks.DeleteEndpoint(c.Request.Context(), id); err != nil {
+        if errors.Is(err, repository.ErrNotFound) {
+            c.Error(webhookNotFound())
+            return
+        }
+        c.Error(apierror.Internal(fmt.Errorf("delete webhook endpoint %s: %w", id, err)))
+        return
+    }
+
+    logger.Log.Info("Webhook endpoint deleted", zap.String("id", id.String()))
+    c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// @Summary List webhook deliveries
+// @Description Get the latest deliveries to a webhook endpoint, newest first, with the outcome of their last attempt
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook endpoint ID"
+// @Param status query string false "Only deliveries in this state" Enums(pending, succeeded, failed)
+// @Param limit query int false "Number of deliveries, 1-500 (default 50)"
+// @Success 200 {array} models.WebhookDelivery
+// @Failure 400 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Security BearerAuth
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+    q := validation.NewQuery(c.Request.URL.Query())
+    filter := repository.DeliveryFilter{
+        Status: models.DeliveryStatus(q.String("status")),
+        Limit:  defaultPageLimit,
+    }
+    switch filter.Status {
+    case "", models.DeliveryPending, models.DeliverySucceeded, models.DeliveryFailed:
+    default:
+        q.Fail("status", string(filter.Status), validation.CodeUnsupported, "must be pending, succeeded or failed")
+    }
+    if limit := q.Int("limit", 1, maxPageLimit); limit != nil {
+        filter.Limit = *limit
+    }
+    if len(q.Errors) > 0 {
+        c.Error(apierror.Invalid(http.StatusBadRequest, q.Errors))
+        return
+    }
+
+    endpoint, ok := h.loadWebhook(c)
+    if !ok {
+        return
+    }
+    filter.EndpointID = endpoint.ID
+    deliveries, err := h.webhooks.ListDeliveries(c.Request.Context(), filter)
+    if err != nil {
+        c.Error(apierror.Internal(fmt.Errorf("list deliveries of webhook endpoint %s: %w", endpoint.ID, err)))
+        return
+    }
+    if deliveries == nil {
+        deliveries = []models.WebhookDelivery{}
+    }
+    c.JSON(http.StatusOK, deliveries)
+}
+
+// loadWebhook fetches the endpoint named by the id path parameter. On
+// failure the response is written and ok is false.
+func (h *WebhookHandler) loadWebhook(c *gin.Context) (*models.WebhookEndpoint, bool) {
+    id, err := uuid.Parse(c.Param("id"))
+    if err != nil {
+        c.Error(webhookNotFound())
+        return nil, false
+    }
+    endpoint, err := h.webhooks.GetEndpoint(c.Request.Context(), id)
+    if err != nil {
+        if errors.Is(err, repository.ErrNotFound) {
+            c.Error(webhookNotFound())
+        } else {
+            c.Error(apierror.Internal(fmt.Errorf("get webhook endpoint %s: %w", id, err)))
+        }
+        return nil, false
+    }
+    return endpoint, true
+}
+
+func webhookNotFound() *apierror.Error {
+    return apierror.NotFound("webhook_not_found", "webhook endpoint not found")
+}
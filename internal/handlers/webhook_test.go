@@ -0,0 +1,90 @@
+package handlers_test
+
+import (
+    "context"
+    "net/http"
+    "strings"
+    "testing"
+
+    "github.com/google/uuid"
+
+    "service/internal/config"
+    "service/internal/models"
+    "service/internal/validation"
+)
+
+func TestWebhookEndpoints(t *testing.T) {
+    s := newServer(t)
+    body := `{"URL":"https://hooks.example.com/subs","Secret":"0123456789abcdef","Events":["subscription.created"]}`
+    var endpoint models.WebhookEndpoint
+    w := s.do(http.MethodPost, "/webhooks", body)
+    s.expect(w, http.StatusCreated, &endpoint)
+    if endpoint.ID == uuid.Nil || endpoint.Secret != "" {
+        t.Errorf("registered %+v", endpoint)
+    }
+    if got := w.Body.String(); got == "" || strings.Contains(got, "0123456789abcdef") {
+        t.Errorf("secret returned: %s", got)
+    }
+
+    var endpoints []models.WebhookEndpoint
+    s.expect(s.do(http.MethodGet, "/webhooks", ""), http.StatusOK, &endpoints)
+    if len(endpoints) != 1 || endpoints[0].ID != endpoint.ID {
+        t.Errorf("listed %+v", endpoints)
+    }
+    var deliveries []models.WebhookDelivery
+    s.expect(s.do(http.MethodGet, "/webhooks/"+endpoint.ID.String()+"/deliveries?status=pending", ""), http.StatusOK, &deliveries)
+    if deliveries == nil || len(deliveries) != 0 {
+        t.Errorf("deliveries %+v", deliveries)
+    }
+    s.expect(s.do(http.MethodGet, "/webhooks/"+endpoint.ID.String()+"/deliveries?status=lost", ""), http.StatusBadRequest, nil)
+
+    s.expect(s.do(http.MethodDelete, "/webhooks/"+endpoint.ID.String(), ""), http.StatusOK, nil)
+    for _, path := range []string{"/webhooks/" + endpoint.ID.String(), "/webhooks/nope"} {
+        if p := s.problem(s.do(http.MethodGet, path, "")); p.Code != "webhook_not_found" {
+            t.Errorf("%s answered %+v", path, p)
+        }
+    }
+
+    w = s.do(http.MethodPost, "/webhooks", `{"URL":"ftp://example.com","Secret":"short","Events":["subscription.renamed"]}`)
+    if got := s.fields(w); w.Code != http.StatusUnprocessableEntity || len(got) != 3 || got["Events[0]"] == "" {
+        t.Errorf("invalid endpoint answered %d %v", w.Code, got)
+    }
+}
+
+func TestWebhookURLsRequireHTTPS(t *testing.T) {
+    body := `{"URL":"http://hooks.example.com/subs","Secret":"0123456789abcdef","Events":["subscription.created"]}`
+    s := newServer(t)
+    w := s.do(http.MethodPost, "/webhooks", body)
+    if got := s.fields(w); w.Code != http.StatusUnprocessableEntity || got["URL"] != validation.CodeInvalidFormat {
+        t.Errorf("http URL answered %d %v", w.Code, got)
+    }
+
+    s = newServerWith(t, &config.Config{WebhookAllowHTTP: true})
+    s.expect(s.do(http.MethodPost, "/webhooks", body), http.StatusCreated, nil)
+    s.expect(s.do(http.MethodPost, "/webhooks", strings.Replace(body, "http:", "ftp:", 1)), http.StatusUnprocessableEntity, nil)
+}
+
+func TestSubscriptionChangesEmitEvents(t *testing.T) {
+    s := newServer(t)
+    user := uuid.New()
+    var sub models.Subscription
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(user, "Netflix", 400)), http.StatusCreated, &sub)
+    s.expect(s.do(http.MethodPut, "/subscriptions/"+sub.ID.String(), subscriptionJSON(user, "Netflix 4K", 400)), http.StatusOK, nil)
+    s.expect(s.do(http.MethodDelete, "/subscriptions/"+sub.ID.String(), ""), http.StatusOK, nil)
+    s.expect(s.do(http.MethodPost, "/subscriptions/"+sub.ID.String()+"/restore", ""), http.StatusOK, nil)
+    s.expect(s.do(http.MethodPost, "/subscriptions", subscriptionJSON(user, "", -1)), http.StatusUnprocessableEntity, nil)
+
+    events, err := s.repos.Outbox.Pending(context.Background(), 10)
+    if err != nil {
+        t.Fatalf("pending: %v", err)
+    }
+    want := []models.EventType{models.EventSubscriptionCreated, models.EventSubscriptionUpdated, models.EventSubscriptionDeleted, models.EventSubscriptionRestored}
+    if len(events) != len(want) {
+        t.Fatalf("outbox holds %+v, want %v", events, want)
+    }
+    for i, event := range events {
+        if event.Type != want[i] || !strings.Contains(event.Payload, sub.ID.String()) {
+            t.Errorf("event %d is %s: %s", i, event.Type, event.Payload)
+        }
+    }
+}
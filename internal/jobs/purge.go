@@ -0,0 +1,106 @@
+// Package jobs runs the service's periodic background work.
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"service/internal/audit"
+	"service/internal/logger"
+	"service/internal/models"
+	"service/internal/reminders"
+	"service/internal/repository"
+	"service/internal/webhooks"
+)
+
+// PurgeTrash permanently removes subscriptions that have been in the trash
+// longer than retention, checking every interval until ctx is done. Every
+// removal is recorded in the audit log in the same transaction.
+func PurgeTrash(ctx context.Context, repos repository.Repositories, retention, interval time.Duration) {
+	every(ctx, interval, func() {
+		purged, err := purgeTrash(ctx, repos, time.Now(), retention)
+		if err != nil {
+			logger.Log.Error("Purging deleted subscriptions failed", zap.Error(err))
+		} else if purged > 0 {
+			logger.Log.Info("Deleted subscriptions purged", zap.Int("count", purged), zap.Duration("retention", retention))
+		}
+	})
+}
+
+func purgeTrash(ctx context.Context, repos repository.Repositories, now time.Time, retention time.Duration) (int, error) {
+	var purged []models.Subscription
+	err := repos.Tx.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if purged, err = repos.Subscriptions.Purge(ctx, now.Add(-retention)); err != nil {
+			return err
+		}
+		for i := range purged {
+			entry, err := audit.NewEntry(ctx, models.AuditPurge, &purged[i], nil, now)
+			if err != nil {
+				return err
+			}
+			entry.Actor = audit.SystemActor
+			if err := repos.Audit.Append(ctx, entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return len(purged), err
+}
+
+// PurgeIdempotencyKeys removes expired idempotency keys every interval until
+// ctx is done.
+func PurgeIdempotencyKeys(ctx context.Context, keys repository.IdempotencyRepository, interval time.Duration) {
+	every(ctx, interval, func() {
+		purged, err := keys.Purge(ctx, time.Now())
+		if err != nil {
+			logger.Log.Error("Purging idempotency keys failed", zap.Error(err))
+		} else if purged > 0 {
+			logger.Log.Info("Expired idempotency keys purged", zap.Int64("count", purged))
+		}
+	})
+}
+
+// SendReminders runs the reminder scheduler every interval until ctx is
+// done.
+func SendReminders(ctx context.Context, scheduler *reminders.Scheduler, interval time.Duration) {
+	every(ctx, interval, func() {
+		sent, err := scheduler.Run(ctx, time.Now())
+		if err != nil {
+			logger.Log.Error("Sending reminders failed", zap.Int("sent", sent), zap.Error(err))
+		} else if sent > 0 {
+			logger.Log.Info("Reminders sent", zap.Int("count", sent))
+		}
+	})
+}
+
+// DeliverWebhooks runs the webhook dispatcher every interval until ctx is
+// done.
+func DeliverWebhooks(ctx context.Context, dispatcher *webhooks.Dispatcher, interval time.Duration) {
+	every(ctx, interval, func() {
+		delivered, err := dispatcher.Run(ctx, time.Now())
+		if err != nil {
+			logger.Log.Error("Dispatching webhooks failed", zap.Int("delivered", delivered), zap.Error(err))
+		} else if delivered > 0 {
+			logger.Log.Info("Webhooks delivered", zap.Int("count", delivered))
+		}
+	})
+}
+
+// every runs fn immediately and then every interval until ctx is done.
+func every(ctx context.Context, interval time.Duration, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		fn()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
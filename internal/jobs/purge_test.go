@@ -0,0 +1,65 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"service/internal/audit"
+	"service/internal/logger"
+	"service/internal/models"
+	"service/internal/repository"
+)
+
+func TestPurgeTrashRunsUntilCancelled(t *testing.T) {
+	logger.Log = zap.NewNop()
+	repos := repository.NewMemory()
+	subs := repos.Subscriptions
+	ctx := context.Background()
+	sub := &models.Subscription{ServiceName: "Netflix", UserID: uuid.New(), StartDate: models.NewMonth(time.Now())}
+	if err := subs.Create(ctx, sub); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := subs.Delete(ctx, sub.ID, sub.Version); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		PurgeTrash(ctx, repos, -time.Minute, time.Millisecond)
+		close(done)
+	}()
+	deadline := time.Now().Add(time.Second)
+	for {
+		left, err := subs.List(context.Background(), repository.SubscriptionFilter{Deleted: repository.IncludeDeleted})
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		if len(left) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("deleted subscription was not purged")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("PurgeTrash did not return after cancellation")
+	}
+
+	entries, err := repos.Audit.List(context.Background(), repository.AuditFilter{SubscriptionID: sub.ID})
+	if err != nil {
+		t.Fatalf("list audit entries: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Action != models.AuditPurge || entries[0].Actor != audit.SystemActor {
+		t.Errorf("audit entries %+v, want one purge by the system", entries)
+	}
+}
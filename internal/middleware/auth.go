@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"service/internal/apierror"
+	"service/internal/auth"
+	"service/internal/logger"
+	"service/internal/models"
+	"service/internal/repository"
+)
+
+// lastUsedResolution is how stale the recorded last use of an API key may
+// get, so not every request writes to the key.
+const lastUsedResolution = time.Minute
+
+// Authenticate identifies the caller by the API key in the X-API-Key header
+// or else by a bearer token that verifier accepts, and stores the caller's
+// principal in the request context. A nil verifier disables token
+// authentication: requests without an API key are let through.
+func Authenticate(verifier *auth.Verifier, keys repository.APIKeyRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key := c.GetHeader(auth.APIKeyHeader); key != "" {
+			authenticateKey(c, keys, key)
+			return
+		}
+		if verifier == nil {
+			c.Next()
+			return
+		}
+
+		scheme, token, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			c.Header("WWW-Authenticate", `Bearer`)
+			c.Error(apierror.Unauthorized(apierror.CodeNoToken, "a bearer token or API key is required"))
+			c.Abort()
+			return
+		}
+		principal, err := verifier.Verify(strings.TrimSpace(token))
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.Error(apierror.Unauthorized(apierror.CodeBadToken, "the bearer token is invalid or expired").WithCause(err))
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+func authenticateKey(c *gin.Context, keys repository.APIKeyRepository, raw string) {
+	ctx := c.Request.Context()
+	now := time.Now()
+	invalid := apierror.Unauthorized(apierror.CodeBadKey, "the API key is invalid, revoked or expired")
+
+	prefix, ok := auth.APIKeyPrefix(raw)
+	if !ok {
+		c.Error(invalid)
+		c.Abort()
+		return
+	}
+	key, err := keys.GetByPrefix(ctx, prefix)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		c.Error(invalid)
+		c.Abort()
+		return
+	case err != nil:
+		c.Error(apierror.Internal(fmt.Errorf("get API key %s: %w", prefix, err)))
+		c.Abort()
+		return
+	}
+	if !auth.MatchAPIKey(raw, key.Hash) || !key.Active(now) {
+		c.Error(invalid.WithCause(fmt.Errorf("API key %s rejected", prefix)))
+		c.Abort()
+		return
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := keys.Touch(context.WithoutCancel(ctx), key.ID, now.UTC()); err != nil {
+			logger.Log.Warn("Recording API key use failed", zap.String("request_id", GetRequestID(c)), zap.Error(err))
+		}
+	}
+
+	principal := &auth.Principal{
+		Subject:  key.Prefix,
+		TenantID: key.TenantID,
+		APIKeyID: key.ID,
+		Scopes:   key.Scopes,
+		Admin:    key.UserID == nil,
+	}
+	if key.UserID != nil {
+		principal.UserID = *key.UserID
+	}
+	c.Request = c.Request.WithContext(auth.NewContext(ctx, principal))
+	c.Next()
+}
+
+// RequireAdmin rejects callers without the admin role with 403. API keys are
+// never admins, even when they see every user's data. Without authentication
+// every caller is let through.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if principal, ok := auth.FromContext(c.Request.Context()); ok && (!principal.Admin || principal.IsAPIKey()) {
+			c.Error(apierror.Forbidden(apierror.CodeForbidden, "this operation requires the admin role"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireScope rejects API keys without scope with 403. Users are let
+// through; what they may see is limited by their role.
+func RequireScope(scope models.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if principal, ok := auth.FromContext(c.Request.Context()); ok && !principal.Allows(scope) {
+			c.Error(apierror.Forbidden(apierror.CodeScope, "the API key lacks the "+string(scope)+" scope"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
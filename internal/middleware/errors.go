@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"service/internal/apierror"
+	"service/internal/logger"
+)
+
+// Errors renders the last error a handler attached with c.Error as an
+// application/problem+json response. Errors that are not *apierror.Error are
+// reported as a generic 500. The internal cause is logged together with the
+// correlation ID instead of being exposed.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 {
+			return
+		}
+		err := c.Errors.Last().Err
+
+		var apiErr *apierror.Error
+		if !errors.As(err, &apiErr) {
+			apiErr = apierror.Internal(err)
+		}
+
+		requestID := GetRequestID(c)
+		fields := []zap.Field{
+			zap.String("request_id", requestID),
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.Int("status", apiErr.Status),
+			zap.String("code", apiErr.Code),
+		}
+		if apiErr.Cause != nil {
+			fields = append(fields, zap.Error(apiErr.Cause))
+		}
+		if apiErr.Status >= 500 {
+			logger.Log.Error("Request failed", fields...)
+		} else {
+			logger.Log.Warn("Request rejected", fields...)
+		}
+
+		if c.Writer.Written() {
+			return
+		}
+		c.Header("Content-Type", apierror.ContentType)
+		c.JSON(apiErr.Status, apiErr.Problem(c.Request.URL.Path, requestID))
+	}
+}
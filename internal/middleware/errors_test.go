@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"service/internal/apierror"
+	"service/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	logger.Log = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+func serve(r *gin.Engine, req *http.Request) (*httptest.ResponseRecorder, apierror.Problem) {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var p apierror.Problem
+	json.Unmarshal(w.Body.Bytes(), &p)
+	return w, p
+}
+
+func TestErrorsRendersProblems(t *testing.T) {
+	r := gin.New()
+	r.Use(RequestID(), Errors())
+	r.GET("/conflict", func(c *gin.Context) {
+		c.Error(apierror.Conflict(apierror.CodeConflict, "already exists"))
+	})
+	r.GET("/boom", func(c *gin.Context) {
+		c.Error(errors.New("password=hunter2"))
+	})
+
+	w, p := serve(r, httptest.NewRequest(http.MethodGet, "/conflict", nil))
+	if w.Code != http.StatusConflict || p.Code != apierror.CodeConflict || p.Detail != "already exists" || p.Instance != "/conflict" {
+		t.Errorf("conflict rendered as %d %+v", w.Code, p)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, apierror.ContentType) {
+		t.Errorf("content type %q", ct)
+	}
+	if p.RequestID == "" || p.RequestID != w.Header().Get(RequestIDHeader) {
+		t.Errorf("request ID %q, header %q", p.RequestID, w.Header().Get(RequestIDHeader))
+	}
+
+	w, p = serve(r, httptest.NewRequest(http.MethodGet, "/boom", nil))
+	if w.Code != http.StatusInternalServerError || p.Code != apierror.CodeInternal {
+		t.Errorf("plain error rendered as %d %+v", w.Code, p)
+	}
+	if strings.Contains(w.Body.String(), "hunter2") {
+		t.Errorf("internal cause leaked: %s", w.Body)
+	}
+}
+
+func TestRequestIDReusesWellFormedHeader(t *testing.T) {
+	r := gin.New()
+	r.Use(RequestID())
+	r.GET("/", func(c *gin.Context) {
+		if got := RequestIDFromContext(c.Request.Context()); got != GetRequestID(c) {
+			t.Errorf("context ID %q, gin ID %q", got, GetRequestID(c))
+		}
+	})
+
+	for incoming, reused := range map[string]bool{
+		"trace-42":               true,
+		"":                       false,
+		"has space":              false,
+		strings.Repeat("x", 129): false,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if incoming != "" {
+			req.Header.Set(RequestIDHeader, incoming)
+		}
+		w, _ := serve(r, req)
+		got := w.Header().Get(RequestIDHeader)
+		if got == "" || (got == incoming) != reused {
+			t.Errorf("incoming %q answered with %q", incoming, got)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"service/internal/apierror"
+	"service/internal/auth"
+	"service/internal/logger"
+	"service/internal/models"
+	"service/internal/repository"
+	"service/internal/tenant"
+)
+
+// IdempotencyKeyHeader names the client-chosen key that identifies retries of
+// the same request.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set on responses replayed from a stored key.
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+const maxIdempotencyKeyLength = 255
+
+// replayedHeaders are the response headers stored with a key and replayed.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// Idempotency makes a route safe to retry. The first request carrying an
+// Idempotency-Key is processed and its response stored for ttl; retries with
+// the same key and body get the stored response, retries with a different
+// body are rejected with 422 and retries racing the first request with 409.
+// Responses reported through c.Error and 5xx responses are not stored, so
+// such requests can be retried with the same key.
+func Idempotency(keys repository.IdempotencyRepository, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.Error(apierror.BadRequest("idempotency_key_invalid", fmt.Sprintf("the %s header must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength)))
+			c.Abort()
+			return
+		}
+
+		body, err := c.GetRawData()
+		if err != nil {
+			c.Error(apierror.BadRequest(apierror.CodeMalformed, "request body could not be read").WithCause(err))
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		now := time.Now()
+		rec := &models.IdempotencyKey{
+			Principal:   idempotencyPrincipal(ctx),
+			Key:         key,
+			RequestHash: requestHash(ctx, c.Request.Method, c.Request.URL.Path, body),
+			Headers:     "{}",
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		}
+		if err := keys.Reserve(ctx, rec); err != nil {
+			if !errors.Is(err, repository.ErrConflict) {
+				c.Error(apierror.Internal(fmt.Errorf("reserve idempotency key: %w", err)))
+				c.Abort()
+				return
+			}
+			replay(c, keys, rec)
+			c.Abort()
+			return
+		}
+
+		writer := &captureWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		status := writer.Status()
+		if len(c.Errors) > 0 || !writer.Written() || status >= http.StatusInternalServerError {
+			if err := keys.Release(context.WithoutCancel(ctx), rec.Principal, key); err != nil {
+				logger.Log.Error("Releasing idempotency key failed", zap.String("request_id", GetRequestID(c)), zap.Error(err))
+			}
+			return
+		}
+
+		headers := map[string]string{}
+		for _, name := range replayedHeaders {
+			if value := writer.Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		encoded, _ := json.Marshal(headers)
+		rec.StatusCode = status
+		rec.Headers = string(encoded)
+		rec.Body = writer.body.Bytes()
+		if err := keys.Complete(context.WithoutCancel(ctx), rec); err != nil {
+			logger.Log.Error("Storing idempotent response failed", zap.String("request_id", GetRequestID(c)), zap.Error(err))
+		}
+	}
+}
+
+// replay answers a request whose key is already known.
+func replay(c *gin.Context, keys repository.IdempotencyRepository, rec *models.IdempotencyKey) {
+	stored, err := keys.Get(c.Request.Context(), rec.Principal, rec.Key)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		// The first request failed and released the key just now.
+		c.Error(apierror.Conflict("idempotency_key_in_progress", "a request with this idempotency key is being processed, retry later"))
+		return
+	case err != nil:
+		c.Error(apierror.Internal(fmt.Errorf("get idempotency key: %w", err)))
+		return
+	}
+
+	if stored.RequestHash != rec.RequestHash {
+		c.Error(apierror.Unprocessable("idempotency_key_reused", "the idempotency key was already used with a different request"))
+		return
+	}
+	if stored.StatusCode == 0 {
+		c.Error(apierror.Conflict("idempotency_key_in_progress", "a request with this idempotency key is being processed, retry later"))
+		return
+	}
+
+	var headers map[string]string
+	_ = json.Unmarshal([]byte(stored.Headers), &headers)
+	for name, value := range headers {
+		c.Header(name, value)
+	}
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Data(stored.StatusCode, headers["Content-Type"], stored.Body)
+}
+
+// idempotencyPrincipal names the caller whose idempotency keys are kept apart
+// from everyone else's. Without authentication all callers share one
+// namespace.
+func idempotencyPrincipal(ctx context.Context) string {
+	principal, ok := auth.FromContext(ctx)
+	switch {
+	case !ok:
+		return "anonymous"
+	case principal.IsAPIKey():
+		return "key:" + principal.APIKeyID.String()
+	default:
+		return "user:" + principal.Subject
+	}
+}
+
+// requestHash identifies a request by its target, body, caller and tenant.
+// Keys are already stored per tenant and principal; the caller is hashed too
+// so a record can never replay a response to anyone but its sender.
+func requestHash(ctx context.Context, method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	if principal, ok := auth.FromContext(ctx); ok {
+		h.Write([]byte(principal.Subject + " " + principal.UserID.String() + "\n"))
+	}
+	if id, ok := tenant.FromContext(ctx); ok {
+		h.Write([]byte(id.String() + "\n"))
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// captureWriter keeps a copy of the response body.
+type captureWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
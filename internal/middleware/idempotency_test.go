@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"service/internal/apierror"
+	"service/internal/repository"
+)
+
+func TestIdempotencyReplaysStoredResponses(t *testing.T) {
+	var calls int
+	r := gin.New()
+	r.Use(RequestID(), Errors())
+	r.POST("/items", Idempotency(repository.NewMemoryIdempotencyRepository(), time.Hour), func(c *gin.Context) {
+		calls++
+		if strings.Contains(c.GetHeader("X-Fail"), "yes") {
+			c.Error(apierror.Unprocessable(apierror.CodeValidation, "rejected"))
+			return
+		}
+		c.Header("Location", "/items/1")
+		c.JSON(http.StatusCreated, gin.H{"call": calls})
+	})
+	post := func(key, body string, headers ...string) (*httptest.ResponseRecorder, apierror.Problem) {
+		req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		return serve(r, req)
+	}
+
+	first, _ := post("k1", `{"a":1}`)
+	retry, _ := post("k1", `{"a":1}`)
+	if first.Code != http.StatusCreated || retry.Code != http.StatusCreated || calls != 1 {
+		t.Fatalf("statuses %d and %d after %d calls, want one stored 201", first.Code, retry.Code, calls)
+	}
+	if retry.Body.String() != first.Body.String() || retry.Header().Get("Location") != "/items/1" || retry.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("replay %q with headers %v", retry.Body, retry.Header())
+	}
+
+	if w, p := post("k1", `{"a":2}`); w.Code != http.StatusUnprocessableEntity || p.Code != "idempotency_key_reused" {
+		t.Errorf("different body answered %d %+v", w.Code, p)
+	}
+	if w, _ := post("", `{"a":1}`); w.Code != http.StatusCreated || calls != 2 {
+		t.Errorf("request without a key answered %d after %d calls", w.Code, calls)
+	}
+	if w, p := post(strings.Repeat("k", maxIdempotencyKeyLength+1), `{}`); w.Code != http.StatusBadRequest || p.Code != "idempotency_key_invalid" {
+		t.Errorf("oversized key answered %d %+v", w.Code, p)
+	}
+
+	// Rejected requests release their key so a corrected retry can reuse it.
+	if w, _ := post("k2", `{}`, "X-Fail", "yes"); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("failing request answered %d", w.Code)
+	}
+	if w, _ := post("k2", `{}`); w.Code != http.StatusCreated || w.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Errorf("retry after a rejection answered %d %v", w.Code, w.Header())
+	}
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"service/internal/apierror"
+	"service/internal/auth"
+	"service/internal/models"
+	"service/internal/repository"
+	"service/internal/tenant"
+)
+
+// Tenant resolves the tenant a request acts for and scopes the request
+// context to it, so repositories only touch that tenant's rows. Callers bound
+// to a tenant by their token or API key act for it and may not name another
+// in the X-Tenant-ID header. Admins bound to no tenant, and every caller when
+// authentication is disabled, may pick one with the header. Everyone else
+// acts for the default tenant.
+func Tenant(tenants repository.TenantRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requested := c.GetHeader(tenant.Header)
+		principal, authenticated := auth.FromContext(c.Request.Context())
+
+		var resolved *models.Tenant
+		var err error
+		switch {
+		case authenticated && principal.TenantID != uuid.Nil:
+			resolved, err = tenants.Get(c.Request.Context(), principal.TenantID)
+		case requested != "" && (!authenticated || principal.Admin && !principal.IsAPIKey()):
+			resolved, err = lookupTenant(c, tenants, requested)
+		default:
+			resolved, err = tenants.Get(c.Request.Context(), models.DefaultTenantID)
+		}
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			c.Error(apierror.NotFound("tenant_not_found", "tenant not found"))
+			c.Abort()
+			return
+		case err != nil:
+			c.Error(apierror.Internal(fmt.Errorf("resolve tenant: %w", err)))
+			c.Abort()
+			return
+		}
+		if requested != "" && requested != resolved.ID.String() && requested != resolved.Slug {
+			c.Error(apierror.Forbidden(apierror.CodeForbidden, "the credentials do not allow acting for tenant "+requested))
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(tenant.NewContext(c.Request.Context(), resolved.ID))
+		c.Next()
+	}
+}
+
+// lookupTenant finds a tenant by ID or slug.
+func lookupTenant(c *gin.Context, tenants repository.TenantRepository, ref string) (*models.Tenant, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return tenants.Get(c.Request.Context(), id)
+	}
+	return tenants.GetBySlug(c.Request.Context(), ref)
+}
+
+// RequireUnboundAdmin rejects callers other than admins bound to no tenant
+// with 403, as they alone may manage tenants and the data shared by every
+// tenant. Without authentication every caller is let through.
+func RequireUnboundAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := auth.FromContext(c.Request.Context())
+		if ok && (!principal.Admin || principal.IsAPIKey() || principal.TenantID != uuid.Nil) {
+			c.Error(apierror.Forbidden(apierror.CodeForbidden, "this operation requires the admin role outside of any tenant"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
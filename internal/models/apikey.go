@@ -0,0 +1,99 @@
+package models
+
+import (
+    "database/sql/driver"
+    "encoding/json"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// Scope names an operation an API key is allowed to perform.
+type Scope string
+
+const (
+    ScopeSubscriptionsRead  Scope = "subscriptions:read"
+    ScopeSubscriptionsWrite Scope = "subscriptions:write"
+    ScopeSummaryRead        Scope = "summary:read"
+    ScopeBudgetsRead        Scope = "budgets:read"
+    ScopeBudgetsWrite       Scope = "budgets:write"
+    ScopeExchangeRatesRead  Scope = "exchange_rates:read"
+)
+
+// ScopesAll lists every scope.
+var ScopesAll = Scopes{
+    ScopeSubscriptionsRead,
+    ScopeSubscriptionsWrite,
+    ScopeSummaryRead,
+    ScopeBudgetsRead,
+    ScopeBudgetsWrite,
+    ScopeExchangeRatesRead,
+}
+
+// Valid reports whether the scope is one of the known ones.
+func (s Scope) Valid() bool {
+    return ScopesAll.Contains(s)
+}
+
+// Scopes is a set of scopes stored as a JSON array.
+type Scopes []Scope
+
+// Contains reports whether s is one of the scopes.
+func (ss Scopes) Contains(s Scope) bool {
+    for _, scope := range ss {
+        if scope == s {
+            return true
+        }
+    }
+    return false
+}
+
+func (ss Scopes) Value() (driver.Value, error) {
+    if ss == nil {
+        ss = Scopes{}
+    }
+    data, err := json.Marshal(ss)
+    return string(data), err
+}
+
+func (ss *Scopes) Scan(value any) error {
+    switch v := value.(type) {
+    case []byte:
+        return json.Unmarshal(v, ss)
+    case string:
+        return json.Unmarshal([]byte(v), ss)
+    case nil:
+        *ss = nil
+        return nil
+    }
+    return fmt.Errorf("cannot scan %T into Scopes", value)
+}
+
+func (Scopes) GormDataType() string {
+    return "jsonb"
+}
+
+// APIKey lets a service call the API without a user token. Only the SHA-256
+// hash of the key is stored; Prefix is its public part, used to look it up and
+// to tell keys apart. A key acts within the tenant it was issued in; bound to
+// a user it sees only that user's data, unbound the data of every user.
+type APIKey struct {
+    ID         uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+    TenantID   uuid.UUID  `gorm:"type:uuid;not null;index"`
+    Name       string     `gorm:"not null" example:"nightly-billing-export"`
+    Prefix     string     `gorm:"not null;uniqueIndex" example:"sk_3f9a1c2e"`
+    Hash       string     `gorm:"not null" json:"-"`
+    Scopes     Scopes     `gorm:"type:jsonb;not null" swaggertype:"array,string" example:"subscriptions:read,summary:read"`
+    UserID     *uuid.UUID `gorm:"type:uuid"`
+    CreatedAt  time.Time  `gorm:"not null"`
+    ExpiresAt  *time.Time
+    RotatedAt  *time.Time
+    RevokedAt  *time.Time
+    LastUsedAt *time.Time
+}
+
+// Active reports whether the key can authenticate requests at now.
+func (k *APIKey) Active(now time.Time) bool {
+    return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+    "database/sql/driver"
+    "encoding/json"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// AuditAction names the kind of change an audit entry records.
+type AuditAction string
+
+const (
+    AuditCreate  AuditAction = "create"
+    AuditUpdate  AuditAction = "update"
+    AuditDelete  AuditAction = "delete"
+    AuditRestore AuditAction = "restore"
+    // AuditPurge records the permanent removal of a subscription that was
+    // in the trash longer than the retention period.
+    AuditPurge AuditAction = "purge"
+)
+
+// Valid reports whether the action is one of the known ones.
+func (a AuditAction) Valid() bool {
+    switch a {
+    case AuditCreate, AuditUpdate, AuditDelete, AuditRestore, AuditPurge:
+        return true
+    }
+    return false
+}
+
+// AuditChange is the value of a field before and after a change. Old is
+// omitted for fields of a created or restored subscription and New for those
+// of a deleted or purged one.
+type AuditChange struct {
+    Old json.RawMessage `json:",omitempty" swaggertype:"object"`
+    New json.RawMessage `json:",omitempty" swaggertype:"object"`
+}
+
+// AuditChanges maps the JSON field names of a subscription to their change,
+// stored as a JSON object.
+type AuditChanges map[string]AuditChange
+
+func (cs AuditChanges) Value() (driver.Value, error) {
+    if cs == nil {
+        cs = AuditChanges{}
+    }
+    data, err := json.Marshal(cs)
+    return string(data), err
+}
+
+func (cs *AuditChanges) Scan(value any) error {
+    switch v := value.(type) {
+    case []byte:
+        return json.Unmarshal(v, cs)
+    case string:
+        return json.Unmarshal([]byte(v), cs)
+    case nil:
+        *cs = nil
+        return nil
+    }
+    return fmt.Errorf("cannot scan %T into AuditChanges", value)
+}
+
+func (AuditChanges) GormDataType() string {
+    return "jsonb"
+}
+
+// AuditEntry records one change of a subscription: who made it, in which
+// request, and the fields it changed. Entries are written in the transaction
+// of the change and never modified; they outlive the purge of the
+// subscription.
+type AuditEntry struct {
+    ID             uint         `gorm:"primaryKey"`
+    TenantID       uuid.UUID    `gorm:"type:uuid;not null"`
+    SubscriptionID uuid.UUID    `gorm:"type:uuid;not null;index"`
+    // UserID is the owner of the subscription after the change, or before
+    // it for deletions and purges.
+    UserID         uuid.UUID    `gorm:"type:uuid;not null"`
+    Action         AuditAction  `gorm:"not null" enums:"create,update,delete,restore,purge"`
+    // Actor is the token subject or "api_key:<ID>" of the caller,
+    // "anonymous" when authentication is disabled, or "system" for changes
+    // made by background jobs.
+    Actor          string       `gorm:"not null" example:"api_key:0b8c6a52-1f9e-4c43-9d0e-5f3a3c1d2e7b"`
+    RequestID      string       `gorm:"not null;default:''"`
+    Changes        AuditChanges `gorm:"not null" swaggertype:"object"`
+    CreatedAt      time.Time    `gorm:"not null;index"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// Budget caps a user's monthly subscription spend, either across all
+// subscriptions or, when Category is set, across the subscriptions of that
+// category. A user has at most one budget per category within a tenant.
+type Budget struct {
+    ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+    TenantID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_budgets_tenant_user_category"`
+    UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_budgets_tenant_user_category"`
+    Category  string    `gorm:"not null;default:'';uniqueIndex:idx_budgets_tenant_user_category" example:"streaming"`
+    // Amount is the monthly limit in Currency.
+    Amount    int       `gorm:"not null" example:"1500"`
+    Currency  string    `gorm:"type:char(3);not null;default:RUB" example:"RUB"`
+    CreatedAt time.Time `gorm:"not null"`
+    UpdatedAt time.Time `gorm:"not null"`
+}
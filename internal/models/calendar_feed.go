@@ -0,0 +1,17 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// CalendarFeed holds the nonce mixed into the token of a user's calendar feed
+// URL. Rotating it revokes the URL issued before. Users whose feed was never
+// rotated have no record and an empty nonce.
+type CalendarFeed struct {
+    TenantID  uuid.UUID `gorm:"type:uuid;primaryKey"`
+    UserID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+    Nonce     string    `gorm:"not null"`
+    RotatedAt time.Time `gorm:"not null"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// ExchangeRate is the number of QuoteCurrency units one BaseCurrency unit
+// buys from EffectiveDate until the next rate for the same pair.
+type ExchangeRate struct {
+    ID            uint      `gorm:"primaryKey"`
+    BaseCurrency  string    `gorm:"type:char(3);not null;uniqueIndex:idx_exchange_rates_pair_date"`
+    QuoteCurrency string    `gorm:"type:char(3);not null;uniqueIndex:idx_exchange_rates_pair_date"`
+    Rate          float64   `gorm:"not null"`
+    EffectiveDate time.Time `gorm:"type:date;not null;uniqueIndex:idx_exchange_rates_pair_date"`
+}
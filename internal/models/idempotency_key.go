@@ -0,0 +1,24 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// IdempotencyKey records the response to a request sent with an
+// Idempotency-Key header so retries of it can be answered without repeating
+// it. StatusCode is 0 while the first request is still being processed. Keys
+// belong to the tenant and principal that sent them, so different callers
+// may pick the same key.
+type IdempotencyKey struct {
+    TenantID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+    Principal   string    `gorm:"primaryKey;size:255"`
+    Key         string    `gorm:"primaryKey;size:255"`
+    RequestHash string    `gorm:"type:char(64);not null"`
+    StatusCode  int       `gorm:"not null;default:0"`
+    Headers     string    `gorm:"type:jsonb;not null;default:'{}'"`
+    Body        []byte
+    CreatedAt   time.Time `gorm:"not null"`
+    ExpiresAt   time.Time `gorm:"not null;index"`
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+    "database/sql/driver"
+    "encoding/json"
+    "fmt"
+    "reflect"
+    "time"
+)
+
+const (
+    // MonthLayout is the MM-YYYY layout months are rendered in.
+    MonthLayout = "01-2006"
+    // MonthLayoutISO is the YYYY-MM layout also accepted on input.
+    MonthLayoutISO = "2006-01"
+)
+
+// Month is a calendar month, represented by its first day at midnight UTC.
+// It is rendered as MM-YYYY in JSON and stored as a DATE in Postgres.
+type Month struct {
+    time.Time
+}
+
+// NewMonth returns the month t falls in, in UTC.
+func NewMonth(t time.Time) Month {
+    y, m, _ := t.UTC().Date()
+    return Month{time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseMonth parses MM-YYYY or YYYY-MM. Full RFC 3339 timestamps and
+// YYYY-MM-DD dates are accepted too and truncated to their month.
+func ParseMonth(s string) (Month, error) {
+    for _, layout := range []string{MonthLayout, MonthLayoutISO, time.DateOnly, time.RFC3339} {
+        if t, err := time.Parse(layout, s); err == nil {
+            return NewMonth(t), nil
+        }
+    }
+    return Month{}, fmt.Errorf("invalid month %q, expected MM-YYYY or YYYY-MM", s)
+}
+
+func (m Month) String() string {
+    return m.Format(MonthLayout)
+}
+
+func (m Month) MarshalJSON() ([]byte, error) {
+    return json.Marshal(m.String())
+}
+
+// UnmarshalJSON reports malformed months as *json.UnmarshalTypeError carrying
+// the raw JSON value.
+func (m *Month) UnmarshalJSON(data []byte) error {
+    var s string
+    if err := json.Unmarshal(data, &s); err != nil {
+        return &json.UnmarshalTypeError{Value: string(data), Type: reflect.TypeOf(*m)}
+    }
+    parsed, err := ParseMonth(s)
+    if err != nil {
+        return &json.UnmarshalTypeError{Value: string(data), Type: reflect.TypeOf(*m)}
+    }
+    *m = parsed
+    return nil
+}
+
+func (m Month) MarshalText() ([]byte, error) {
+    return []byte(m.String()), nil
+}
+
+func (m *Month) UnmarshalText(data []byte) error {
+    parsed, err := ParseMonth(string(data))
+    if err != nil {
+        return err
+    }
+    *m = parsed
+    return nil
+}
+
+// Value stores the month as the first day of the month.
+func (m Month) Value() (driver.Value, error) {
+    return m.Time, nil
+}
+
+func (m *Month) Scan(value any) error {
+    switch v := value.(type) {
+    case time.Time:
+        *m = NewMonth(v)
+    case string:
+        parsed, err := ParseMonth(v)
+        if err != nil {
+            return err
+        }
+        *m = parsed
+    case nil:
+        *m = Month{}
+    default:
+        return fmt.Errorf("cannot scan %T into Month", value)
+    }
+    return nil
+}
+
+func (Month) GormDataType() string {
+    return "date"
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+    "encoding/json"
+    "errors"
+    "testing"
+    "time"
+)
+
+func TestParseMonthLayouts(t *testing.T) {
+    want := NewMonth(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+    for _, s := range []string{"03-2026", "2026-03", "2026-03-17", "2026-03-31T23:30:00Z"} {
+        got, err := ParseMonth(s)
+        if err != nil {
+            t.Errorf("ParseMonth(%q): %v", s, err)
+            continue
+        }
+        if !got.Equal(want.Time) {
+            t.Errorf("ParseMonth(%q) = %s, want %s", s, got, want)
+        }
+    }
+    for _, s := range []string{"", "3-2026", "13-2026", "March 2026"} {
+        if _, err := ParseMonth(s); err == nil {
+            t.Errorf("ParseMonth(%q) succeeded", s)
+        }
+    }
+}
+
+func TestMonthJSON(t *testing.T) {
+    var v struct{ Start Month }
+    if err := json.Unmarshal([]byte(`{"Start":"2026-03"}`), &v); err != nil {
+        t.Fatalf("unmarshal: %v", err)
+    }
+    out, err := json.Marshal(v)
+    if err != nil {
+        t.Fatalf("marshal: %v", err)
+    }
+    if string(out) != `{"Start":"03-2026"}` {
+        t.Errorf("marshalled %s, want MM-YYYY", out)
+    }
+
+    var typeErr *json.UnmarshalTypeError
+    for _, raw := range []string{`{"Start":"soon"}`, `{"Start":202603}`} {
+        if err := json.Unmarshal([]byte(raw), &v); !errors.As(err, &typeErr) {
+            t.Errorf("unmarshal %s: err = %v, want *json.UnmarshalTypeError", raw, err)
+        }
+    }
+}
+
+func TestMonthScan(t *testing.T) {
+    var m Month
+    if err := m.Scan(time.Date(2026, 3, 17, 12, 0, 0, 0, time.UTC)); err != nil || m.String() != "03-2026" {
+        t.Errorf("Scan(time) = %s, %v", m, err)
+    }
+    if err := m.Scan("2026-04-01"); err != nil || m.String() != "04-2026" {
+        t.Errorf("Scan(string) = %s, %v", m, err)
+    }
+    if err := m.Scan(nil); err != nil || !m.IsZero() {
+        t.Errorf("Scan(nil) = %s, %v", m, err)
+    }
+    if err := m.Scan(42); err == nil {
+        t.Errorf("Scan(int) succeeded")
+    }
+}
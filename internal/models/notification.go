@@ -0,0 +1,20 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// Notification records a reminder delivered, or being delivered, through one
+// notification channel. A reminder is identified by its subscription, kind
+// and due date, so it is sent at most once per channel.
+type Notification struct {
+    ID             uint       `gorm:"primaryKey"`
+    SubscriptionID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_notifications_reminder"`
+    Kind           string     `gorm:"not null;uniqueIndex:idx_notifications_reminder"`
+    DueDate        time.Time  `gorm:"type:date;not null;uniqueIndex:idx_notifications_reminder"`
+    Channel        string     `gorm:"not null;uniqueIndex:idx_notifications_reminder"`
+    ClaimedAt      time.Time  `gorm:"not null"`
+    SentAt         *time.Time
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// PriceChange sets the price of a subscription from a month on, until its
+// next price change. Months before the first change are billed at the
+// subscription's own Price, so raising a price keeps past costs intact.
+type PriceChange struct {
+    ID             uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+    TenantID       uuid.UUID `gorm:"type:uuid;not null"`
+    SubscriptionID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_price_changes_subscription_month"`
+    Price          int       `gorm:"not null"`
+    EffectiveFrom  Month     `gorm:"not null;uniqueIndex:idx_price_changes_subscription_month" swaggertype:"string" example:"03-2026"`
+    CreatedAt      time.Time `gorm:"not null"`
+}
@@ -1,15 +1,55 @@
 package models
 
 import (
-    "time"
     "github.com/google/uuid"
+    "gorm.io/gorm"
 )
 
+// DefaultCurrency is used for subscriptions created without a currency and as
+// the default target currency of cost calculations.
+const DefaultCurrency = "RUB"
+
+// BillingInterval is the unit of a subscription's billing period.
+type BillingInterval string
+
+const (
+    IntervalWeek    BillingInterval = "week"
+    IntervalMonth   BillingInterval = "month"
+    IntervalQuarter BillingInterval = "quarter"
+    IntervalYear    BillingInterval = "year"
+    IntervalOnce    BillingInterval = "once"
+)
+
+// Valid reports whether the interval is one of the supported units.
+func (i BillingInterval) Valid() bool {
+    switch i {
+    case IntervalWeek, IntervalMonth, IntervalQuarter, IntervalYear, IntervalOnce:
+        return true
+    }
+    return false
+}
+
 type Subscription struct {
-    ID          uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-    ServiceName string     `gorm:"not null"`
-    Price       int        `gorm:"not null"`
-    UserID      uuid.UUID  `gorm:"type:uuid;not null;index"`
-    StartDate   time.Time  `gorm:"not null"`
-    EndDate     *time.Time
+    ID              uuid.UUID       `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+    // TenantID is the organization owning the subscription. It is assigned
+    // by the server from the tenant of the request and ignored on input.
+    TenantID        uuid.UUID       `gorm:"type:uuid;not null;index"`
+    ServiceName     string          `gorm:"not null"`
+    // Category groups subscriptions for budgets. It is optional and stored
+    // in lower case.
+    Category        string          `gorm:"not null;default:''" example:"streaming"`
+    // Price is charged from StartDate until the first price change of the
+    // subscription, if any.
+    Price           int             `gorm:"not null"`
+    Currency        string          `gorm:"type:char(3);not null;default:RUB" example:"RUB"`
+    BillingInterval BillingInterval `gorm:"not null;default:month" enums:"week,month,quarter,year,once"`
+    IntervalCount   int             `gorm:"not null;default:1"`
+    UserID          uuid.UUID       `gorm:"type:uuid;not null;index"`
+    StartDate       Month           `gorm:"not null" swaggertype:"string" example:"07-2025"`
+    EndDate         *Month          `swaggertype:"string" example:"12-2025"`
+    // Version is incremented on every update and backs the ETag of the
+    // subscription. It is assigned by the server and ignored on input.
+    Version         int             `gorm:"not null;default:1" example:"1"`
+    // DeletedAt is set while the subscription is in the trash.
+    DeletedAt       gorm.DeletedAt  `gorm:"index" swaggertype:"string" format:"date-time"`
 }
@@ -0,0 +1,21 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// DefaultTenantID is the tenant that owns the rows created before tenants
+// existed and the requests that do not name a tenant.
+var DefaultTenantID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+// Tenant is an organization whose subscriptions, budgets, webhooks and API
+// keys are isolated from those of every other tenant.
+type Tenant struct {
+    ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+    // Slug names the tenant in the X-Tenant-ID header.
+    Slug      string    `gorm:"not null;uniqueIndex" example:"payments-team"`
+    Name      string    `gorm:"not null" example:"Payments team"`
+    CreatedAt time.Time `gorm:"not null"`
+}
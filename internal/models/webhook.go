@@ -0,0 +1,136 @@
+package models
+
+import (
+    "database/sql/driver"
+    "encoding/json"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// EventType names a subscription lifecycle event sent to webhooks.
+type EventType string
+
+const (
+    EventSubscriptionCreated  EventType = "subscription.created"
+    EventSubscriptionUpdated  EventType = "subscription.updated"
+    EventSubscriptionDeleted  EventType = "subscription.deleted"
+    EventSubscriptionRestored EventType = "subscription.restored"
+    // EventSubscriptionExpired is emitted once the end month of a
+    // subscription is over.
+    EventSubscriptionExpired EventType = "subscription.expired"
+)
+
+// EventTypesAll lists every event type.
+var EventTypesAll = EventTypes{
+    EventSubscriptionCreated,
+    EventSubscriptionUpdated,
+    EventSubscriptionDeleted,
+    EventSubscriptionRestored,
+    EventSubscriptionExpired,
+}
+
+// Valid reports whether the event type is one of the known ones.
+func (t EventType) Valid() bool {
+    for _, known := range EventTypesAll {
+        if t == known {
+            return true
+        }
+    }
+    return false
+}
+
+// EventTypes is a set of event types stored as a JSON array.
+type EventTypes []EventType
+
+// Contains reports whether t is one of the event types.
+func (ts EventTypes) Contains(t EventType) bool {
+    for _, e := range ts {
+        if e == t {
+            return true
+        }
+    }
+    return false
+}
+
+func (ts EventTypes) Value() (driver.Value, error) {
+    if ts == nil {
+        ts = EventTypes{}
+    }
+    data, err := json.Marshal(ts)
+    return string(data), err
+}
+
+func (ts *EventTypes) Scan(value any) error {
+    switch v := value.(type) {
+    case []byte:
+        return json.Unmarshal(v, ts)
+    case string:
+        return json.Unmarshal([]byte(v), ts)
+    case nil:
+        *ts = nil
+        return nil
+    }
+    return fmt.Errorf("cannot scan %T into EventTypes", value)
+}
+
+func (EventTypes) GormDataType() string {
+    return "jsonb"
+}
+
+// WebhookEndpoint is a URL that receives the events it subscribed to about
+// the subscriptions of its tenant. The secret signs the deliveries and is
+// never returned.
+type WebhookEndpoint struct {
+    ID        uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+    TenantID  uuid.UUID  `gorm:"type:uuid;not null;index"`
+    URL       string     `gorm:"not null" example:"https://budget.example.com/hooks/subscriptions"`
+    Secret    string     `gorm:"not null" json:"-"`
+    Events    EventTypes `gorm:"not null" swaggertype:"array,string" example:"subscription.created,subscription.deleted"`
+    CreatedAt time.Time  `gorm:"not null"`
+}
+
+// OutboxEvent is an event written in the transaction of the change it
+// describes and handed to the webhook dispatcher afterwards. Key, when set,
+// keeps the same event from being emitted twice.
+type OutboxEvent struct {
+    ID           uint       `gorm:"primaryKey"`
+    EventID      uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex"`
+    TenantID     uuid.UUID  `gorm:"type:uuid;not null"`
+    Type         EventType  `gorm:"not null"`
+    Key          *string    `gorm:"uniqueIndex"`
+    Payload      string     `gorm:"type:jsonb;not null"`
+    CreatedAt    time.Time  `gorm:"not null"`
+    DispatchedAt *time.Time `gorm:"index"`
+}
+
+// DeliveryStatus is the state of a webhook delivery.
+type DeliveryStatus string
+
+const (
+    DeliveryPending   DeliveryStatus = "pending"
+    DeliverySucceeded DeliveryStatus = "succeeded"
+    // DeliveryFailed means every attempt failed and no more will be made.
+    DeliveryFailed DeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event sent to one endpoint, with the outcome of its
+// latest attempt.
+type WebhookDelivery struct {
+    ID             uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+    EndpointID     uuid.UUID      `gorm:"type:uuid;not null;index"`
+    EventID        uuid.UUID      `gorm:"type:uuid;not null"`
+    EventType      EventType      `gorm:"not null"`
+    Payload        string         `gorm:"type:jsonb;not null" json:"-"`
+    Status         DeliveryStatus `gorm:"not null;default:pending" enums:"pending,succeeded,failed"`
+    Attempts       int            `gorm:"not null;default:0"`
+    NextAttemptAt  time.Time      `gorm:"not null;index"`
+    LastAttemptAt  *time.Time
+    // ResponseStatus is the HTTP status of the latest attempt, 0 when it got
+    // no response.
+    ResponseStatus int            `gorm:"not null;default:0"`
+    LastError      string         `gorm:"not null;default:''"`
+    CreatedAt      time.Time      `gorm:"not null"`
+    DeliveredAt    *time.Time
+}
@@ -0,0 +1,157 @@
+package reminders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"service/internal/logger"
+	"service/internal/models"
+)
+
+// Notifier delivers reminders through one channel.
+type Notifier interface {
+	// Name identifies the channel. A reminder is delivered once per name.
+	Name() string
+	Notify(ctx context.Context, r Reminder) error
+}
+
+// Subject is a one-line description of r, e.g. "Netflix renews on 2025-08-01".
+func (r Reminder) Subject() string {
+	verb := "renews"
+	if r.Kind == KindEnd {
+		verb = "ends"
+	}
+	return fmt.Sprintf("%s %s on %s", r.Subscription.ServiceName, verb, r.Date.Format(time.DateOnly))
+}
+
+// LogNotifier writes reminders to the service log.
+type LogNotifier struct{}
+
+func (LogNotifier) Name() string { return "log" }
+
+func (LogNotifier) Notify(_ context.Context, r Reminder) error {
+	logger.Log.Info("Subscription reminder",
+		zap.String("kind", string(r.Kind)),
+		zap.String("date", r.Date.Format(time.DateOnly)),
+		zap.String("subscription_id", r.Subscription.ID.String()),
+		zap.String("user_id", r.Subscription.UserID.String()),
+		zap.String("service_name", r.Subscription.ServiceName),
+		zap.Int("price", r.Price),
+		zap.String("currency", r.Subscription.Currency),
+	)
+	return nil
+}
+
+// WebhookPayload is the JSON body a WebhookNotifier posts.
+type WebhookPayload struct {
+	Kind         Kind                `json:"kind"`
+	Date         string              `json:"date"`
+	Subscription models.Subscription `json:"subscription"`
+	// Price is the price effective on Date; Subscription.Price is the price
+	// the subscription started at.
+	Price int `json:"price"`
+}
+
+// WebhookNotifier posts reminders as JSON to a URL. Any response other than
+// 2xx is a failed delivery.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (n *WebhookNotifier) Name() string { return "webhook" }
+
+func (n *WebhookNotifier) Notify(ctx context.Context, r Reminder) error {
+	body, err := json.Marshal(WebhookPayload{
+		Kind:         r.Kind,
+		Date:         r.Date.Format(time.DateOnly),
+		Subscription: r.Subscription,
+		Price:        r.Price,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+// SMTPNotifier mails reminders to a fixed list of recipients, since
+// subscriptions only carry a user ID and no address.
+type SMTPNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+// NewSMTPNotifier returns a notifier sending through the server at addr
+// (host:port), authenticating with PLAIN auth when username is set.
+func NewSMTPNotifier(addr, username, password, from string, to []string) (*SMTPNotifier, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("smtp address %q: %w", addr, err)
+	}
+	if from == "" || len(to) == 0 {
+		return nil, fmt.Errorf("smtp notifier needs a sender and at least one recipient")
+	}
+	n := &SMTPNotifier{addr: addr, from: from, to: to}
+	if username != "" {
+		n.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return n, nil
+}
+
+func (n *SMTPNotifier) Name() string { return "smtp" }
+
+func (n *SMTPNotifier) Notify(_ context.Context, r Reminder) error {
+	sub := r.Subscription
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s.\r\n\r\n", r.Subject())
+	fmt.Fprintf(&body, "Subscription: %s\r\n", sub.ID)
+	fmt.Fprintf(&body, "User: %s\r\n", sub.UserID)
+	fmt.Fprintf(&body, "Price: %d %s per %d %s\r\n", r.Price, sub.Currency, sub.IntervalCount, sub.BillingInterval)
+	if sub.EndDate != nil {
+		fmt.Fprintf(&body, "Active: %s to %s\r\n", sub.StartDate, sub.EndDate)
+	} else {
+		fmt.Fprintf(&body, "Active since: %s\r\n", sub.StartDate)
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "Reminder: "+r.Subject()))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(body.String())
+
+	return smtp.SendMail(n.addr, n.auth, n.from, n.to, []byte(msg.String()))
+}
@@ -0,0 +1,173 @@
+// Package reminders notifies about subscriptions that renew or end soon.
+package reminders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"service/internal/billing"
+	"service/internal/logger"
+	"service/internal/models"
+	"service/internal/repository"
+)
+
+// Kind is what a reminder is about.
+type Kind string
+
+const (
+	// KindRenewal announces a renewal, i.e. a billing day after the first.
+	KindRenewal Kind = "renewal"
+	// KindEnd announces the last day of a subscription with an end date.
+	KindEnd Kind = "end"
+)
+
+// claimTimeout is how long a reminder claimed by a sender stays reserved
+// before it is assumed the sender died without delivering it.
+const claimTimeout = 10 * time.Minute
+
+// Reminder is one upcoming renewal or end of a subscription.
+type Reminder struct {
+	Kind         Kind
+	Date         time.Time
+	Subscription models.Subscription
+	// Price is the price effective in the month of Date, which differs from
+	// the subscription's Price once a price change took effect.
+	Price int
+}
+
+// Due returns the reminders of sub falling on the days from..to, both
+// included. Renewals fall on the billing days after the start month's first,
+// up to the last day of the end month; one-off subscriptions have none.
+func Due(sub models.Subscription, prices *billing.Prices, from, to time.Time) []Reminder {
+	if sub.BillingInterval == models.IntervalOnce {
+		return nil
+	}
+
+	var until time.Time
+	if sub.EndDate != nil {
+		until = lastDay(sub.EndDate.Time)
+	}
+	var due []Reminder
+	for k := 1; ; k++ {
+		date := billing.BillingDay(sub, k)
+		if date.After(to) || (!until.IsZero() && date.After(until)) {
+			break
+		}
+		if !date.Before(from) {
+			due = append(due, Reminder{Kind: KindRenewal, Date: date, Subscription: sub, Price: prices.At(sub, date)})
+		}
+	}
+	if !until.IsZero() && !until.Before(from) && !until.After(to) {
+		due = append(due, Reminder{Kind: KindEnd, Date: until, Subscription: sub, Price: prices.At(sub, until)})
+	}
+	return due
+}
+
+func lastDay(month time.Time) time.Time {
+	return billing.MonthStart(month).AddDate(0, 1, -1)
+}
+
+// Scheduler sends the reminders due within a number of days through every
+// notifier. Each reminder is claimed per notifier before it is sent and
+// marked sent afterwards, so it is delivered once per notifier even when
+// several instances run or the service restarts. Only a sender dying between
+// delivering a reminder and marking it sent makes it go out twice.
+type Scheduler struct {
+	subs          repository.SubscriptionRepository
+	prices        repository.PriceRepository
+	notifications repository.NotificationRepository
+	notifiers     []Notifier
+	days          int
+}
+
+func NewScheduler(subs repository.SubscriptionRepository, prices repository.PriceRepository, notifications repository.NotificationRepository, notifiers []Notifier, days int) *Scheduler {
+	return &Scheduler{subs: subs, prices: prices, notifications: notifications, notifiers: notifiers, days: days}
+}
+
+// Run sends the reminders falling on the days from now's until days later
+// that were not sent yet and returns how many were sent. A failed delivery is
+// released so the next run retries it.
+func (s *Scheduler) Run(ctx context.Context, now time.Time) (int, error) {
+	now = now.UTC()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, s.days)
+
+	subs, err := s.subs.List(ctx, repository.SubscriptionFilter{
+		StartsBefore:  to,
+		EndsOnOrAfter: billing.MonthStart(from),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("list subscriptions: %w", err)
+	}
+	prices, err := s.loadPrices(ctx, subs)
+	if err != nil {
+		return 0, fmt.Errorf("list price changes: %w", err)
+	}
+
+	sent := 0
+	var errs []error
+	for _, sub := range subs {
+		for _, r := range Due(sub, prices, from, to) {
+			for _, n := range s.notifiers {
+				ok, err := s.send(ctx, n, r, now)
+				if err != nil {
+					errs = append(errs, err)
+				} else if ok {
+					sent++
+				}
+			}
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
+// loadPrices loads the price timeline of subs.
+func (s *Scheduler) loadPrices(ctx context.Context, subs []models.Subscription) (*billing.Prices, error) {
+	if len(subs) == 0 {
+		return nil, nil
+	}
+	ids := make([]uuid.UUID, len(subs))
+	for i, sub := range subs {
+		ids[i] = sub.ID
+	}
+	changes, err := s.prices.List(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return billing.NewPrices(changes), nil
+}
+
+// send delivers r through n unless it was already claimed, and reports
+// whether it did.
+func (s *Scheduler) send(ctx context.Context, n Notifier, r Reminder, now time.Time) (bool, error) {
+	rec := &models.Notification{
+		SubscriptionID: r.Subscription.ID,
+		Kind:           string(r.Kind),
+		DueDate:        r.Date,
+		Channel:        n.Name(),
+		ClaimedAt:      now,
+	}
+	err := s.notifications.Claim(ctx, rec, now.Add(-claimTimeout))
+	if errors.Is(err, repository.ErrConflict) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("claim %s reminder of %s: %w", r.Kind, r.Subscription.ID, err)
+	}
+
+	if err := n.Notify(ctx, r); err != nil {
+		if releaseErr := s.notifications.Release(context.WithoutCancel(ctx), rec.ID); releaseErr != nil {
+			logger.Log.Error("Releasing reminder failed", zap.Uint("notification_id", rec.ID), zap.Error(releaseErr))
+		}
+		return false, fmt.Errorf("send %s reminder of %s via %s: %w", r.Kind, r.Subscription.ID, n.Name(), err)
+	}
+	if err := s.notifications.MarkSent(context.WithoutCancel(ctx), rec.ID, time.Now()); err != nil {
+		return true, fmt.Errorf("mark %s reminder of %s sent: %w", r.Kind, r.Subscription.ID, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,192 @@
+package reminders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"service/internal/billing"
+	"service/internal/logger"
+	"service/internal/models"
+	"service/internal/repository"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+func month(y int, m time.Month) models.Month {
+	return models.NewMonth(time.Date(y, m, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestDue(t *testing.T) {
+	end := month(2025, time.September)
+	tests := []struct {
+		name string
+		sub  models.Subscription
+		from time.Time
+		to   time.Time
+		want []string
+	}{
+		{
+			name: "monthly without the first billing day",
+			sub:  models.Subscription{BillingInterval: models.IntervalMonth, IntervalCount: 1, StartDate: month(2025, time.July)},
+			from: day(2025, time.June, 1),
+			to:   day(2025, time.September, 1),
+			want: []string{"renewal 2025-08-01", "renewal 2025-09-01"},
+		},
+		{
+			name: "renewals and end within the end month",
+			sub:  models.Subscription{BillingInterval: models.IntervalMonth, IntervalCount: 1, StartDate: month(2025, time.July), EndDate: &end},
+			from: day(2025, time.September, 1),
+			to:   day(2025, time.December, 1),
+			want: []string{"renewal 2025-09-01", "end 2025-09-30"},
+		},
+		{
+			name: "fortnightly",
+			sub:  models.Subscription{BillingInterval: models.IntervalWeek, IntervalCount: 2, StartDate: month(2025, time.July)},
+			from: day(2025, time.July, 10),
+			to:   day(2025, time.August, 1),
+			want: []string{"renewal 2025-07-15", "renewal 2025-07-29"},
+		},
+		{
+			name: "one-off",
+			sub:  models.Subscription{BillingInterval: models.IntervalOnce, StartDate: month(2025, time.July), EndDate: &end},
+			from: day(2025, time.July, 1),
+			to:   day(2025, time.December, 31),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, r := range Due(tt.sub, nil, tt.from, tt.to) {
+				got = append(got, string(r.Kind)+" "+r.Date.Format(time.DateOnly))
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Due = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Due = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDueAtEffectivePrices(t *testing.T) {
+	end := month(2025, time.October)
+	sub := models.Subscription{ID: uuid.New(), Price: 100, BillingInterval: models.IntervalMonth, IntervalCount: 1, StartDate: month(2025, time.July), EndDate: &end}
+	prices := billing.NewPrices([]models.PriceChange{
+		{SubscriptionID: sub.ID, Price: 150, EffectiveFrom: month(2025, time.September)},
+	})
+	var got []int
+	for _, r := range Due(sub, prices, day(2025, time.August, 1), day(2025, time.November, 1)) {
+		got = append(got, r.Price)
+	}
+	want := []int{100, 150, 150, 150}
+	if len(got) != len(want) {
+		t.Fatalf("prices %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("prices %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+// recorder is a Notifier remembering what it delivered.
+type recorder struct {
+	name string
+	fail bool
+	sent []Reminder
+}
+
+func (r *recorder) Name() string { return r.name }
+
+func (r *recorder) Notify(_ context.Context, rem Reminder) error {
+	if r.fail {
+		return errors.New("channel down")
+	}
+	r.sent = append(r.sent, rem)
+	return nil
+}
+
+func TestSchedulerSendsOncePerNotifier(t *testing.T) {
+	ctx := context.Background()
+	subs := repository.NewMemorySubscriptionRepository()
+	netflix := &models.Subscription{ServiceName: "Netflix", Price: 400, UserID: uuid.New(), BillingInterval: models.IntervalMonth, IntervalCount: 1, StartDate: month(2025, time.July)}
+	for _, sub := range []*models.Subscription{
+		netflix,
+		{ServiceName: "Yearly", UserID: uuid.New(), BillingInterval: models.IntervalYear, IntervalCount: 1, StartDate: month(2025, time.July)},
+	} {
+		if err := subs.Create(ctx, sub); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	prices := repository.NewMemoryPriceRepository()
+	if err := prices.Create(ctx, &models.PriceChange{ID: uuid.New(), SubscriptionID: netflix.ID, Price: 450, EffectiveFrom: month(2025, time.August)}); err != nil {
+		t.Fatalf("create price change: %v", err)
+	}
+	log, hook := &recorder{name: "log"}, &recorder{name: "webhook", fail: true}
+	scheduler := NewScheduler(subs, prices, repository.NewMemoryNotificationRepository(), []Notifier{log, hook}, 7)
+	now := time.Date(2025, time.July, 28, 9, 0, 0, 0, time.UTC)
+
+	sent, err := scheduler.Run(ctx, now)
+	if sent != 1 || err == nil || len(log.sent) != 1 || log.sent[0].Subject() != "Netflix renews on 2025-08-01" {
+		t.Fatalf("first run sent %d (%v), log got %v", sent, err, log.sent)
+	}
+	if log.sent[0].Price != 450 {
+		t.Errorf("reminder at price %d, want the 450 effective in August", log.sent[0].Price)
+	}
+
+	hook.fail = false
+	sent, err = scheduler.Run(ctx, now.Add(time.Hour))
+	if sent != 1 || err != nil || len(log.sent) != 1 || len(hook.sent) != 1 {
+		t.Errorf("second run sent %d (%v): log %d, webhook %d", sent, err, len(log.sent), len(hook.sent))
+	}
+	if sent, err := scheduler.Run(ctx, now.Add(2*time.Hour)); sent != 0 || err != nil {
+		t.Errorf("third run sent %d (%v), want nothing new", sent, err)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got WebhookPayload
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("content type %q", r.Header.Get("Content-Type"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	n := NewWebhookNotifier(srv.URL)
+	r := Reminder{Kind: KindEnd, Date: day(2025, time.September, 30), Subscription: models.Subscription{ID: uuid.New(), ServiceName: "Netflix"}}
+	if err := n.Notify(context.Background(), r); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if got.Kind != KindEnd || got.Date != "2025-09-30" || got.Subscription.ID != r.Subscription.ID {
+		t.Errorf("payload %+v", got)
+	}
+
+	status = http.StatusBadGateway
+	if err := n.Notify(context.Background(), r); err == nil {
+		t.Error("a 502 response counted as delivered")
+	}
+}
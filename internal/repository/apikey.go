@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"service/internal/models"
+	"service/internal/tenant"
+)
+
+// PostgresAPIKeyRepository is an APIKeyRepository backed by GORM. Keys are
+// looked up and touched through system, which bypasses row-level security,
+// since no tenant is known before the key is found.
+type PostgresAPIKeyRepository struct {
+	db     *gorm.DB
+	system *gorm.DB
+}
+
+func NewPostgresAPIKeyRepository(db, system *gorm.DB) *PostgresAPIKeyRepository {
+	return &PostgresAPIKeyRepository{db: db, system: system}
+}
+
+func (r *PostgresAPIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	return translate(conn(ctx, r.db).Create(key).Error)
+}
+
+func (r *PostgresAPIKeyRepository) Get(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := conn(ctx, r.db).First(&key, "id = ?", id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &key, nil
+}
+
+func (r *PostgresAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.system.WithContext(ctx).First(&key, "prefix = ?", prefix).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &key, nil
+}
+
+func (r *PostgresAPIKeyRepository) List(ctx context.Context) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := conn(ctx, r.db).Order("created_at, id").Find(&keys).Error
+	return keys, err
+}
+
+func (r *PostgresAPIKeyRepository) Update(ctx context.Context, key *models.APIKey) error {
+	res := conn(ctx, r.db).Model(key).
+		Select("name", "prefix", "hash", "scopes", "user_id", "expires_at", "rotated_at", "revoked_at").
+		Updates(key)
+	if res.Error != nil {
+		return translate(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresAPIKeyRepository) Touch(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	return r.system.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, usedAt).
+		Update("last_used_at", usedAt).Error
+}
+
+// MemoryAPIKeyRepository is an in-memory APIKeyRepository.
+type MemoryAPIKeyRepository struct {
+	mu   sync.Mutex
+	keys map[uuid.UUID]models.APIKey
+}
+
+func NewMemoryAPIKeyRepository() *MemoryAPIKeyRepository {
+	return &MemoryAPIKeyRepository{keys: map[uuid.UUID]models.APIKey{}}
+}
+
+func (r *MemoryAPIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	assignTenantID(ctx, &key.TenantID)
+	if key.ID == uuid.Nil {
+		key.ID = uuid.New()
+	}
+	if _, ok := r.keys[key.ID]; ok || r.taken(key) {
+		return ErrConflict
+	}
+	r.keys[key.ID] = *key
+	return nil
+}
+
+func (r *MemoryAPIKeyRepository) Get(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[id]
+	if !ok || !tenant.Visible(ctx, key.TenantID) {
+		return nil, ErrNotFound
+	}
+	return &key, nil
+}
+
+func (r *MemoryAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range r.keys {
+		if key.Prefix == prefix && tenant.Visible(ctx, key.TenantID) {
+			return &key, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryAPIKeyRepository) List(ctx context.Context) ([]models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var keys []models.APIKey
+	for _, key := range r.keys {
+		if tenant.Visible(ctx, key.TenantID) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].ID.String() < keys[j].ID.String()
+	})
+	return keys, nil
+}
+
+func (r *MemoryAPIKeyRepository) Update(ctx context.Context, key *models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.keys[key.ID]
+	if !ok || !tenant.Visible(ctx, stored.TenantID) {
+		return ErrNotFound
+	}
+	if r.taken(key) {
+		return ErrConflict
+	}
+	key.TenantID = stored.TenantID
+	key.CreatedAt = stored.CreatedAt
+	key.LastUsedAt = stored.LastUsedAt
+	r.keys[key.ID] = *key
+	return nil
+}
+
+func (r *MemoryAPIKeyRepository) Touch(_ context.Context, id uuid.UUID, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[id]
+	if !ok || (key.LastUsedAt != nil && !key.LastUsedAt.Before(usedAt)) {
+		return nil
+	}
+	key.LastUsedAt = &usedAt
+	r.keys[id] = key
+	return nil
+}
+
+// taken reports whether another key has the prefix of key.
+func (r *MemoryAPIKeyRepository) taken(key *models.APIKey) bool {
+	for id, other := range r.keys {
+		if id != key.ID && other.Prefix == key.Prefix {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"service/internal/models"
+	"service/internal/tenant"
+)
+
+// PostgresAuditRepository is an AuditRepository backed by GORM.
+type PostgresAuditRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresAuditRepository(db *gorm.DB) *PostgresAuditRepository {
+	return &PostgresAuditRepository{db: db}
+}
+
+func (r *PostgresAuditRepository) Append(ctx context.Context, entry *models.AuditEntry) error {
+	return translate(conn(ctx, r.db).Create(entry).Error)
+}
+
+func (r *PostgresAuditRepository) List(ctx context.Context, filter AuditFilter) ([]models.AuditEntry, error) {
+	query := conn(ctx, r.db).Model(&models.AuditEntry{})
+	if filter.SubscriptionID != uuid.Nil {
+		query = query.Where("subscription_id = ?", filter.SubscriptionID)
+	}
+	if filter.UserID != uuid.Nil {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	if filter.BeforeID > 0 {
+		query = query.Where("id < ?", filter.BeforeID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	var entries []models.AuditEntry
+	err := query.Order("id DESC").Find(&entries).Error
+	return entries, err
+}
+
+// MemoryAuditRepository is an in-memory AuditRepository.
+type MemoryAuditRepository struct {
+	mu      sync.Mutex
+	nextID  uint
+	entries []models.AuditEntry
+}
+
+func NewMemoryAuditRepository() *MemoryAuditRepository {
+	return &MemoryAuditRepository{}
+}
+
+func (r *MemoryAuditRepository) Append(ctx context.Context, entry *models.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	assignTenantID(ctx, &entry.TenantID)
+	r.nextID++
+	entry.ID = r.nextID
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *MemoryAuditRepository) List(ctx context.Context, filter AuditFilter) ([]models.AuditEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var entries []models.AuditEntry
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		e := r.entries[i]
+		if !tenant.Visible(ctx, e.TenantID) || !matchesAudit(e, filter) {
+			continue
+		}
+		if filter.Limit > 0 && len(entries) == filter.Limit {
+			break
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func matchesAudit(e models.AuditEntry, filter AuditFilter) bool {
+	switch {
+	case filter.SubscriptionID != uuid.Nil && e.SubscriptionID != filter.SubscriptionID,
+		filter.UserID != uuid.Nil && e.UserID != filter.UserID,
+		filter.Actor != "" && e.Actor != filter.Actor,
+		filter.Action != "" && e.Action != filter.Action,
+		!filter.From.IsZero() && e.CreatedAt.Before(filter.From),
+		!filter.To.IsZero() && !e.CreatedAt.Before(filter.To),
+		filter.BeforeID > 0 && e.ID >= filter.BeforeID:
+		return false
+	}
+	return true
+}
+
+func (r *MemoryAuditRepository) snapshot() []models.AuditEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.AuditEntry(nil), r.entries...)
+}
+
+func (r *MemoryAuditRepository) restore(entries []models.AuditEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = entries
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"service/internal/models"
+	"service/internal/tenant"
+)
+
+// PostgresBudgetRepository is a BudgetRepository backed by GORM.
+type PostgresBudgetRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresBudgetRepository(db *gorm.DB) *PostgresBudgetRepository {
+	return &PostgresBudgetRepository{db: db}
+}
+
+func (r *PostgresBudgetRepository) Create(ctx context.Context, budget *models.Budget) error {
+	return translate(conn(ctx, r.db).Create(budget).Error)
+}
+
+func (r *PostgresBudgetRepository) Get(ctx context.Context, id uuid.UUID) (*models.Budget, error) {
+	var budget models.Budget
+	if err := conn(ctx, r.db).First(&budget, "id = ?", id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &budget, nil
+}
+
+func (r *PostgresBudgetRepository) Update(ctx context.Context, budget *models.Budget) error {
+	res := conn(ctx, r.db).Model(budget).Select("user_id", "category", "amount", "currency", "updated_at").Updates(budget)
+	if res.Error != nil {
+		return translate(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresBudgetRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	res := conn(ctx, r.db).Delete(&models.Budget{}, "id = ?", id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresBudgetRepository) List(ctx context.Context, userID uuid.UUID) ([]models.Budget, error) {
+	query := conn(ctx, r.db)
+	if userID != uuid.Nil {
+		query = query.Where("user_id = ?", userID)
+	}
+	var budgets []models.Budget
+	err := query.Order("user_id, category").Find(&budgets).Error
+	return budgets, err
+}
+
+// MemoryBudgetRepository is an in-memory BudgetRepository.
+type MemoryBudgetRepository struct {
+	mu      sync.Mutex
+	budgets map[uuid.UUID]models.Budget
+}
+
+func NewMemoryBudgetRepository() *MemoryBudgetRepository {
+	return &MemoryBudgetRepository{budgets: map[uuid.UUID]models.Budget{}}
+}
+
+func (r *MemoryBudgetRepository) Create(ctx context.Context, budget *models.Budget) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	assignTenantID(ctx, &budget.TenantID)
+	if budget.ID == uuid.Nil {
+		budget.ID = uuid.New()
+	}
+	if _, ok := r.budgets[budget.ID]; ok || r.taken(budget) {
+		return ErrConflict
+	}
+	r.budgets[budget.ID] = *budget
+	return nil
+}
+
+func (r *MemoryBudgetRepository) Get(ctx context.Context, id uuid.UUID) (*models.Budget, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	budget, ok := r.budgets[id]
+	if !ok || !tenant.Visible(ctx, budget.TenantID) {
+		return nil, ErrNotFound
+	}
+	return &budget, nil
+}
+
+func (r *MemoryBudgetRepository) Update(ctx context.Context, budget *models.Budget) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.budgets[budget.ID]
+	if !ok || !tenant.Visible(ctx, stored.TenantID) {
+		return ErrNotFound
+	}
+	budget.TenantID = stored.TenantID
+	if r.taken(budget) {
+		return ErrConflict
+	}
+	budget.CreatedAt = stored.CreatedAt
+	r.budgets[budget.ID] = *budget
+	return nil
+}
+
+func (r *MemoryBudgetRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.budgets[id]; !ok || !tenant.Visible(ctx, stored.TenantID) {
+		return ErrNotFound
+	}
+	delete(r.budgets, id)
+	return nil
+}
+
+func (r *MemoryBudgetRepository) List(ctx context.Context, userID uuid.UUID) ([]models.Budget, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var budgets []models.Budget
+	for _, budget := range r.budgets {
+		if tenant.Visible(ctx, budget.TenantID) && (userID == uuid.Nil || budget.UserID == userID) {
+			budgets = append(budgets, budget)
+		}
+	}
+	sort.Slice(budgets, func(i, j int) bool {
+		if budgets[i].UserID != budgets[j].UserID {
+			return budgets[i].UserID.String() < budgets[j].UserID.String()
+		}
+		return budgets[i].Category < budgets[j].Category
+	})
+	return budgets, nil
+}
+
+// taken reports whether another budget has the tenant, user and category of
+// budget.
+func (r *MemoryBudgetRepository) taken(budget *models.Budget) bool {
+	for id, other := range r.budgets {
+		if id != budget.ID && other.TenantID == budget.TenantID && other.UserID == budget.UserID && other.Category == budget.Category {
+			return true
+		}
+	}
+	return false
+}
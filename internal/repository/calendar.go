@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"service/internal/models"
+	"service/internal/tenant"
+)
+
+// PostgresCalendarFeedRepository is a CalendarFeedRepository backed by GORM.
+type PostgresCalendarFeedRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresCalendarFeedRepository(db *gorm.DB) *PostgresCalendarFeedRepository {
+	return &PostgresCalendarFeedRepository{db: db}
+}
+
+func (r *PostgresCalendarFeedRepository) Get(ctx context.Context, userID uuid.UUID) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	if err := conn(ctx, r.db).First(&feed, "user_id = ?", userID).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &feed, nil
+}
+
+func (r *PostgresCalendarFeedRepository) Save(ctx context.Context, feed *models.CalendarFeed) error {
+	return conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"nonce", "rotated_at"}),
+	}).Create(feed).Error
+}
+
+// MemoryCalendarFeedRepository is an in-memory CalendarFeedRepository.
+type MemoryCalendarFeedRepository struct {
+	mu    sync.Mutex
+	feeds []models.CalendarFeed
+}
+
+func NewMemoryCalendarFeedRepository() *MemoryCalendarFeedRepository {
+	return &MemoryCalendarFeedRepository{}
+}
+
+func (r *MemoryCalendarFeedRepository) Get(ctx context.Context, userID uuid.UUID) (*models.CalendarFeed, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, feed := range r.feeds {
+		if feed.UserID == userID && tenant.Visible(ctx, feed.TenantID) {
+			return &feed, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryCalendarFeedRepository) Save(ctx context.Context, feed *models.CalendarFeed) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	assignTenantID(ctx, &feed.TenantID)
+	for i := range r.feeds {
+		if r.feeds[i].TenantID == feed.TenantID && r.feeds[i].UserID == feed.UserID {
+			r.feeds[i] = *feed
+			return nil
+		}
+	}
+	r.feeds = append(r.feeds, *feed)
+	return nil
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"service/internal/models"
+	"service/internal/tenant"
+)
+
+// PostgresIdempotencyRepository is an IdempotencyRepository backed by GORM.
+type PostgresIdempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresIdempotencyRepository(db *gorm.DB) *PostgresIdempotencyRepository {
+	return &PostgresIdempotencyRepository{db: db}
+}
+
+func (r *PostgresIdempotencyRepository) Reserve(ctx context.Context, rec *models.IdempotencyKey) error {
+	// An expired record for the key is taken over instead of conflicting.
+	res := conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tenant_id"}, {Name: "principal"}, {Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"request_hash", "status_code", "headers", "body", "created_at", "expires_at",
+		}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Lt{Column: clause.Column{Table: "idempotency_keys", Name: "expires_at"}, Value: rec.CreatedAt},
+		}},
+	}).Create(rec)
+	if res.Error != nil {
+		return translate(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *PostgresIdempotencyRepository) Get(ctx context.Context, principal, key string) (*models.IdempotencyKey, error) {
+	var rec models.IdempotencyKey
+	if err := conn(ctx, r.db).First(&rec, "principal = ? AND key = ? AND expires_at >= ?", principal, key, time.Now()).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &rec, nil
+}
+
+func (r *PostgresIdempotencyRepository) Complete(ctx context.Context, rec *models.IdempotencyKey) error {
+	// The key is matched explicitly because GORM leaves zero primary key
+	// fields out of the conditions.
+	res := conn(ctx, r.db).Model(&models.IdempotencyKey{}).
+		Where("tenant_id = ? AND principal = ? AND key = ?", rec.TenantID, rec.Principal, rec.Key).
+		Select("status_code", "headers", "body").
+		Updates(rec)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresIdempotencyRepository) Release(ctx context.Context, principal, key string) error {
+	return conn(ctx, r.db).Delete(&models.IdempotencyKey{}, "principal = ? AND key = ?", principal, key).Error
+}
+
+func (r *PostgresIdempotencyRepository) Purge(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	res := conn(ctx, r.db).Where("expires_at < ?", expiredBefore).Delete(&models.IdempotencyKey{})
+	return res.RowsAffected, res.Error
+}
+
+// idempotencyID is the primary key of an idempotency record.
+type idempotencyID struct {
+	tenantID  uuid.UUID
+	principal string
+	key       string
+}
+
+// MemoryIdempotencyRepository is an in-memory IdempotencyRepository.
+type MemoryIdempotencyRepository struct {
+	mu   sync.Mutex
+	keys map[idempotencyID]models.IdempotencyKey
+}
+
+func NewMemoryIdempotencyRepository() *MemoryIdempotencyRepository {
+	return &MemoryIdempotencyRepository{keys: map[idempotencyID]models.IdempotencyKey{}}
+}
+
+func (r *MemoryIdempotencyRepository) Reserve(ctx context.Context, rec *models.IdempotencyKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	assignTenantID(ctx, &rec.TenantID)
+	id := idempotencyID{tenantID: rec.TenantID, principal: rec.Principal, key: rec.Key}
+	if existing, ok := r.keys[id]; ok && !existing.ExpiresAt.Before(rec.CreatedAt) {
+		return ErrConflict
+	}
+	r.keys[id] = *rec
+	return nil
+}
+
+func (r *MemoryIdempotencyRepository) Get(ctx context.Context, principal, key string) (*models.IdempotencyKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, ok := r.keys[memoryIdempotencyID(ctx, principal, key)]
+	if !ok || rec.ExpiresAt.Before(time.Now()) {
+		return nil, ErrNotFound
+	}
+	return &rec, nil
+}
+
+func (r *MemoryIdempotencyRepository) Complete(_ context.Context, rec *models.IdempotencyKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := idempotencyID{tenantID: rec.TenantID, principal: rec.Principal, key: rec.Key}
+	stored, ok := r.keys[id]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.StatusCode = rec.StatusCode
+	stored.Headers = rec.Headers
+	stored.Body = append([]byte(nil), rec.Body...)
+	r.keys[id] = stored
+	return nil
+}
+
+func (r *MemoryIdempotencyRepository) Release(ctx context.Context, principal, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.keys, memoryIdempotencyID(ctx, principal, key))
+	return nil
+}
+
+func (r *MemoryIdempotencyRepository) Purge(_ context.Context, expiredBefore time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for id, rec := range r.keys {
+		if rec.ExpiresAt.Before(expiredBefore) {
+			delete(r.keys, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// memoryIdempotencyID identifies the record of the principal's key in the
+// tenant of ctx.
+func memoryIdempotencyID(ctx context.Context, principal, key string) idempotencyID {
+	tenantID, _ := tenant.FromContext(ctx)
+	return idempotencyID{tenantID: tenantID, principal: principal, key: key}
+}
@@ -0,0 +1,346 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"service/internal/models"
+	"service/internal/tenant"
+)
+
+// NewMemory returns repositories that keep everything in process memory. They
+// let the HTTP layer run without a database, e.g. in tests.
+func NewMemory() Repositories {
+	subs := NewMemorySubscriptionRepository()
+	prices := NewMemoryPriceRepository()
+	outbox := NewMemoryOutboxRepository()
+	audit := NewMemoryAuditRepository()
+	return Repositories{
+		Tx:            NewMemoryTransactor(subs, prices, outbox, audit),
+		Subscriptions: subs,
+		ExchangeRates: NewMemoryExchangeRateRepository(),
+		Idempotency:   NewMemoryIdempotencyRepository(),
+		Notifications: NewMemoryNotificationRepository(),
+		Outbox:        outbox,
+		Webhooks:      NewMemoryWebhookRepository(),
+		Budgets:       NewMemoryBudgetRepository(),
+		APIKeys:       NewMemoryAPIKeyRepository(),
+		Tenants:       NewMemoryTenantRepository(),
+		Audit:         audit,
+		Prices:        prices,
+		Calendars:     NewMemoryCalendarFeedRepository(),
+	}
+}
+
+// MemorySubscriptionRepository is an in-memory SubscriptionRepository.
+type MemorySubscriptionRepository struct {
+	mu   sync.RWMutex
+	subs map[uuid.UUID]models.Subscription
+}
+
+func NewMemorySubscriptionRepository() *MemorySubscriptionRepository {
+	return &MemorySubscriptionRepository{subs: map[uuid.UUID]models.Subscription{}}
+}
+
+func (r *MemorySubscriptionRepository) Create(ctx context.Context, sub *models.Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	assignTenantID(ctx, &sub.TenantID)
+	if sub.ID == uuid.Nil {
+		sub.ID = uuid.New()
+	}
+	if _, ok := r.subs[sub.ID]; ok {
+		return ErrConflict
+	}
+	if sub.Version == 0 {
+		sub.Version = 1
+	}
+	r.subs[sub.ID] = copySubscription(*sub)
+	return nil
+}
+
+func (r *MemorySubscriptionRepository) Get(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sub, ok := r.subs[id]
+	if !ok || sub.DeletedAt.Valid || !tenant.Visible(ctx, sub.TenantID) {
+		return nil, ErrNotFound
+	}
+	sub = copySubscription(sub)
+	return &sub, nil
+}
+
+func (r *MemorySubscriptionRepository) Update(ctx context.Context, sub *models.Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.subs[sub.ID]
+	if !ok || stored.DeletedAt.Valid || !tenant.Visible(ctx, stored.TenantID) {
+		return ErrNotFound
+	}
+	if stored.Version != sub.Version {
+		return ErrStale
+	}
+	sub.TenantID = stored.TenantID
+	sub.DeletedAt = stored.DeletedAt
+	sub.Version++
+	r.subs[sub.ID] = copySubscription(*sub)
+	return nil
+}
+
+func (r *MemorySubscriptionRepository) Delete(ctx context.Context, id uuid.UUID, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.subs[id]
+	if !ok || stored.DeletedAt.Valid || !tenant.Visible(ctx, stored.TenantID) {
+		return ErrNotFound
+	}
+	if stored.Version != version {
+		return ErrStale
+	}
+	stored.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	stored.Version++
+	r.subs[id] = stored
+	return nil
+}
+
+func (r *MemorySubscriptionRepository) Restore(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.subs[id]
+	if !ok || !stored.DeletedAt.Valid || !tenant.Visible(ctx, stored.TenantID) {
+		return nil, ErrNotFound
+	}
+	stored.DeletedAt = gorm.DeletedAt{}
+	stored.Version++
+	r.subs[id] = stored
+	sub := copySubscription(stored)
+	return &sub, nil
+}
+
+func (r *MemorySubscriptionRepository) Purge(ctx context.Context, deletedBefore time.Time) ([]models.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged []models.Subscription
+	for id, sub := range r.subs {
+		if tenant.Visible(ctx, sub.TenantID) && sub.DeletedAt.Valid && sub.DeletedAt.Time.Before(deletedBefore) {
+			delete(r.subs, id)
+			purged = append(purged, sub)
+		}
+	}
+	return purged, nil
+}
+
+func (r *MemorySubscriptionRepository) List(ctx context.Context, filter SubscriptionFilter) ([]models.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subs := []models.Subscription{}
+	for _, sub := range r.subs {
+		if tenant.Visible(ctx, sub.TenantID) && filter.matches(sub) {
+			subs = append(subs, copySubscription(sub))
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		if !subs[i].StartDate.Equal(subs[j].StartDate.Time) {
+			return subs[i].StartDate.Before(subs[j].StartDate.Time)
+		}
+		return subs[i].ID.String() < subs[j].ID.String()
+	})
+	return subs, nil
+}
+
+func (r *MemorySubscriptionRepository) Each(ctx context.Context, filter SubscriptionFilter, fn func(models.Subscription) error) error {
+	subs, err := r.List(ctx, filter)
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		if err := fn(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemorySubscriptionRepository) ListPage(ctx context.Context, filter SubscriptionFilter, req PageRequest) (Page, error) {
+	if req.Sort == "" {
+		req.Sort = SortStartDate
+	}
+	after, err := decodeCursor(req)
+	if err != nil {
+		return Page{}, err
+	}
+
+	subs, err := r.List(ctx, filter)
+	if err != nil {
+		return Page{}, err
+	}
+
+	less := func(a, b models.Subscription) bool {
+		cmp := compareSort(req.Sort, a, b)
+		if cmp == 0 {
+			cmp = strings.Compare(a.ID.String(), b.ID.String())
+		}
+		if req.Desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	}
+	sort.Slice(subs, func(i, j int) bool { return less(subs[i], subs[j]) })
+
+	page := Page{Total: int64(len(subs)), Items: []models.Subscription{}}
+	start := 0
+	if after != nil {
+		last := after.subscription()
+		start = sort.Search(len(subs), func(i int) bool { return less(last, subs[i]) })
+	}
+	end := start + req.Limit
+	if end < len(subs) {
+		page.NextCursor = encodeCursor(req, subs[end-1])
+	} else {
+		end = len(subs)
+	}
+	page.Items = append(page.Items, subs[start:end]...)
+	return page, nil
+}
+
+func (f SubscriptionFilter) matches(sub models.Subscription) bool {
+	switch f.Deleted {
+	case ExcludeDeleted:
+		if sub.DeletedAt.Valid {
+			return false
+		}
+	case OnlyDeleted:
+		if !sub.DeletedAt.Valid {
+			return false
+		}
+	}
+	if f.UserID != uuid.Nil && sub.UserID != f.UserID {
+		return false
+	}
+	if len(f.ServiceNames) > 0 {
+		found := false
+		for _, name := range f.ServiceNames {
+			if strings.Contains(strings.ToLower(sub.ServiceName), strings.ToLower(name)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.MinBasePrice != nil && sub.Price < *f.MinBasePrice {
+		return false
+	}
+	if f.MaxBasePrice != nil && sub.Price > *f.MaxBasePrice {
+		return false
+	}
+	if !f.StartsAfter.IsZero() && !sub.StartDate.After(f.StartsAfter) {
+		return false
+	}
+	if !f.StartsBefore.IsZero() && !sub.StartDate.Before(f.StartsBefore) {
+		return false
+	}
+	if !f.EndsBefore.IsZero() && (sub.EndDate == nil || !sub.EndDate.Before(f.EndsBefore)) {
+		return false
+	}
+	if !f.EndsOnOrAfter.IsZero() && sub.EndDate != nil && sub.EndDate.Before(f.EndsOnOrAfter) {
+		return false
+	}
+	if !f.ActiveOn.IsZero() && (sub.StartDate.After(f.ActiveOn) || sub.EndDate != nil && sub.EndDate.Before(f.ActiveOn)) {
+		return false
+	}
+	if f.HasEndDate != nil && (sub.EndDate != nil) != *f.HasEndDate {
+		return false
+	}
+	return true
+}
+
+func copySubscription(sub models.Subscription) models.Subscription {
+	if sub.EndDate != nil {
+		end := *sub.EndDate
+		sub.EndDate = &end
+	}
+	return sub
+}
+
+type rateKey struct {
+	base      string
+	quote     string
+	effective string
+}
+
+// MemoryExchangeRateRepository is an in-memory ExchangeRateRepository.
+type MemoryExchangeRateRepository struct {
+	mu     sync.RWMutex
+	nextID uint
+	rates  map[rateKey]models.ExchangeRate
+}
+
+func NewMemoryExchangeRateRepository() *MemoryExchangeRateRepository {
+	return &MemoryExchangeRateRepository{rates: map[rateKey]models.ExchangeRate{}}
+}
+
+func (r *MemoryExchangeRateRepository) Upsert(_ context.Context, rates []models.ExchangeRate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range rates {
+		key := rateKey{
+			base:      rates[i].BaseCurrency,
+			quote:     rates[i].QuoteCurrency,
+			effective: rates[i].EffectiveDate.Format("2006-01-02"),
+		}
+		if existing, ok := r.rates[key]; ok {
+			rates[i].ID = existing.ID
+		} else {
+			r.nextID++
+			rates[i].ID = r.nextID
+		}
+		r.rates[key] = rates[i]
+	}
+	return nil
+}
+
+func (r *MemoryExchangeRateRepository) List(_ context.Context, filter ExchangeRateFilter) ([]models.ExchangeRate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rates := []models.ExchangeRate{}
+	for _, rate := range r.rates {
+		if filter.BaseCurrency != "" && rate.BaseCurrency != filter.BaseCurrency {
+			continue
+		}
+		if filter.QuoteCurrency != "" && rate.QuoteCurrency != filter.QuoteCurrency {
+			continue
+		}
+		if !filter.EffectiveBefore.IsZero() && !rate.EffectiveDate.Before(filter.EffectiveBefore) {
+			continue
+		}
+		rates = append(rates, rate)
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		a, b := rates[i], rates[j]
+		if a.BaseCurrency != b.BaseCurrency {
+			return a.BaseCurrency < b.BaseCurrency
+		}
+		if a.QuoteCurrency != b.QuoteCurrency {
+			return a.QuoteCurrency < b.QuoteCurrency
+		}
+		return a.EffectiveDate.Before(b.EffectiveDate)
+	})
+	return rates, nil
+}
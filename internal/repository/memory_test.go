@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service/internal/models"
+)
+
+func date(y int, m time.Month) models.Month {
+	return models.NewMonth(time.Date(y, m, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func TestMemorySubscriptionsAreCopies(t *testing.T) {
+	repo := NewMemorySubscriptionRepository()
+	ctx := context.Background()
+	end := date(2026, 12)
+	stored := end
+	sub := &models.Subscription{ServiceName: "Netflix", Price: 100, UserID: uuid.New(), StartDate: date(2026, 1), EndDate: &stored}
+	if err := repo.Create(ctx, sub); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	*sub.EndDate = date(2030, 1)
+
+	got, err := repo.Get(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if !got.EndDate.Equal(end.Time) {
+		t.Errorf("stored end date changed through the caller's pointer to %s", got.EndDate)
+	}
+	*got.EndDate = date(2031, 1)
+	if again, _ := repo.Get(ctx, sub.ID); !again.EndDate.Equal(end.Time) {
+		t.Errorf("stored end date changed through a returned pointer to %s", again.EndDate)
+	}
+
+	if err := repo.Delete(ctx, sub.ID, got.Version); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := repo.Get(ctx, sub.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("get after delete: err = %v, want ErrNotFound", err)
+	}
+	if err := repo.Update(ctx, sub); !errors.Is(err, ErrNotFound) {
+		t.Errorf("update after delete: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryListFiltersAndOrders(t *testing.T) {
+	repo := NewMemorySubscriptionRepository()
+	ctx := context.Background()
+	user := uuid.New()
+	ended := date(2025, 6)
+	for _, sub := range []models.Subscription{
+		{ServiceName: "Spotify", UserID: user, StartDate: date(2026, 3)},
+		{ServiceName: "Netflix", UserID: user, StartDate: date(2026, 1)},
+		{ServiceName: "Netflix", UserID: user, StartDate: date(2025, 1), EndDate: &ended},
+		{ServiceName: "Netflix", UserID: uuid.New(), StartDate: date(2026, 2)},
+	} {
+		sub := sub
+		if err := repo.Create(ctx, &sub); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter SubscriptionFilter
+		want   []string
+	}{
+		{"all", SubscriptionFilter{}, []string{"2025-01", "2026-01", "2026-02", "2026-03"}},
+		{"user", SubscriptionFilter{UserID: user}, []string{"2025-01", "2026-01", "2026-03"}},
+		{"service", SubscriptionFilter{ServiceNames: []string{"NETFLIX"}}, []string{"2025-01", "2026-01", "2026-02"}},
+		{"window", SubscriptionFilter{EndsOnOrAfter: date(2026, 1).Time, StartsBefore: date(2026, 3).Time}, []string{"2026-01", "2026-02"}},
+	}
+	for _, tt := range tests {
+		subs, err := repo.List(ctx, tt.filter)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var got []string
+		for _, sub := range subs {
+			got = append(got, sub.StartDate.Format("2006-01"))
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: listed %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: listed %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestMemoryRejectsStaleVersions(t *testing.T) {
+	repo := NewMemorySubscriptionRepository()
+	ctx := context.Background()
+	sub := &models.Subscription{ServiceName: "Netflix", UserID: uuid.New(), StartDate: date(2026, 1)}
+	if err := repo.Create(ctx, sub); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if sub.Version != 1 {
+		t.Fatalf("created at version %d, want 1", sub.Version)
+	}
+	if err := repo.Create(ctx, sub); !errors.Is(err, ErrConflict) {
+		t.Errorf("create with a taken ID: err = %v, want ErrConflict", err)
+	}
+
+	stale := *sub
+	sub.Price = 100
+	if err := repo.Update(ctx, sub); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if sub.Version != 2 {
+		t.Errorf("updated to version %d, want 2", sub.Version)
+	}
+	stale.Price = 200
+	if err := repo.Update(ctx, &stale); !errors.Is(err, ErrStale) {
+		t.Errorf("update of version 1: err = %v, want ErrStale", err)
+	}
+	if err := repo.Delete(ctx, sub.ID, 1); !errors.Is(err, ErrStale) {
+		t.Errorf("delete of version 1: err = %v, want ErrStale", err)
+	}
+	if got, _ := repo.Get(ctx, sub.ID); got.Price != 100 {
+		t.Errorf("stored price %d, want 100", got.Price)
+	}
+}
+
+func TestMemoryTrash(t *testing.T) {
+	repo := NewMemorySubscriptionRepository()
+	ctx := context.Background()
+	kept := &models.Subscription{ServiceName: "Kept", UserID: uuid.New(), StartDate: date(2026, 1)}
+	trashed := &models.Subscription{ServiceName: "Trashed", UserID: uuid.New(), StartDate: date(2026, 2)}
+	for _, sub := range []*models.Subscription{kept, trashed} {
+		if err := repo.Create(ctx, sub); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	if err := repo.Delete(ctx, trashed.ID, 1); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := repo.Get(ctx, trashed.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("get of a deleted subscription: err = %v, want ErrNotFound", err)
+	}
+	if err := repo.Delete(ctx, trashed.ID, 2); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second delete: err = %v, want ErrNotFound", err)
+	}
+
+	for deleted, want := range map[DeletedFilter]int{ExcludeDeleted: 1, IncludeDeleted: 2, OnlyDeleted: 1} {
+		subs, err := repo.List(ctx, SubscriptionFilter{Deleted: deleted})
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		if len(subs) != want {
+			t.Errorf("list with Deleted=%d returned %d, want %d", deleted, len(subs), want)
+		}
+	}
+
+	restored, err := repo.Restore(ctx, trashed.ID)
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if restored.DeletedAt.Valid || restored.Version != 3 {
+		t.Errorf("restored %+v, want it out of the trash at version 3", restored)
+	}
+	if _, err := repo.Restore(ctx, kept.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("restore of a live subscription: err = %v, want ErrNotFound", err)
+	}
+
+	if err := repo.Delete(ctx, trashed.ID, 3); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if purged, err := repo.Purge(ctx, time.Now().Add(-time.Hour)); err != nil || len(purged) != 0 {
+		t.Errorf("purge of older entries removed %+v, %v; want none", purged, err)
+	}
+	if purged, err := repo.Purge(ctx, time.Now().Add(time.Second)); err != nil || len(purged) != 1 || purged[0].ID != trashed.ID {
+		t.Errorf("purge removed %+v, %v; want the trashed subscription", purged, err)
+	}
+	if _, err := repo.Restore(ctx, trashed.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("restore after purge: err = %v, want ErrNotFound", err)
+	}
+}
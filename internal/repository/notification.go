@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"service/internal/models"
+)
+
+// PostgresNotificationRepository is a NotificationRepository backed by GORM.
+type PostgresNotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresNotificationRepository(db *gorm.DB) *PostgresNotificationRepository {
+	return &PostgresNotificationRepository{db: db}
+}
+
+func (r *PostgresNotificationRepository) Claim(ctx context.Context, n *models.Notification, staleBefore time.Time) error {
+	// A stale claim that was never marked sent is taken over instead of
+	// conflicting, so a reminder whose sender died is retried.
+	res := conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "subscription_id"}, {Name: "kind"}, {Name: "due_date"}, {Name: "channel"},
+		},
+		DoUpdates: clause.AssignmentColumns([]string{"claimed_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Eq{Column: clause.Column{Table: "notifications", Name: "sent_at"}, Value: nil},
+			clause.Lt{Column: clause.Column{Table: "notifications", Name: "claimed_at"}, Value: staleBefore},
+		}},
+	}).Create(n)
+	if res.Error != nil {
+		return translate(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *PostgresNotificationRepository) MarkSent(ctx context.Context, id uint, sentAt time.Time) error {
+	res := conn(ctx, r.db).Model(&models.Notification{}).Where("id = ?", id).Update("sent_at", sentAt)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresNotificationRepository) Release(ctx context.Context, id uint) error {
+	return conn(ctx, r.db).Where("sent_at IS NULL").Delete(&models.Notification{}, id).Error
+}
+
+// MemoryNotificationRepository is an in-memory NotificationRepository.
+type MemoryNotificationRepository struct {
+	mu     sync.Mutex
+	nextID uint
+	byKey  map[notificationKey]models.Notification
+}
+
+type notificationKey struct {
+	subscriptionID uuid.UUID
+	kind           string
+	dueDate        string
+	channel        string
+}
+
+func keyOf(n *models.Notification) notificationKey {
+	return notificationKey{n.SubscriptionID, n.Kind, n.DueDate.Format(time.DateOnly), n.Channel}
+}
+
+func NewMemoryNotificationRepository() *MemoryNotificationRepository {
+	return &MemoryNotificationRepository{byKey: map[notificationKey]models.Notification{}}
+}
+
+func (r *MemoryNotificationRepository) Claim(_ context.Context, n *models.Notification, staleBefore time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := keyOf(n)
+	if existing, ok := r.byKey[key]; ok {
+		if existing.SentAt != nil || !existing.ClaimedAt.Before(staleBefore) {
+			return ErrConflict
+		}
+		n.ID = existing.ID
+	} else {
+		r.nextID++
+		n.ID = r.nextID
+	}
+	r.byKey[key] = *n
+	return nil
+}
+
+func (r *MemoryNotificationRepository) MarkSent(_ context.Context, id uint, sentAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, n := range r.byKey {
+		if n.ID == id {
+			n.SentAt = &sentAt
+			r.byKey[key] = n
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *MemoryNotificationRepository) Release(_ context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, n := range r.byKey {
+		if n.ID == id && n.SentAt == nil {
+			delete(r.byKey, key)
+		}
+	}
+	return nil
+}
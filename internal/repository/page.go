@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service/internal/models"
+)
+
+// ErrInvalidCursor is returned when a page cursor cannot be decoded or was
+// issued for a different sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SortField is a column subscriptions can be ordered by.
+type SortField string
+
+const (
+	SortStartDate SortField = "start_date"
+	// SortBasePrice orders by Price, the price at the start month; later
+	// price changes are not considered.
+	SortBasePrice   SortField = "price"
+	SortServiceName SortField = "service_name"
+)
+
+// Valid reports whether the field is one of the supported sort columns.
+func (f SortField) Valid() bool {
+	switch f {
+	case SortStartDate, SortBasePrice, SortServiceName:
+		return true
+	}
+	return false
+}
+
+// PageRequest selects a page of subscriptions. Pages are ordered by Sort and
+// then by ID so that every subscription has a unique position.
+type PageRequest struct {
+	Sort  SortField
+	Desc  bool
+	Limit int
+	// Cursor is the NextCursor of the previous page, empty for the first page.
+	Cursor string
+}
+
+// Page is a page of subscriptions.
+type Page struct {
+	Items []models.Subscription
+	// NextCursor continues after the last item, empty on the last page.
+	NextCursor string
+	// Total is the number of subscriptions matching the filter across all pages.
+	Total int64
+}
+
+type cursor struct {
+	Sort  SortField `json:"s"`
+	Desc  bool      `json:"d,omitempty"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+func encodeCursor(req PageRequest, sub models.Subscription) string {
+	c := cursor{Sort: req.Sort, Desc: req.Desc, Value: sortValue(req.Sort, sub), ID: sub.ID}
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(req PageRequest) (*cursor, error) {
+	if req.Cursor == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(req.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.Sort != req.Sort || c.Desc != req.Desc || c.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	if _, err := c.key(); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// key returns the cursor's sort value in the type of its column.
+func (c cursor) key() (any, error) {
+	switch c.Sort {
+	case SortBasePrice:
+		return strconv.Atoi(c.Value)
+	case SortServiceName:
+		return c.Value, nil
+	default:
+		return time.Parse(time.RFC3339Nano, c.Value)
+	}
+}
+
+// subscription returns a subscription positioned where the cursor points.
+func (c cursor) subscription() models.Subscription {
+	sub := models.Subscription{ID: c.ID}
+	key, _ := c.key()
+	switch v := key.(type) {
+	case int:
+		sub.Price = v
+	case string:
+		sub.ServiceName = v
+	case time.Time:
+		sub.StartDate = models.NewMonth(v)
+	}
+	return sub
+}
+
+func sortValue(field SortField, sub models.Subscription) string {
+	switch field {
+	case SortBasePrice:
+		return strconv.Itoa(sub.Price)
+	case SortServiceName:
+		return sub.ServiceName
+	default:
+		return sub.StartDate.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// compareSort orders a before b by the given field, returning a negative
+// number, zero or a positive number.
+func compareSort(field SortField, a, b models.Subscription) int {
+	switch field {
+	case SortBasePrice:
+		return a.Price - b.Price
+	case SortServiceName:
+		switch {
+		case a.ServiceName < b.ServiceName:
+			return -1
+		case a.ServiceName > b.ServiceName:
+			return 1
+		}
+		return 0
+	default:
+		return a.StartDate.Compare(b.StartDate.Time)
+	}
+}
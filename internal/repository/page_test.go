@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service/internal/models"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	sub := models.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Netflix, Inc.",
+		Price:       499,
+		StartDate:   date(2026, 3),
+	}
+	for _, field := range []SortField{SortStartDate, SortBasePrice, SortServiceName} {
+		for _, desc := range []bool{false, true} {
+			req := PageRequest{Sort: field, Desc: desc}
+			req.Cursor = encodeCursor(req, sub)
+
+			c, err := decodeCursor(req)
+			if err != nil {
+				t.Fatalf("decode %s desc=%v: %v", field, desc, err)
+			}
+			pos := c.subscription()
+			if pos.ID != sub.ID || compareSort(field, pos, sub) != 0 {
+				t.Errorf("cursor %s desc=%v points at %+v, want %+v", field, desc, pos, sub)
+			}
+		}
+	}
+}
+
+func TestDecodeCursorRejectsForeignCursors(t *testing.T) {
+	sub := models.Subscription{ID: uuid.New(), Price: 1}
+	cursor := encodeCursor(PageRequest{Sort: SortBasePrice}, sub)
+
+	for name, req := range map[string]PageRequest{
+		"other field": {Sort: SortServiceName, Cursor: cursor},
+		"other order": {Sort: SortBasePrice, Desc: true, Cursor: cursor},
+		"not base64":  {Sort: SortBasePrice, Cursor: "%%%"},
+		"not json":    {Sort: SortBasePrice, Cursor: "bm9wZQ"},
+	} {
+		if _, err := decodeCursor(req); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: err = %v, want ErrInvalidCursor", name, err)
+		}
+	}
+}
+
+func TestMemoryListPageVisitsEveryRowOnce(t *testing.T) {
+	repo := NewMemorySubscriptionRepository()
+	ctx := context.Background()
+	for i := 0; i < 7; i++ {
+		sub := &models.Subscription{
+			ServiceName: "Service",
+			Price:       100 * (i % 3),
+			UserID:      uuid.New(),
+			StartDate:   date(2026, time.Month(1+i%2)),
+		}
+		if err := repo.Create(ctx, sub); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	for _, field := range []SortField{SortStartDate, SortBasePrice, SortServiceName} {
+		for _, desc := range []bool{false, true} {
+			req := PageRequest{Sort: field, Desc: desc, Limit: 3}
+			seen := map[uuid.UUID]bool{}
+			var prev *models.Subscription
+			for pages := 0; ; pages++ {
+				if pages > 7 {
+					t.Fatalf("%s desc=%v: pagination does not terminate", field, desc)
+				}
+				page, err := repo.ListPage(ctx, SubscriptionFilter{}, req)
+				if err != nil {
+					t.Fatalf("%s desc=%v: %v", field, desc, err)
+				}
+				if page.Total != 7 {
+					t.Errorf("%s desc=%v: total = %d, want 7", field, desc, page.Total)
+				}
+				for i := range page.Items {
+					sub := page.Items[i]
+					if seen[sub.ID] {
+						t.Errorf("%s desc=%v: %s listed twice", field, desc, sub.ID)
+					}
+					seen[sub.ID] = true
+					if prev != nil {
+						order := compareSort(field, *prev, sub)
+						if desc {
+							order = -order
+						}
+						if order > 0 {
+							t.Errorf("%s desc=%v: %+v listed before %+v", field, desc, *prev, sub)
+						}
+					}
+					prev = &sub
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				req.Cursor = page.NextCursor
+			}
+			if len(seen) != 7 {
+				t.Errorf("%s desc=%v: listed %d subscriptions, want 7", field, desc, len(seen))
+			}
+		}
+	}
+}
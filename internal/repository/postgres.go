@@ -0,0 +1,296 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"service/internal/models"
+)
+
+// NewPostgres returns the repositories backed by db, a connection that row-level
+// security confines to the tenant of each query's context. API keys are also
+// looked up through system, a BYPASSRLS connection, because the tenant of a
+// request is only known once its key is found; background jobs, which work
+// across tenants, pass system as both. The tenant scope callbacks are
+// installed on both connections.
+func NewPostgres(db, system *gorm.DB) Repositories {
+	for _, db := range []*gorm.DB{db, system} {
+		if err := registerTenantScope(db); err != nil {
+			panic(fmt.Sprintf("register tenant scope: %v", err))
+		}
+	}
+	return Repositories{
+		Tx:            NewPostgresTransactor(db),
+		Subscriptions: NewPostgresSubscriptionRepository(db),
+		ExchangeRates: NewPostgresExchangeRateRepository(db),
+		Idempotency:   NewPostgresIdempotencyRepository(db),
+		Notifications: NewPostgresNotificationRepository(db),
+		Outbox:        NewPostgresOutboxRepository(db),
+		Webhooks:      NewPostgresWebhookRepository(db),
+		Budgets:       NewPostgresBudgetRepository(db),
+		APIKeys:       NewPostgresAPIKeyRepository(db, system),
+		Tenants:       NewPostgresTenantRepository(db),
+		Audit:         NewPostgresAuditRepository(db),
+		Prices:        NewPostgresPriceRepository(db),
+		Calendars:     NewPostgresCalendarFeedRepository(db),
+	}
+}
+
+// translate maps GORM errors to the repository errors. It relies on the
+// connection being opened with TranslateError enabled.
+func translate(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrConflict
+	}
+	return err
+}
+
+// PostgresSubscriptionRepository is a SubscriptionRepository backed by GORM.
+type PostgresSubscriptionRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresSubscriptionRepository(db *gorm.DB) *PostgresSubscriptionRepository {
+	return &PostgresSubscriptionRepository{db: db}
+}
+
+func (r *PostgresSubscriptionRepository) Create(ctx context.Context, sub *models.Subscription) error {
+	return translate(conn(ctx, r.db).Create(sub).Error)
+}
+
+func (r *PostgresSubscriptionRepository) Get(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	var sub models.Subscription
+	if err := conn(ctx, r.db).First(&sub, "id = ?", id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &sub, nil
+}
+
+func (r *PostgresSubscriptionRepository) Update(ctx context.Context, sub *models.Subscription) error {
+	expected := sub.Version
+	sub.Version++
+	res := conn(ctx, r.db).Model(sub).Where("version = ?", expected).Select("*").Omit("tenant_id", "deleted_at").Updates(sub)
+	if res.Error != nil {
+		sub.Version = expected
+		return translate(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		sub.Version = expected
+		return r.missingOrStale(ctx, sub.ID)
+	}
+	return nil
+}
+
+func (r *PostgresSubscriptionRepository) Delete(ctx context.Context, id uuid.UUID, version int) error {
+	res := conn(ctx, r.db).Model(&models.Subscription{}).
+		Where("id = ? AND version = ?", id, version).
+		Updates(map[string]any{"deleted_at": time.Now(), "version": gorm.Expr("version + 1")})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return r.missingOrStale(ctx, id)
+	}
+	return nil
+}
+
+func (r *PostgresSubscriptionRepository) Restore(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	res := conn(ctx, r.db).Unscoped().Model(&models.Subscription{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]any{"deleted_at": nil, "version": gorm.Expr("version + 1")})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+	return r.Get(ctx, id)
+}
+
+func (r *PostgresSubscriptionRepository) Purge(ctx context.Context, deletedBefore time.Time) ([]models.Subscription, error) {
+	var purged []models.Subscription
+	err := conn(ctx, r.db).Unscoped().
+		Clauses(clause.Returning{}).
+		Where("deleted_at < ?", deletedBefore).
+		Delete(&purged).Error
+	return purged, err
+}
+
+// missingOrStale tells why a versioned write matched no row.
+func (r *PostgresSubscriptionRepository) missingOrStale(ctx context.Context, id uuid.UUID) error {
+	var count int64
+	if err := conn(ctx, r.db).Model(&models.Subscription{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return ErrStale
+}
+
+func (r *PostgresSubscriptionRepository) List(ctx context.Context, filter SubscriptionFilter) ([]models.Subscription, error) {
+	var subs []models.Subscription
+	if err := r.filtered(ctx, filter).Order("start_date, id").Find(&subs).Error; err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// Each reads the rows in a transaction, so app.tenant_id stays set until the
+// last of them is read.
+func (r *PostgresSubscriptionRepository) Each(ctx context.Context, filter SubscriptionFilter, fn func(models.Subscription) error) error {
+	return NewPostgresTransactor(r.db).InTx(ctx, func(ctx context.Context) error {
+		query := r.filtered(ctx, filter).Order("start_date, id")
+		rows, err := query.Rows()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var sub models.Subscription
+			if err := query.ScanRows(rows, &sub); err != nil {
+				return err
+			}
+			if err := fn(sub); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	})
+}
+
+func (r *PostgresSubscriptionRepository) ListPage(ctx context.Context, filter SubscriptionFilter, req PageRequest) (Page, error) {
+	if req.Sort == "" {
+		req.Sort = SortStartDate
+	}
+	after, err := decodeCursor(req)
+	if err != nil {
+		return Page{}, err
+	}
+
+	var page Page
+	if err := r.filtered(ctx, filter).Count(&page.Total).Error; err != nil {
+		return Page{}, err
+	}
+
+	direction, comparison := "ASC", ">"
+	if req.Desc {
+		direction, comparison = "DESC", "<"
+	}
+	query := r.filtered(ctx, filter)
+	if after != nil {
+		key, _ := after.key()
+		query = query.Where(fmt.Sprintf("(%s, id) %s (?, ?)", req.Sort, comparison), key, after.ID)
+	}
+	query = query.Order(fmt.Sprintf("%s %s, id %s", req.Sort, direction, direction)).Limit(req.Limit + 1)
+
+	if err := query.Find(&page.Items).Error; err != nil {
+		return Page{}, err
+	}
+	if len(page.Items) > req.Limit {
+		page.Items = page.Items[:req.Limit]
+		page.NextCursor = encodeCursor(req, page.Items[req.Limit-1])
+	}
+	return page, nil
+}
+
+// filtered returns a subscriptions query narrowed down by filter.
+func (r *PostgresSubscriptionRepository) filtered(ctx context.Context, filter SubscriptionFilter) *gorm.DB {
+	query := conn(ctx, r.db).Model(&models.Subscription{})
+
+	switch filter.Deleted {
+	case IncludeDeleted:
+		query = query.Unscoped()
+	case OnlyDeleted:
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+
+	if filter.UserID != uuid.Nil {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if len(filter.ServiceNames) > 0 {
+		names := r.db.Where("service_name ILIKE ?", "%"+filter.ServiceNames[0]+"%")
+		for _, name := range filter.ServiceNames[1:] {
+			names = names.Or("service_name ILIKE ?", "%"+name+"%")
+		}
+		query = query.Where(names)
+	}
+	if filter.MinBasePrice != nil {
+		query = query.Where("price >= ?", *filter.MinBasePrice)
+	}
+	if filter.MaxBasePrice != nil {
+		query = query.Where("price <= ?", *filter.MaxBasePrice)
+	}
+	if !filter.StartsAfter.IsZero() {
+		query = query.Where("start_date > ?", filter.StartsAfter)
+	}
+	if !filter.StartsBefore.IsZero() {
+		query = query.Where("start_date < ?", filter.StartsBefore)
+	}
+	if !filter.EndsBefore.IsZero() {
+		query = query.Where("end_date < ?", filter.EndsBefore)
+	}
+	if !filter.EndsOnOrAfter.IsZero() {
+		query = query.Where("(end_date IS NULL OR end_date >= ?)", filter.EndsOnOrAfter)
+	}
+	if !filter.ActiveOn.IsZero() {
+		query = query.Where("start_date <= ? AND (end_date IS NULL OR end_date >= ?)", filter.ActiveOn, filter.ActiveOn)
+	}
+	if filter.HasEndDate != nil {
+		if *filter.HasEndDate {
+			query = query.Where("end_date IS NOT NULL")
+		} else {
+			query = query.Where("end_date IS NULL")
+		}
+	}
+	return query
+}
+
+// PostgresExchangeRateRepository is an ExchangeRateRepository backed by GORM.
+type PostgresExchangeRateRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresExchangeRateRepository(db *gorm.DB) *PostgresExchangeRateRepository {
+	return &PostgresExchangeRateRepository{db: db}
+}
+
+func (r *PostgresExchangeRateRepository) Upsert(ctx context.Context, rates []models.ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "base_currency"}, {Name: "quote_currency"}, {Name: "effective_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate"}),
+	}).Create(&rates).Error
+}
+
+func (r *PostgresExchangeRateRepository) List(ctx context.Context, filter ExchangeRateFilter) ([]models.ExchangeRate, error) {
+	var rates []models.ExchangeRate
+	query := conn(ctx, r.db)
+
+	if filter.BaseCurrency != "" {
+		query = query.Where("base_currency = ?", filter.BaseCurrency)
+	}
+	if filter.QuoteCurrency != "" {
+		query = query.Where("quote_currency = ?", filter.QuoteCurrency)
+	}
+	if !filter.EffectiveBefore.IsZero() {
+		query = query.Where("effective_date < ?", filter.EffectiveBefore)
+	}
+
+	if err := query.Order("base_currency, quote_currency, effective_date").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
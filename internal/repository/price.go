@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"service/internal/models"
+	"service/internal/tenant"
+)
+
+// priceListChunk bounds the subscription IDs sent in one query, well below
+// the limit on the parameters of a statement.
+const priceListChunk = 1000
+
+// PostgresPriceRepository is a PriceRepository backed by GORM.
+type PostgresPriceRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresPriceRepository(db *gorm.DB) *PostgresPriceRepository {
+	return &PostgresPriceRepository{db: db}
+}
+
+func (r *PostgresPriceRepository) Create(ctx context.Context, change *models.PriceChange) error {
+	return translate(conn(ctx, r.db).Create(change).Error)
+}
+
+func (r *PostgresPriceRepository) Delete(ctx context.Context, subscriptionID, id uuid.UUID) error {
+	res := conn(ctx, r.db).Delete(&models.PriceChange{}, "id = ? AND subscription_id = ?", id, subscriptionID)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresPriceRepository) List(ctx context.Context, subscriptionIDs []uuid.UUID) ([]models.PriceChange, error) {
+	var changes []models.PriceChange
+	for start := 0; start < len(subscriptionIDs); start += priceListChunk {
+		end := min(start+priceListChunk, len(subscriptionIDs))
+		var chunk []models.PriceChange
+		err := conn(ctx, r.db).
+			Where("subscription_id IN ?", subscriptionIDs[start:end]).
+			Order("subscription_id, effective_from").
+			Find(&chunk).Error
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, chunk...)
+	}
+	return changes, nil
+}
+
+// MemoryPriceRepository is an in-memory PriceRepository.
+type MemoryPriceRepository struct {
+	mu      sync.Mutex
+	changes map[uuid.UUID]models.PriceChange
+}
+
+func NewMemoryPriceRepository() *MemoryPriceRepository {
+	return &MemoryPriceRepository{changes: map[uuid.UUID]models.PriceChange{}}
+}
+
+func (r *MemoryPriceRepository) Create(ctx context.Context, change *models.PriceChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	assignTenantID(ctx, &change.TenantID)
+	if change.ID == uuid.Nil {
+		change.ID = uuid.New()
+	}
+	for _, c := range r.changes {
+		if c.ID == change.ID || c.SubscriptionID == change.SubscriptionID && c.EffectiveFrom.Equal(change.EffectiveFrom.Time) {
+			return ErrConflict
+		}
+	}
+	r.changes[change.ID] = *change
+	return nil
+}
+
+func (r *MemoryPriceRepository) Delete(ctx context.Context, subscriptionID, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.changes[id]
+	if !ok || c.SubscriptionID != subscriptionID || !tenant.Visible(ctx, c.TenantID) {
+		return ErrNotFound
+	}
+	delete(r.changes, id)
+	return nil
+}
+
+func (r *MemoryPriceRepository) List(ctx context.Context, subscriptionIDs []uuid.UUID) ([]models.PriceChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	wanted := make(map[uuid.UUID]bool, len(subscriptionIDs))
+	for _, id := range subscriptionIDs {
+		wanted[id] = true
+	}
+	var changes []models.PriceChange
+	for _, c := range r.changes {
+		if wanted[c.SubscriptionID] && tenant.Visible(ctx, c.TenantID) {
+			changes = append(changes, c)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].SubscriptionID != changes[j].SubscriptionID {
+			return changes[i].SubscriptionID.String() < changes[j].SubscriptionID.String()
+		}
+		return changes[i].EffectiveFrom.Before(changes[j].EffectiveFrom.Time)
+	})
+	return changes, nil
+}
+
+func (r *MemoryPriceRepository) snapshot() map[uuid.UUID]models.PriceChange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changes := make(map[uuid.UUID]models.PriceChange, len(r.changes))
+	for id, c := range r.changes {
+		changes[id] = c
+	}
+	return changes
+}
+
+func (r *MemoryPriceRepository) restore(changes map[uuid.UUID]models.PriceChange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.changes = changes
+}
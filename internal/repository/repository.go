@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service/internal/models"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist.
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict is returned when a record with the same key already exists.
+	ErrConflict = errors.New("record already exists")
+	// ErrStale is returned when a record was modified since the version the
+	// caller read.
+	ErrStale = errors.New("record was modified concurrently")
+)
+
+// SubscriptionFilter narrows down the subscriptions returned by List. Zero
+// values disable the corresponding condition.
+type SubscriptionFilter struct {
+	UserID uuid.UUID
+	// ServiceNames matches service names containing any of them, case-insensitively.
+	ServiceNames []string
+	// MinBasePrice and MaxBasePrice bound Price, the price at the start
+	// month; later price changes are not considered.
+	MinBasePrice *int
+	MaxBasePrice *int
+	// StartsAfter keeps subscriptions starting strictly after it.
+	StartsAfter time.Time
+	// StartsBefore keeps subscriptions starting strictly before it.
+	StartsBefore time.Time
+	// EndsBefore keeps subscriptions with an end date strictly before it.
+	EndsBefore time.Time
+	// EndsOnOrAfter keeps open-ended subscriptions and those ending on or after it.
+	EndsOnOrAfter time.Time
+	// ActiveOn keeps subscriptions whose start..end range covers it.
+	ActiveOn time.Time
+	// HasEndDate keeps only subscriptions with (true) or without (false) an end date.
+	HasEndDate *bool
+	Deleted    DeletedFilter
+}
+
+// DeletedFilter selects subscriptions by whether they are in the trash.
+type DeletedFilter int
+
+const (
+	ExcludeDeleted DeletedFilter = iota
+	IncludeDeleted
+	OnlyDeleted
+)
+
+// SubscriptionRepository stores subscriptions.
+type SubscriptionRepository interface {
+	Create(ctx context.Context, sub *models.Subscription) error
+	Get(ctx context.Context, id uuid.UUID) (*models.Subscription, error)
+	// Update stores sub if its Version still matches the stored one and
+	// increments Version. It returns ErrStale otherwise.
+	Update(ctx context.Context, sub *models.Subscription) error
+	// Delete moves the subscription to the trash if it is still at the given
+	// version. It returns ErrStale otherwise.
+	Delete(ctx context.Context, id uuid.UUID, version int) error
+	// Restore moves a subscription out of the trash. It returns ErrNotFound
+	// when the subscription is not in the trash.
+	Restore(ctx context.Context, id uuid.UUID) (*models.Subscription, error)
+	// Purge permanently removes the subscriptions moved to the trash before
+	// the given time and returns them.
+	Purge(ctx context.Context, deletedBefore time.Time) ([]models.Subscription, error)
+	// List returns the matching subscriptions ordered by start date.
+	List(ctx context.Context, filter SubscriptionFilter) ([]models.Subscription, error)
+	// Each calls fn with every matching subscription in List order, reading
+	// them one at a time. It stops at the first error fn returns.
+	Each(ctx context.Context, filter SubscriptionFilter, fn func(models.Subscription) error) error
+	// ListPage returns a page of the matching subscriptions using keyset
+	// pagination. It returns ErrInvalidCursor for a malformed cursor.
+	ListPage(ctx context.Context, filter SubscriptionFilter, req PageRequest) (Page, error)
+}
+
+// ExchangeRateFilter narrows down the exchange rates returned by List.
+type ExchangeRateFilter struct {
+	BaseCurrency  string
+	QuoteCurrency string
+	// EffectiveBefore keeps rates effective strictly before it.
+	EffectiveBefore time.Time
+}
+
+// ExchangeRateRepository stores exchange rates.
+type ExchangeRateRepository interface {
+	// Upsert inserts the rates, replacing any existing rate for the same
+	// currency pair and effective date.
+	Upsert(ctx context.Context, rates []models.ExchangeRate) error
+	// List returns the matching rates ordered by pair and effective date.
+	List(ctx context.Context, filter ExchangeRateFilter) ([]models.ExchangeRate, error)
+}
+
+// IdempotencyRepository stores the responses to requests sent with an
+// Idempotency-Key header.
+type IdempotencyRepository interface {
+	// Reserve records rec as in progress. It returns ErrConflict when an
+	// unexpired record for the same tenant, principal and key exists.
+	Reserve(ctx context.Context, rec *models.IdempotencyKey) error
+	// Get returns the unexpired record the principal stored for key in the
+	// tenant of ctx.
+	Get(ctx context.Context, principal, key string) (*models.IdempotencyKey, error)
+	// Complete stores the response of a reserved key.
+	Complete(ctx context.Context, rec *models.IdempotencyKey) error
+	// Release forgets a key the principal reserved in the tenant of ctx so
+	// the request can be retried.
+	Release(ctx context.Context, principal, key string) error
+	// Purge removes the records that expired before the given time and
+	// returns how many were removed.
+	Purge(ctx context.Context, expiredBefore time.Time) (int64, error)
+}
+
+// NotificationRepository records the reminders sent through each
+// notification channel.
+type NotificationRepository interface {
+	// Claim records n as being sent. It returns ErrConflict when the reminder
+	// was already sent through the channel or claimed at or after
+	// staleBefore by a sender that may still deliver it.
+	Claim(ctx context.Context, n *models.Notification, staleBefore time.Time) error
+	// MarkSent records that a claimed notification was delivered.
+	MarkSent(ctx context.Context, id uint, sentAt time.Time) error
+	// Release forgets a claim so the reminder is sent again later.
+	Release(ctx context.Context, id uint) error
+}
+
+// OutboxRepository stores events until the webhook dispatcher hands them to
+// the subscribed endpoints.
+type OutboxRepository interface {
+	// Append stores an event. It returns ErrConflict when an event with the
+	// same Key exists.
+	Append(ctx context.Context, event *models.OutboxEvent) error
+	// Pending returns up to limit undispatched events, oldest first. Within a
+	// transaction they are locked, and skipped by other callers, until it
+	// ends.
+	Pending(ctx context.Context, limit int) ([]models.OutboxEvent, error)
+	// MarkDispatched records that the events were handed over.
+	MarkDispatched(ctx context.Context, ids []uint, at time.Time) error
+}
+
+// DeliveryFilter narrows down the deliveries returned by ListDeliveries.
+type DeliveryFilter struct {
+	EndpointID uuid.UUID
+	Status     models.DeliveryStatus
+	Limit      int
+}
+
+// WebhookRepository stores webhook endpoints and their deliveries.
+type WebhookRepository interface {
+	CreateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) error
+	GetEndpoint(ctx context.Context, id uuid.UUID) (*models.WebhookEndpoint, error)
+	// ListEndpoints returns every endpoint ordered by creation time.
+	ListEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error)
+	// DeleteEndpoint removes an endpoint and its deliveries.
+	DeleteEndpoint(ctx context.Context, id uuid.UUID) error
+	CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error
+	// ClaimDeliveries returns up to limit pending deliveries due at now and
+	// postpones their next attempt to leaseUntil, so other callers skip them
+	// while they are being sent.
+	ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error)
+	// UpdateDelivery stores the outcome of an attempt.
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	// ListDeliveries returns the matching deliveries, newest first.
+	ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]models.WebhookDelivery, error)
+}
+
+// BudgetRepository stores budgets.
+type BudgetRepository interface {
+	// Create stores a budget. It returns ErrConflict when the user already
+	// has a budget for the category.
+	Create(ctx context.Context, budget *models.Budget) error
+	Get(ctx context.Context, id uuid.UUID) (*models.Budget, error)
+	// Update replaces a budget. It returns ErrConflict like Create.
+	Update(ctx context.Context, budget *models.Budget) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// List returns the budgets of a user, or of every user for uuid.Nil,
+	// ordered by user and category.
+	List(ctx context.Context, userID uuid.UUID) ([]models.Budget, error)
+}
+
+// APIKeyRepository stores the API keys of service callers.
+type APIKeyRepository interface {
+	// Create stores a key. It returns ErrConflict when the prefix is taken.
+	Create(ctx context.Context, key *models.APIKey) error
+	Get(ctx context.Context, id uuid.UUID) (*models.APIKey, error)
+	// GetByPrefix returns the key with the given public prefix.
+	GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	// List returns every key, revoked ones included, oldest first.
+	List(ctx context.Context) ([]models.APIKey, error)
+	// Update replaces a key, leaving its creation and last use untouched.
+	Update(ctx context.Context, key *models.APIKey) error
+	// Touch records that a key was used at usedAt, unless a later use is
+	// already recorded.
+	Touch(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
+
+// PriceRepository stores the price changes of subscriptions.
+type PriceRepository interface {
+	// Create stores a price change. It returns ErrConflict when the
+	// subscription already has a change effective in the same month.
+	Create(ctx context.Context, change *models.PriceChange) error
+	// Delete removes a price change of the subscription.
+	Delete(ctx context.Context, subscriptionID, id uuid.UUID) error
+	// List returns the price changes of the subscriptions, ordered by
+	// subscription and effective month.
+	List(ctx context.Context, subscriptionIDs []uuid.UUID) ([]models.PriceChange, error)
+}
+
+// CalendarFeedRepository stores the nonces of the calendar feeds.
+type CalendarFeedRepository interface {
+	// Get returns the feed of the user in the tenant of ctx. It returns
+	// ErrNotFound for users whose feed was never rotated.
+	Get(ctx context.Context, userID uuid.UUID) (*models.CalendarFeed, error)
+	// Save stores feed, replacing the previous feed of the user.
+	Save(ctx context.Context, feed *models.CalendarFeed) error
+}
+
+// AuditFilter narrows down the audit entries returned by List. Zero values
+// disable the corresponding condition.
+type AuditFilter struct {
+	SubscriptionID uuid.UUID
+	UserID         uuid.UUID
+	Actor          string
+	Action         models.AuditAction
+	// From keeps entries created at or after it.
+	From time.Time
+	// To keeps entries created strictly before it.
+	To time.Time
+	// BeforeID keeps entries older than the entry with this ID, to page
+	// through the log.
+	BeforeID uint
+	Limit    int
+}
+
+// AuditRepository stores the append-only audit log of subscription changes.
+type AuditRepository interface {
+	Append(ctx context.Context, entry *models.AuditEntry) error
+	// List returns the matching entries, newest first.
+	List(ctx context.Context, filter AuditFilter) ([]models.AuditEntry, error)
+}
+
+// TenantRepository stores the tenants.
+type TenantRepository interface {
+	// Create stores a tenant. It returns ErrConflict when the slug is taken.
+	Create(ctx context.Context, t *models.Tenant) error
+	Get(ctx context.Context, id uuid.UUID) (*models.Tenant, error)
+	GetBySlug(ctx context.Context, slug string) (*models.Tenant, error)
+	// List returns every tenant ordered by slug.
+	List(ctx context.Context) ([]models.Tenant, error)
+}
+
+// Repositories groups the repositories the handlers depend on.
+type Repositories struct {
+	Tx            Transactor
+	Subscriptions SubscriptionRepository
+	ExchangeRates ExchangeRateRepository
+	Idempotency   IdempotencyRepository
+	Notifications NotificationRepository
+	Outbox        OutboxRepository
+	Webhooks      WebhookRepository
+	Budgets       BudgetRepository
+	APIKeys       APIKeyRepository
+	Tenants       TenantRepository
+	Audit         AuditRepository
+	Prices        PriceRepository
+	Calendars     CalendarFeedRepository
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	gormcallbacks "gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+
+	"service/internal/models"
+	"service/internal/tenant"
+)
+
+// tenantField is the field of the models owned by a tenant.
+const tenantField = "TenantID"
+
+// registerTenantScope makes every GORM query on a model owned by a tenant
+// confined to the tenant of its context: reads, updates and deletes get a
+// tenant_id condition and created rows are assigned the tenant. The
+// row-level security policies show no rows without app.tenant_id, so writes
+// and queries set it too, queries in a transaction of their own when they
+// run outside of one. PostgresTransactor sets it for whole transactions.
+// Registering the callbacks again on the same DB does nothing.
+func registerTenantScope(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if callbacks.Query().Get("tenant:scope") != nil {
+		return nil
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("tenant:scope", scopeTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("tenant:begin_transaction", beginTenantTransaction); err != nil {
+		return err
+	}
+	if err := callbacks.Query().After("tenant:begin_transaction").Before("gorm:query").Register("tenant:setting", setTenantSetting); err != nil {
+		return err
+	}
+	if err := callbacks.Query().After("gorm:after_query").Register("tenant:commit_or_rollback_transaction", gormcallbacks.CommitOrRollbackTransaction); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("tenant:scope", scopeTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant:scope", scopeTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("tenant:scope", scopeTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Create().Before("gorm:create").Register("tenant:assign", assignTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Create().After("gorm:begin_transaction").Register("tenant:setting", setTenantSetting); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:begin_transaction").Register("tenant:setting", setTenantSetting); err != nil {
+		return err
+	}
+	return callbacks.Delete().After("gorm:begin_transaction").Register("tenant:setting", setTenantSetting)
+}
+
+func tenantScoped(db *gorm.DB) (uuid.UUID, bool) {
+	if db.Statement.Schema == nil || db.Statement.Schema.LookUpField(tenantField) == nil {
+		return uuid.Nil, false
+	}
+	return tenant.FromContext(db.Statement.Context)
+}
+
+func scopeTenant(db *gorm.DB) {
+	id, ok := tenantScoped(db)
+	if !ok {
+		return
+	}
+	field := db.Statement.Schema.LookUpField(tenantField)
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: id},
+	}})
+}
+
+func assignTenant(db *gorm.DB) {
+	id, ok := tenantScoped(db)
+	if !ok {
+		return
+	}
+	field := db.Statement.Schema.LookUpField(tenantField)
+	ctx := db.Statement.Context
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if err := field.Set(ctx, reflect.Indirect(rv.Index(i)), id); err != nil {
+				db.AddError(err)
+				return
+			}
+		}
+	case reflect.Struct:
+		if err := field.Set(ctx, rv, id); err != nil {
+			db.AddError(err)
+		}
+	}
+}
+
+// beginTenantTransaction starts a transaction for a query confined to a
+// tenant, so app.tenant_id can be set for it. Queries already running in a
+// transaction keep it.
+func beginTenantTransaction(db *gorm.DB) {
+	if _, ok := tenantScoped(db); ok {
+		gormcallbacks.BeginTransaction(db)
+	}
+}
+
+func setTenantSetting(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	if id, ok := tenantScoped(db); ok {
+		if _, err := db.Statement.ConnPool.ExecContext(db.Statement.Context, "SELECT set_config('app.tenant_id', $1, true)", id.String()); err != nil {
+			db.AddError(err)
+		}
+	}
+}
+
+// PostgresTenantRepository is a TenantRepository backed by GORM.
+type PostgresTenantRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresTenantRepository(db *gorm.DB) *PostgresTenantRepository {
+	return &PostgresTenantRepository{db: db}
+}
+
+func (r *PostgresTenantRepository) Create(ctx context.Context, t *models.Tenant) error {
+	return translate(conn(ctx, r.db).Create(t).Error)
+}
+
+func (r *PostgresTenantRepository) Get(ctx context.Context, id uuid.UUID) (*models.Tenant, error) {
+	var t models.Tenant
+	if err := conn(ctx, r.db).First(&t, "id = ?", id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &t, nil
+}
+
+func (r *PostgresTenantRepository) GetBySlug(ctx context.Context, slug string) (*models.Tenant, error) {
+	var t models.Tenant
+	if err := conn(ctx, r.db).First(&t, "slug = ?", slug).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &t, nil
+}
+
+func (r *PostgresTenantRepository) List(ctx context.Context) ([]models.Tenant, error) {
+	var tenants []models.Tenant
+	err := conn(ctx, r.db).Order("slug").Find(&tenants).Error
+	return tenants, err
+}
+
+// MemoryTenantRepository is an in-memory TenantRepository. It starts with
+// the default tenant, like a migrated database.
+type MemoryTenantRepository struct {
+	mu      sync.Mutex
+	tenants map[uuid.UUID]models.Tenant
+}
+
+func NewMemoryTenantRepository() *MemoryTenantRepository {
+	return &MemoryTenantRepository{tenants: map[uuid.UUID]models.Tenant{
+		models.DefaultTenantID: {ID: models.DefaultTenantID, Slug: "default", Name: "Default"},
+	}}
+}
+
+func (r *MemoryTenantRepository) Create(_ context.Context, t *models.Tenant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	for id, other := range r.tenants {
+		if id == t.ID || other.Slug == t.Slug {
+			return ErrConflict
+		}
+	}
+	r.tenants[t.ID] = *t
+	return nil
+}
+
+func (r *MemoryTenantRepository) Get(_ context.Context, id uuid.UUID) (*models.Tenant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tenants[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &t, nil
+}
+
+func (r *MemoryTenantRepository) GetBySlug(_ context.Context, slug string) (*models.Tenant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, t := range r.tenants {
+		if t.Slug == slug {
+			return &t, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryTenantRepository) List(_ context.Context) ([]models.Tenant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var tenants []models.Tenant
+	for _, t := range r.tenants {
+		tenants = append(tenants, t)
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].Slug < tenants[j].Slug })
+	return tenants, nil
+}
+
+// assignTenantID gives a row created in ctx the tenant of ctx, if any, like
+// the assign callback does for GORM.
+func assignTenantID(ctx context.Context, id *uuid.UUID) {
+	if scope, ok := tenant.FromContext(ctx); ok {
+		*id = scope
+	}
+}
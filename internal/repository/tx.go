@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"service/internal/models"
+	"service/internal/tenant"
+)
+
+// Transactor runs functions in a transaction. Repository calls made with the
+// context passed to fn take part in it. Nested calls run in a savepoint, so a
+// failed inner call can be rolled back without aborting the outer one.
+type Transactor interface {
+	// InTx commits the transaction when fn returns nil and rolls it back
+	// otherwise.
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txKey struct{}
+
+// PostgresTransactor is a Transactor backed by GORM transactions.
+type PostgresTransactor struct {
+	db *gorm.DB
+}
+
+func NewPostgresTransactor(db *gorm.DB) *PostgresTransactor {
+	return &PostgresTransactor{db: db}
+}
+
+// InTx confines the transaction to the tenant of ctx, if any, by setting
+// app.tenant_id for the row-level security policies.
+func (t *PostgresTransactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	_, nested := ctx.Value(txKey{}).(*gorm.DB)
+	return conn(ctx, t.db).Transaction(func(tx *gorm.DB) error {
+		if id, ok := tenant.FromContext(ctx); ok && !nested {
+			if err := tx.Exec("SELECT set_config('app.tenant_id', ?, true)", id.String()).Error; err != nil {
+				return err
+			}
+		}
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn returns the transaction carried by ctx, or db outside of one.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// MemoryTransactor is a Transactor for the in-memory repositories. It
+// restores a snapshot of the subscriptions, their price changes, the outbox
+// and the audit log when fn fails; writes are not isolated from concurrent
+// callers.
+type MemoryTransactor struct {
+	mu     sync.Mutex
+	subs   *MemorySubscriptionRepository
+	prices *MemoryPriceRepository
+	outbox *MemoryOutboxRepository
+	audit  *MemoryAuditRepository
+}
+
+func NewMemoryTransactor(subs *MemorySubscriptionRepository, prices *MemoryPriceRepository, outbox *MemoryOutboxRepository, audit *MemoryAuditRepository) *MemoryTransactor {
+	return &MemoryTransactor{subs: subs, prices: prices, outbox: outbox, audit: audit}
+}
+
+func (t *MemoryTransactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) == nil {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		ctx = context.WithValue(ctx, txKey{}, t)
+	}
+
+	snapshot := t.subs.snapshot()
+	changes := t.prices.snapshot()
+	events := t.outbox.snapshot()
+	entries := t.audit.snapshot()
+	if err := fn(ctx); err != nil {
+		t.subs.restore(snapshot)
+		t.prices.restore(changes)
+		t.outbox.restore(events)
+		t.audit.restore(entries)
+		return err
+	}
+	return nil
+}
+
+func (r *MemorySubscriptionRepository) snapshot() map[uuid.UUID]models.Subscription {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subs := make(map[uuid.UUID]models.Subscription, len(r.subs))
+	for id, sub := range r.subs {
+		subs[id] = copySubscription(sub)
+	}
+	return subs
+}
+
+func (r *MemorySubscriptionRepository) restore(subs map[uuid.UUID]models.Subscription) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subs = subs
+}
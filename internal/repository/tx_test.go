@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"service/internal/models"
+)
+
+func TestMemoryTransactorRollsBack(t *testing.T) {
+	subs := NewMemorySubscriptionRepository()
+	outbox := NewMemoryOutboxRepository()
+	prices := NewMemoryPriceRepository()
+	audit := NewMemoryAuditRepository()
+	tx := NewMemoryTransactor(subs, prices, outbox, audit)
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+
+	create := func(ctx context.Context, name string) {
+		t.Helper()
+		if err := subs.Create(ctx, &models.Subscription{ServiceName: name, UserID: uuid.New(), StartDate: date(2026, 1)}); err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+	}
+	names := func() map[string]bool {
+		t.Helper()
+		list, err := subs.List(ctx, SubscriptionFilter{})
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		got := map[string]bool{}
+		for _, sub := range list {
+			got[sub.ServiceName] = true
+		}
+		return got
+	}
+
+	err := tx.InTx(ctx, func(ctx context.Context) error {
+		create(ctx, "outer")
+		inner := tx.InTx(ctx, func(ctx context.Context) error {
+			create(ctx, "rolled back")
+			return errAbort
+		})
+		if !errors.Is(inner, errAbort) {
+			t.Errorf("inner InTx returned %v, want errAbort", inner)
+		}
+		return tx.InTx(ctx, func(ctx context.Context) error {
+			create(ctx, "inner")
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("InTx: %v", err)
+	}
+	if got := names(); len(got) != 2 || !got["outer"] || !got["inner"] {
+		t.Errorf("committed %v, want outer and inner", got)
+	}
+
+	err = tx.InTx(ctx, func(ctx context.Context) error {
+		create(ctx, "aborted")
+		if err := outbox.Append(ctx, &models.OutboxEvent{EventID: uuid.New(), Type: models.EventSubscriptionCreated}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+		if err := audit.Append(ctx, &models.AuditEntry{Action: models.AuditCreate}); err != nil {
+			t.Fatalf("append audit entry: %v", err)
+		}
+		if err := prices.Create(ctx, &models.PriceChange{ID: uuid.New(), SubscriptionID: uuid.New(), Price: 100}); err != nil {
+			t.Fatalf("create price change: %v", err)
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Errorf("InTx returned %v, want errAbort", err)
+	}
+	if got := names(); got["aborted"] {
+		t.Errorf("aborted transaction left %v", got)
+	}
+	if pending, _ := outbox.Pending(ctx, 10); len(pending) != 0 {
+		t.Errorf("aborted transaction left events %+v", pending)
+	}
+	if entries, _ := audit.List(ctx, AuditFilter{}); len(entries) != 0 {
+		t.Errorf("aborted transaction left audit entries %+v", entries)
+	}
+	if changes := prices.snapshot(); len(changes) != 0 {
+		t.Errorf("aborted transaction left price changes %+v", changes)
+	}
+}
@@ -0,0 +1,343 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"service/internal/models"
+	"service/internal/tenant"
+)
+
+// PostgresOutboxRepository is an OutboxRepository backed by GORM.
+type PostgresOutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresOutboxRepository(db *gorm.DB) *PostgresOutboxRepository {
+	return &PostgresOutboxRepository{db: db}
+}
+
+func (r *PostgresOutboxRepository) Append(ctx context.Context, event *models.OutboxEvent) error {
+	return translate(conn(ctx, r.db).Create(event).Error)
+}
+
+func (r *PostgresOutboxRepository) Pending(ctx context.Context, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := conn(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("dispatched_at IS NULL").
+		Order("id").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+func (r *PostgresOutboxRepository) MarkDispatched(ctx context.Context, ids []uint, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Model(&models.OutboxEvent{}).Where("id IN ?", ids).Update("dispatched_at", at).Error
+}
+
+// PostgresWebhookRepository is a WebhookRepository backed by GORM.
+type PostgresWebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresWebhookRepository(db *gorm.DB) *PostgresWebhookRepository {
+	return &PostgresWebhookRepository{db: db}
+}
+
+func (r *PostgresWebhookRepository) CreateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) error {
+	return translate(conn(ctx, r.db).Create(endpoint).Error)
+}
+
+func (r *PostgresWebhookRepository) GetEndpoint(ctx context.Context, id uuid.UUID) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	if err := conn(ctx, r.db).First(&endpoint, "id = ?", id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &endpoint, nil
+}
+
+func (r *PostgresWebhookRepository) ListEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	err := conn(ctx, r.db).Order("created_at, id").Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (r *PostgresWebhookRepository) DeleteEndpoint(ctx context.Context, id uuid.UUID) error {
+	res := conn(ctx, r.db).Delete(&models.WebhookEndpoint{}, "id = ?", id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresWebhookRepository) CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).CreateInBatches(deliveries, 500).Error
+}
+
+func (r *PostgresWebhookRepository) ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := conn(ctx, r.db).Raw(`
+		UPDATE webhook_deliveries SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, leaseUntil, models.DeliveryPending, now, limit).Scan(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *PostgresWebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return conn(ctx, r.db).Model(delivery).
+		Select("status", "attempts", "next_attempt_at", "last_attempt_at", "response_status", "last_error", "delivered_at").
+		Updates(delivery).Error
+}
+
+func (r *PostgresWebhookRepository) ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]models.WebhookDelivery, error) {
+	query := conn(ctx, r.db).Where("endpoint_id = ?", filter.EndpointID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	var deliveries []models.WebhookDelivery
+	err := query.Order("created_at DESC, id").Find(&deliveries).Error
+	return deliveries, err
+}
+
+// MemoryOutboxRepository is an in-memory OutboxRepository.
+type MemoryOutboxRepository struct {
+	mu     sync.Mutex
+	nextID uint
+	events []models.OutboxEvent
+}
+
+func NewMemoryOutboxRepository() *MemoryOutboxRepository {
+	return &MemoryOutboxRepository{}
+}
+
+func (r *MemoryOutboxRepository) Append(ctx context.Context, event *models.OutboxEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	assignTenantID(ctx, &event.TenantID)
+	if event.Key != nil {
+		for _, e := range r.events {
+			if e.Key != nil && *e.Key == *event.Key {
+				return ErrConflict
+			}
+		}
+	}
+	r.nextID++
+	event.ID = r.nextID
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *MemoryOutboxRepository) Pending(_ context.Context, limit int) ([]models.OutboxEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var events []models.OutboxEvent
+	for _, e := range r.events {
+		if e.DispatchedAt == nil && len(events) < limit {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (r *MemoryOutboxRepository) MarkDispatched(_ context.Context, ids []uint, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	marked := map[uint]bool{}
+	for _, id := range ids {
+		marked[id] = true
+	}
+	for i := range r.events {
+		if marked[r.events[i].ID] {
+			r.events[i].DispatchedAt = &at
+		}
+	}
+	return nil
+}
+
+func (r *MemoryOutboxRepository) snapshot() []models.OutboxEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.OutboxEvent(nil), r.events...)
+}
+
+func (r *MemoryOutboxRepository) restore(events []models.OutboxEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = events
+}
+
+// MemoryWebhookRepository is an in-memory WebhookRepository.
+type MemoryWebhookRepository struct {
+	mu         sync.Mutex
+	endpoints  map[uuid.UUID]models.WebhookEndpoint
+	deliveries []models.WebhookDelivery
+}
+
+func NewMemoryWebhookRepository() *MemoryWebhookRepository {
+	return &MemoryWebhookRepository{endpoints: map[uuid.UUID]models.WebhookEndpoint{}}
+}
+
+func (r *MemoryWebhookRepository) CreateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	assignTenantID(ctx, &endpoint.TenantID)
+	if endpoint.ID == uuid.Nil {
+		endpoint.ID = uuid.New()
+	}
+	if _, ok := r.endpoints[endpoint.ID]; ok {
+		return ErrConflict
+	}
+	stored := *endpoint
+	stored.Events = append(models.EventTypes(nil), endpoint.Events...)
+	r.endpoints[endpoint.ID] = stored
+	return nil
+}
+
+func (r *MemoryWebhookRepository) GetEndpoint(ctx context.Context, id uuid.UUID) (*models.WebhookEndpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	endpoint, ok := r.endpoints[id]
+	if !ok || !tenant.Visible(ctx, endpoint.TenantID) {
+		return nil, ErrNotFound
+	}
+	return &endpoint, nil
+}
+
+func (r *MemoryWebhookRepository) ListEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	endpoints := make([]models.WebhookEndpoint, 0, len(r.endpoints))
+	for _, endpoint := range r.endpoints {
+		if tenant.Visible(ctx, endpoint.TenantID) {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if !endpoints[i].CreatedAt.Equal(endpoints[j].CreatedAt) {
+			return endpoints[i].CreatedAt.Before(endpoints[j].CreatedAt)
+		}
+		return endpoints[i].ID.String() < endpoints[j].ID.String()
+	})
+	return endpoints, nil
+}
+
+func (r *MemoryWebhookRepository) DeleteEndpoint(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if endpoint, ok := r.endpoints[id]; !ok || !tenant.Visible(ctx, endpoint.TenantID) {
+		return ErrNotFound
+	}
+	delete(r.endpoints, id)
+	kept := r.deliveries[:0]
+	for _, d := range r.deliveries {
+		if d.EndpointID != id {
+			kept = append(kept, d)
+		}
+	}
+	r.deliveries = kept
+	return nil
+}
+
+func (r *MemoryWebhookRepository) CreateDeliveries(_ context.Context, deliveries []models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range deliveries {
+		if deliveries[i].ID == uuid.Nil {
+			deliveries[i].ID = uuid.New()
+		}
+		if deliveries[i].Status == "" {
+			deliveries[i].Status = models.DeliveryPending
+		}
+		r.deliveries = append(r.deliveries, deliveries[i])
+	}
+	return nil
+}
+
+func (r *MemoryWebhookRepository) ClaimDeliveries(_ context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []int
+	for i, d := range r.deliveries {
+		if d.Status == models.DeliveryPending && !d.NextAttemptAt.After(now) {
+			due = append(due, i)
+		}
+	}
+	sort.SliceStable(due, func(a, b int) bool {
+		return r.deliveries[due[a]].NextAttemptAt.Before(r.deliveries[due[b]].NextAttemptAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]models.WebhookDelivery, 0, len(due))
+	for _, i := range due {
+		r.deliveries[i].NextAttemptAt = leaseUntil
+		claimed = append(claimed, r.deliveries[i])
+	}
+	return claimed, nil
+}
+
+func (r *MemoryWebhookRepository) UpdateDelivery(_ context.Context, delivery *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.deliveries {
+		if r.deliveries[i].ID == delivery.ID {
+			r.deliveries[i] = *delivery
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *MemoryWebhookRepository) ListDeliveries(_ context.Context, filter DeliveryFilter) ([]models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deliveries []models.WebhookDelivery
+	for i := len(r.deliveries) - 1; i >= 0; i-- {
+		d := r.deliveries[i]
+		if d.EndpointID != filter.EndpointID || (filter.Status != "" && d.Status != filter.Status) {
+			continue
+		}
+		if filter.Limit > 0 && len(deliveries) == filter.Limit {
+			break
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, nil
+}
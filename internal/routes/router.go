@@ -1,15 +1,154 @@
 package routes
 
 import (
+	"log"
+
 	"github.com/gin-gonic/gin"
+	"service/internal/apierror"
+	"service/internal/auth"
+	"service/internal/config"
 	"service/internal/handlers"
+	"service/internal/logger"
+	"service/internal/middleware"
+	"service/internal/models"
+	"service/internal/repository"
 )
 
-func SetupRoutes(r *gin.Engine) {
-    r.POST("/subscriptions", handlers.CreateSubscription)
-    r.GET("/subscriptions/:id", handlers.GetSubscription)
-    r.PUT("/subscriptions/:id", handlers.UpdateSubscription)
-    r.DELETE("/subscriptions/:id", handlers.DeleteSubscription)
-    r.GET("/subscriptions", handlers.ListSubscriptions)
-    r.GET("/subscriptions/summary", handlers.GetSummary)
-}
\ No newline at end of file
+func SetupRoutes(r *gin.Engine, repos repository.Repositories, cfg *config.Config) {
+    r.Use(middleware.RequestID(), middleware.Errors())
+    r.NoRoute(routeNotFound)
+
+    subscriptions := handlers.NewSubscriptionHandler(repos, cfg.RequireIfMatch)
+    exchangeRates := handlers.NewExchangeRateHandler(repos.ExchangeRates)
+    calendars := handlers.NewCalendarHandler(repos.Subscriptions, repos.Prices, repos.Calendars, []byte(cfg.CalendarSecret))
+    webhooks := handlers.NewWebhookHandler(repos.Webhooks, cfg.WebhookAllowHTTP)
+    budgets := handlers.NewBudgetHandler(repos)
+    apiKeys := handlers.NewAPIKeyHandler(repos.APIKeys)
+    tenants := handlers.NewTenantHandler(repos.Tenants)
+    audit := handlers.NewAuditHandler(repos.Audit)
+
+    idempotent := middleware.Idempotency(repos.Idempotency, cfg.IdempotencyTTL)
+    admin := middleware.RequireAdmin()
+    // Global data such as the exchange rates shared by every tenant is only
+    // changed by admins bound to no tenant.
+    unboundAdmin := middleware.RequireUnboundAdmin()
+    readSubscriptions := middleware.RequireScope(models.ScopeSubscriptionsRead)
+    writeSubscriptions := middleware.RequireScope(models.ScopeSubscriptionsWrite)
+    readSummary := middleware.RequireScope(models.ScopeSummaryRead)
+    readBudgets := middleware.RequireScope(models.ScopeBudgetsRead)
+    writeBudgets := middleware.RequireScope(models.ScopeBudgetsWrite)
+    readExchangeRates := middleware.RequireScope(models.ScopeExchangeRatesRead)
+
+    // The calendar feed authenticates with the token in its URL, so calendar
+    // apps can fetch it. Without a secret the tokens could not be verified
+    // across restarts, so the feeds are disabled.
+    calendarEnabled := cfg.CalendarSecret != ""
+    if calendarEnabled {
+        r.GET("/users/:user_id/subscriptions.ics", calendars.GetCalendar)
+    } else {
+        logger.Log.Warn("CALENDAR_SECRET is not set, calendar feeds are disabled")
+    }
+
+    authenticate := middleware.Authenticate(verifier(cfg), repos.APIKeys)
+
+    // Tenants are managed outside of any tenant.
+    tenantAdmin := r.Group("/tenants", authenticate, unboundAdmin)
+    tenantAdmin.POST("", tenants.CreateTenant)
+    tenantAdmin.GET("", tenants.ListTenants)
+    tenantAdmin.GET("/:id", tenants.GetTenant)
+
+    api := r.Group("", authenticate, middleware.Tenant(repos.Tenants))
+
+    // The router reads a colon as the start of a parameter, so custom methods
+    // such as /subscriptions:batch are checked before anything else runs.
+    r.POST("/subscriptions:method", customMethod("batch"), authenticate, middleware.Tenant(repos.Tenants), writeSubscriptions, idempotent, subscriptions.BatchSubscriptions)
+
+    api.POST("/subscriptions", writeSubscriptions, idempotent, subscriptions.CreateSubscription)
+    api.POST("/subscriptions/import", writeSubscriptions, subscriptions.ImportSubscriptions)
+    api.GET("/subscriptions/:id", readSubscriptions, subscriptions.GetSubscription)
+    api.PUT("/subscriptions/:id", writeSubscriptions, subscriptions.UpdateSubscription)
+    api.PATCH("/subscriptions/:id", writeSubscriptions, subscriptions.PatchSubscription)
+    api.DELETE("/subscriptions/:id", writeSubscriptions, subscriptions.DeleteSubscription)
+    api.POST("/subscriptions/:id/restore", writeSubscriptions, subscriptions.RestoreSubscription)
+    api.GET("/subscriptions/:id/history", readSubscriptions, audit.GetSubscriptionHistory)
+    api.GET("/subscriptions/:id/prices", readSubscriptions, subscriptions.ListPriceChanges)
+    api.POST("/subscriptions/:id/prices", writeSubscriptions, subscriptions.CreatePriceChange)
+    api.DELETE("/subscriptions/:id/prices/:price_id", writeSubscriptions, subscriptions.DeletePriceChange)
+    api.GET("/subscriptions", readSubscriptions, subscriptions.ListSubscriptions)
+    api.GET("/subscriptions/trash", readSubscriptions, subscriptions.ListTrash)
+    api.GET("/subscriptions/export", readSubscriptions, subscriptions.ExportSubscriptions)
+    api.GET("/subscriptions/summary", readSummary, subscriptions.GetSummary)
+    api.GET("/subscriptions/summary/breakdown", readSummary, subscriptions.GetSummaryBreakdown)
+    api.POST("/exchange-rates", unboundAdmin, exchangeRates.UpsertExchangeRates)
+    api.GET("/exchange-rates", readExchangeRates, exchangeRates.ListExchangeRates)
+    if calendarEnabled {
+        api.GET("/users/:user_id/calendar", readSubscriptions, calendars.GetCalendarLink)
+        api.POST("/users/:user_id/calendar/rotate", writeSubscriptions, calendars.RotateCalendarLink)
+    }
+    api.GET("/users/:user_id/budget-status", readBudgets, budgets.GetBudgetStatus)
+    api.POST("/budgets", writeBudgets, budgets.CreateBudget)
+    api.GET("/budgets", readBudgets, budgets.ListBudgets)
+    api.GET("/budgets/:id", readBudgets, budgets.GetBudget)
+    api.PUT("/budgets/:id", writeBudgets, budgets.UpdateBudget)
+    api.DELETE("/budgets/:id", writeBudgets, budgets.DeleteBudget)
+    api.POST("/webhooks", admin, webhooks.CreateWebhook)
+    api.GET("/webhooks", admin, webhooks.ListWebhooks)
+    api.GET("/webhooks/:id", admin, webhooks.GetWebhook)
+    api.DELETE("/webhooks/:id", admin, webhooks.DeleteWebhook)
+    api.GET("/webhooks/:id/deliveries", admin, webhooks.ListWebhookDeliveries)
+    api.POST("/api-keys", admin, apiKeys.CreateAPIKey)
+    api.GET("/api-keys", admin, apiKeys.ListAPIKeys)
+    api.GET("/api-keys/:id", admin, apiKeys.GetAPIKey)
+    api.POST("/api-keys/:id/rotate", admin, apiKeys.RotateAPIKey)
+    api.DELETE("/api-keys/:id", admin, apiKeys.RevokeAPIKey)
+    api.GET("/audit", admin, audit.ListAuditEntries)
+}
+
+// routeNotFound answers requests that match no route.
+func routeNotFound(c *gin.Context) {
+    c.Error(apierror.NotFound(apierror.CodeNoRoute, "no route matches "+c.Request.Method+" "+c.Request.URL.Path))
+}
+
+// customMethod lets through the requests of the custom method name, the
+// suffix after the colon of a path like /subscriptions:batch, and answers
+// all others like unknown routes.
+func customMethod(name string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        if c.Param("method") != ":"+name {
+            routeNotFound(c)
+            c.Abort()
+            return
+        }
+        c.Next()
+    }
+}
+
+// verifier builds the verifier of bearer tokens, or returns nil when no keys
+// are configured and only API keys are checked.
+func verifier(cfg *config.Config) *auth.Verifier {
+    if cfg.JWTSecret == "" && cfg.JWTJWKSFile == "" {
+        logger.Log.Warn("JWT_HS256_SECRET and JWT_JWKS_FILE are not set, requests without an API key are not authenticated")
+        return nil
+    }
+    authCfg := auth.Config{
+        HS256Secret: []byte(cfg.JWTSecret),
+        Issuer:      cfg.JWTIssuer,
+        Audience:    cfg.JWTAudience,
+        UserClaim:   cfg.JWTUserClaim,
+        RolesClaim:  cfg.JWTRolesClaim,
+        AdminRole:   cfg.JWTAdminRole,
+        TenantClaim: cfg.JWTTenantClaim,
+    }
+    if cfg.JWTJWKSFile != "" {
+        keys, err := auth.LoadJWKS(cfg.JWTJWKSFile)
+        if err != nil {
+            log.Fatalf("[error] Loading JWT keys failed: %v", err)
+        }
+        authCfg.RSAKeys = keys
+    }
+    v, err := auth.NewVerifier(authCfg)
+    if err != nil {
+        log.Fatalf("[error] Configuring JWT verification failed: %v", err)
+    }
+    return v
+}
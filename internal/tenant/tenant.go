@@ -0,0 +1,34 @@
+// Package tenant carries the organization a request acts for through
+// contexts. Repositories read it to confine every query to the rows of that
+// organization.
+package tenant
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Header selects the tenant of a request where the caller's credentials do
+// not already name one. It holds a tenant ID or slug.
+const Header = "X-Tenant-ID"
+
+type tenantKey struct{}
+
+// NewContext returns a copy of ctx scoped to the tenant id.
+func NewContext(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext returns the tenant ctx is scoped to. Contexts of background
+// jobs carry none and see the rows of every tenant.
+func FromContext(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(tenantKey{}).(uuid.UUID)
+	return id, ok && id != uuid.Nil
+}
+
+// Visible reports whether a row of the tenant id may be seen in ctx.
+func Visible(ctx context.Context, id uuid.UUID) bool {
+	scope, ok := FromContext(ctx)
+	return !ok || scope == id
+}
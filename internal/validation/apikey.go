@@ -0,0 +1,35 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"service/internal/models"
+)
+
+// MaxAPIKeyNameLength is the longest accepted API key name.
+const MaxAPIKeyNameLength = 100
+
+// APIKey checks an API key issued at now.
+func APIKey(key *models.APIKey, now time.Time) Errors {
+	var errs Errors
+
+	if strings.TrimSpace(key.Name) == "" {
+		errs.Add("Name", key.Name, CodeRequired, "name is required")
+	} else if len(key.Name) > MaxAPIKeyNameLength {
+		errs.Add("Name", key.Name, CodeOutOfRange, fmt.Sprintf("name must be at most %d characters", MaxAPIKeyNameLength))
+	}
+	if len(key.Scopes) == 0 {
+		errs.Add("Scopes", key.Scopes, CodeRequired, "at least one scope is required")
+	}
+	for i, scope := range key.Scopes {
+		if !scope.Valid() {
+			errs.Add(fmt.Sprintf("Scopes[%d]", i), scope, CodeUnsupported, "scope must be one of subscriptions:read, subscriptions:write, summary:read, budgets:read, budgets:write, exchange_rates:read")
+		}
+	}
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(now) {
+		errs.Add("ExpiresAt", key.ExpiresAt, CodeInvalidRange, "expiry must be in the future")
+	}
+	return errs
+}
@@ -0,0 +1,30 @@
+package validation
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"service/internal/billing"
+	"service/internal/models"
+)
+
+// Budget checks a budget payload. The currency default must already be
+// applied.
+func Budget(budget *models.Budget) Errors {
+	var errs Errors
+
+	if budget.UserID == uuid.Nil {
+		errs.Add("UserID", budget.UserID, CodeRequired, "user ID is required")
+	}
+	if len(budget.Category) > MaxCategoryLength {
+		errs.Add("Category", budget.Category, CodeOutOfRange, fmt.Sprintf("category must be at most %d characters", MaxCategoryLength))
+	}
+	if budget.Amount <= 0 {
+		errs.Add("Amount", budget.Amount, CodeOutOfRange, "amount must be positive")
+	}
+	if !billing.ValidCurrency(budget.Currency) {
+		errs.Add("Currency", budget.Currency, CodeInvalidFormat, "currency must be an ISO 4217 code")
+	}
+	return errs
+}
@@ -0,0 +1,28 @@
+package validation
+
+import (
+	"service/internal/billing"
+	"service/internal/models"
+)
+
+// ExchangeRate checks an exchange rate payload. Field names are prefixed
+// with prefix, e.g. to point at an item of a list.
+func ExchangeRate(prefix string, rate *models.ExchangeRate) Errors {
+	var errs Errors
+
+	if !billing.ValidCurrency(rate.BaseCurrency) {
+		errs.Add(prefix+"BaseCurrency", rate.BaseCurrency, CodeInvalidFormat, "base currency must be an ISO 4217 code")
+	}
+	if !billing.ValidCurrency(rate.QuoteCurrency) {
+		errs.Add(prefix+"QuoteCurrency", rate.QuoteCurrency, CodeInvalidFormat, "quote currency must be an ISO 4217 code")
+	} else if rate.QuoteCurrency == rate.BaseCurrency {
+		errs.Add(prefix+"QuoteCurrency", rate.QuoteCurrency, CodeInvalid, "quote currency must differ from base currency")
+	}
+	if rate.Rate <= 0 {
+		errs.Add(prefix+"Rate", rate.Rate, CodeOutOfRange, "rate must be positive")
+	}
+	if rate.EffectiveDate.IsZero() {
+		errs.Add(prefix+"EffectiveDate", rate.EffectiveDate, CodeRequired, "effective date is required")
+	}
+	return errs
+}
@@ -0,0 +1,63 @@
+package validation
+
+import (
+	"fmt"
+	"time"
+
+	"service/internal/billing"
+	"service/internal/models"
+)
+
+// PriceChange checks a price change of sub. It must take effect after the
+// start month, where the subscription's own price applies, and no later than
+// the end month.
+func PriceChange(change *models.PriceChange, sub *models.Subscription) Errors {
+	var errs Errors
+
+	if change.Price < 0 {
+		errs.Add("Price", change.Price, CodeOutOfRange, "price must not be negative")
+	}
+	switch {
+	case change.EffectiveFrom.IsZero():
+		errs.Add("EffectiveFrom", change.EffectiveFrom, CodeRequired, "effective month is required")
+	case sub.BillingInterval == models.IntervalOnce:
+		errs.Add("EffectiveFrom", change.EffectiveFrom, CodeUnsupported, "one-off subscriptions are billed once and cannot change price")
+	case !change.EffectiveFrom.After(sub.StartDate.Time):
+		errs.Add("EffectiveFrom", change.EffectiveFrom, CodeBeforeStart, "effective month must be after the start date; change the subscription's price instead")
+	case sub.EndDate != nil && change.EffectiveFrom.After(sub.EndDate.Time):
+		errs.Add("EffectiveFrom", change.EffectiveFrom, CodeInvalidRange, "effective month must not be after the end date")
+	}
+	return errs
+}
+
+// PriceHistory checks an update from stored to sub against the price changes
+// of the subscription. Once a subscription was billed before month, its price
+// is that of the billed months and only changes through price changes. The
+// new dates and interval must keep every price change valid.
+func PriceHistory(sub, stored *models.Subscription, changes []models.PriceChange, month time.Time) Errors {
+	var errs Errors
+
+	if sub.Price != stored.Price && stored.StartDate.Before(billing.MonthStart(month)) {
+		errs.Add("Price", sub.Price, CodeImmutable, fmt.Sprintf("the subscription was already billed at this price; schedule a price change with POST /subscriptions/%s/prices instead", sub.ID))
+	}
+	for i := range changes {
+		// The errors on the effective month point at the subscription field
+		// it clashes with.
+		for _, e := range PriceChange(&changes[i], sub) {
+			var field string
+			var value any
+			switch e.Code {
+			case CodeUnsupported:
+				field, value = "BillingInterval", sub.BillingInterval
+			case CodeBeforeStart:
+				field, value = "StartDate", sub.StartDate
+			case CodeInvalidRange:
+				field, value = "EndDate", sub.EndDate
+			default:
+				continue
+			}
+			errs.Add(field, value, e.Code, fmt.Sprintf("conflicts with the price change effective %s; cancel it first", changes[i].EffectiveFrom))
+		}
+	}
+	return errs
+}
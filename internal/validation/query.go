@@ -0,0 +1,138 @@
+package validation
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service/internal/billing"
+)
+
+// Query reads typed query parameters, collecting an error for every
+// parameter that cannot be parsed instead of stopping at the first one.
+type Query struct {
+	values url.Values
+	Errors Errors
+}
+
+func NewQuery(values url.Values) *Query {
+	return &Query{values: values}
+}
+
+// String returns the raw value of the parameter.
+func (q *Query) String(name string) string {
+	return q.values.Get(name)
+}
+
+// List returns every value of a repeated parameter, also splitting each
+// value on commas. Empty items are dropped.
+func (q *Query) List(name string) []string {
+	var items []string
+	for _, raw := range q.values[name] {
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// UUID parses an optional UUID parameter, returning uuid.Nil when it is absent.
+func (q *Query) UUID(name string) uuid.UUID {
+	raw := q.values.Get(name)
+	if raw == "" {
+		return uuid.Nil
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		q.Errors.Add(name, raw, CodeInvalidFormat, "must be a UUID")
+	}
+	return id
+}
+
+// Int parses an optional integer parameter within min..max.
+func (q *Query) Int(name string, min, max int) *int {
+	raw := q.values.Get(name)
+	if raw == "" {
+		return nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		q.Errors.Add(name, raw, CodeInvalidType, "must be an integer")
+		return nil
+	}
+	if v < min || v > max {
+		q.Errors.Add(name, raw, CodeOutOfRange, fmt.Sprintf("must be between %d and %d", min, max))
+		return nil
+	}
+	return &v
+}
+
+// Bool parses an optional boolean parameter.
+func (q *Query) Bool(name string) *bool {
+	raw := q.values.Get(name)
+	if raw == "" {
+		return nil
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		q.Errors.Add(name, raw, CodeInvalidType, "must be a boolean")
+		return nil
+	}
+	return &v
+}
+
+// Date parses an optional YYYY-MM-DD date or RFC 3339 timestamp, returning
+// the zero time when it is absent.
+func (q *Query) Date(name string) time.Time {
+	raw := q.values.Get(name)
+	if raw == "" {
+		return time.Time{}
+	}
+	if t, err := time.Parse(time.DateOnly, raw); err == nil {
+		return t
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		q.Errors.Add(name, raw, CodeInvalidFormat, "must be a YYYY-MM-DD date or an RFC 3339 timestamp")
+	}
+	return t
+}
+
+// Month parses an optional YYYY-MM month, returning the zero time when it is
+// absent.
+func (q *Query) Month(name string) time.Time {
+	raw := q.values.Get(name)
+	if raw == "" {
+		return time.Time{}
+	}
+	t, err := billing.ParseMonth(raw)
+	if err != nil {
+		q.Errors.Add(name, raw, CodeInvalidFormat, "must be a YYYY-MM month")
+	}
+	return t
+}
+
+// Currency parses an optional ISO 4217 currency code, returning def when it
+// is absent.
+func (q *Query) Currency(name, def string) string {
+	raw := q.values.Get(name)
+	if raw == "" {
+		return def
+	}
+	code := strings.ToUpper(raw)
+	if !billing.ValidCurrency(code) {
+		q.Errors.Add(name, raw, CodeInvalidFormat, "must be an ISO 4217 currency code")
+	}
+	return code
+}
+
+// Fail records an error for a parameter that parsed but is otherwise invalid.
+func (q *Query) Fail(name string, value any, code, message string) {
+	q.Errors.Add(name, value, code, message)
+}
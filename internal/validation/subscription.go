@@ -0,0 +1,49 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"service/internal/billing"
+	"service/internal/models"
+)
+
+// MaxCategoryLength is the longest accepted subscription or budget category.
+const MaxCategoryLength = 64
+
+// Subscription checks a subscription payload. Defaults such as the currency
+// and billing period must already be applied.
+func Subscription(sub *models.Subscription) Errors {
+	var errs Errors
+
+	if strings.TrimSpace(sub.ServiceName) == "" {
+		errs.Add("ServiceName", sub.ServiceName, CodeRequired, "service name is required")
+	}
+	if len(sub.Category) > MaxCategoryLength {
+		errs.Add("Category", sub.Category, CodeOutOfRange, fmt.Sprintf("category must be at most %d characters", MaxCategoryLength))
+	}
+	if sub.Price < 0 {
+		errs.Add("Price", sub.Price, CodeOutOfRange, "price must not be negative")
+	}
+	if !billing.ValidCurrency(sub.Currency) {
+		errs.Add("Currency", sub.Currency, CodeInvalidFormat, "currency must be an ISO 4217 code")
+	}
+	if !sub.BillingInterval.Valid() {
+		errs.Add("BillingInterval", sub.BillingInterval, CodeUnsupported, "billing interval must be one of week, month, quarter, year, once")
+	}
+	if sub.IntervalCount < 1 {
+		errs.Add("IntervalCount", sub.IntervalCount, CodeOutOfRange, "interval count must be at least 1")
+	}
+	if sub.UserID == uuid.Nil {
+		errs.Add("UserID", sub.UserID, CodeRequired, "user ID is required")
+	}
+	if sub.StartDate.IsZero() {
+		errs.Add("StartDate", sub.StartDate, CodeRequired, "start date is required")
+	}
+	if sub.EndDate != nil && !sub.StartDate.IsZero() && sub.EndDate.Before(sub.StartDate.Time) {
+		errs.Add("EndDate", sub.EndDate, CodeBeforeStart, "end date must not be before start date")
+	}
+	return errs
+}
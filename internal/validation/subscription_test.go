@@ -0,0 +1,106 @@
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service/internal/models"
+)
+
+func codes(errs Errors) map[string]string {
+	got := map[string]string{}
+	for _, fe := range errs {
+		got[fe.Field] = fe.Code
+	}
+	return got
+}
+
+func TestSubscriptionReportsEveryField(t *testing.T) {
+	start := models.NewMonth(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	end := models.NewMonth(start.AddDate(0, -1, 0))
+	sub := &models.Subscription{
+		ServiceName:     "  ",
+		Price:           -1,
+		Currency:        "rubles",
+		BillingInterval: "fortnight",
+		StartDate:       start,
+		EndDate:         &end,
+	}
+	want := map[string]string{
+		"ServiceName":     CodeRequired,
+		"Price":           CodeOutOfRange,
+		"Currency":        CodeInvalidFormat,
+		"BillingInterval": CodeUnsupported,
+		"IntervalCount":   CodeOutOfRange,
+		"UserID":          CodeRequired,
+		"EndDate":         CodeBeforeStart,
+	}
+	got := codes(Subscription(sub))
+	if len(got) != len(want) {
+		t.Errorf("got errors %v, want %v", got, want)
+	}
+	for field, code := range want {
+		if got[field] != code {
+			t.Errorf("%s: code %q, want %q", field, got[field], code)
+		}
+	}
+
+	valid := &models.Subscription{
+		ServiceName:     "Netflix",
+		Currency:        "RUB",
+		BillingInterval: models.IntervalMonth,
+		IntervalCount:   1,
+		UserID:          uuid.New(),
+		StartDate:       start,
+	}
+	if errs := Subscription(valid); errs.Err() != nil {
+		t.Errorf("valid subscription rejected: %v", errs)
+	}
+}
+
+func TestQueryCollectsEveryError(t *testing.T) {
+	q := NewQuery(map[string][]string{
+		"user_id":  {"nope"},
+		"limit":    {"1000"},
+		"min":      {"ten"},
+		"done":     {"maybe"},
+		"on":       {"2026-13-01"},
+		"month":    {"2026-1"},
+		"currency": {"rubles"},
+		"names":    {"a, b", ",c"},
+	})
+	q.UUID("user_id")
+	q.Int("limit", 1, 500)
+	q.Int("min", 0, 10)
+	q.Bool("done")
+	q.Date("on")
+	q.Month("month")
+	q.Currency("currency", "RUB")
+	if names := q.List("names"); len(names) != 3 || names[0] != "a" || names[1] != "b" || names[2] != "c" {
+		t.Errorf("List = %q, want [a b c]", names)
+	}
+	if got := q.Currency("missing", "RUB"); got != "RUB" {
+		t.Errorf("Currency default = %q, want RUB", got)
+	}
+
+	want := map[string]string{
+		"user_id":  CodeInvalidFormat,
+		"limit":    CodeOutOfRange,
+		"min":      CodeInvalidType,
+		"done":     CodeInvalidType,
+		"on":       CodeInvalidFormat,
+		"month":    CodeInvalidFormat,
+		"currency": CodeInvalidFormat,
+	}
+	got := codes(q.Errors)
+	if len(got) != len(want) {
+		t.Errorf("got errors %v, want %v", got, want)
+	}
+	for field, code := range want {
+		if got[field] != code {
+			t.Errorf("%s: code %q, want %q", field, got[field], code)
+		}
+	}
+}
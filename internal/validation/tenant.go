@@ -0,0 +1,27 @@
+package validation
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"service/internal/models"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,62}$`)
+
+// Tenant checks a tenant payload.
+func Tenant(t *models.Tenant) Errors {
+	var errs Errors
+
+	if !slugPattern.MatchString(t.Slug) {
+		errs.Add("Slug", t.Slug, CodeInvalidFormat, "slug must be 2-63 lower-case letters, digits and hyphens, starting with a letter or digit")
+	} else if _, err := uuid.Parse(t.Slug); err == nil {
+		errs.Add("Slug", t.Slug, CodeInvalidFormat, "slug must not be a UUID")
+	}
+	if strings.TrimSpace(t.Name) == "" {
+		errs.Add("Name", t.Name, CodeRequired, "name is required")
+	}
+	return errs
+}
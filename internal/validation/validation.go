@@ -0,0 +1,53 @@
+// Package validation checks request payloads and query parameters and
+// reports every offending field at once.
+package validation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Machine-readable error codes reported in FieldError.Code.
+const (
+	CodeRequired      = "required"
+	CodeInvalid       = "invalid"
+	CodeInvalidType   = "invalid_type"
+	CodeInvalidFormat = "invalid_format"
+	CodeOutOfRange    = "out_of_range"
+	CodeUnsupported   = "unsupported_value"
+	CodeBeforeStart   = "before_start"
+	CodeInvalidRange  = "invalid_range"
+	CodeImmutable     = "immutable"
+)
+
+// FieldError describes why a single field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Value   any    `json:"value"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors is a list of field errors. A nil or empty list means the input is valid.
+type Errors []FieldError
+
+// Add records an error for the field.
+func (e *Errors) Add(field string, value any, code, message string) {
+	*e = append(*e, FieldError{Field: field, Value: value, Code: code, Message: message})
+}
+
+// Err returns the errors as an error, or nil when there are none.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fmt.Sprintf("%s: %s", fe.Field, fe.Message)
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
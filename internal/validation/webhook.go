@@ -0,0 +1,41 @@
+package validation
+
+import (
+	"fmt"
+	"net/url"
+
+	"service/internal/models"
+)
+
+// MinWebhookSecretLength is the shortest accepted webhook signing secret.
+const MinWebhookSecretLength = 16
+
+// WebhookEndpoint checks a webhook endpoint registration. Its URL must use
+// https unless allowHTTP is set.
+func WebhookEndpoint(endpoint *models.WebhookEndpoint, allowHTTP bool) Errors {
+	var errs Errors
+
+	if endpoint.URL == "" {
+		errs.Add("URL", endpoint.URL, CodeRequired, "URL is required")
+	} else if u, err := url.Parse(endpoint.URL); err != nil || u.Host == "" || (u.Scheme != "https" && !(allowHTTP && u.Scheme == "http")) {
+		if allowHTTP {
+			errs.Add("URL", endpoint.URL, CodeInvalidFormat, "URL must be an absolute http or https URL")
+		} else {
+			errs.Add("URL", endpoint.URL, CodeInvalidFormat, "URL must be an absolute https URL")
+		}
+	}
+	if endpoint.Secret == "" {
+		errs.Add("Secret", nil, CodeRequired, "secret is required")
+	} else if len(endpoint.Secret) < MinWebhookSecretLength {
+		errs.Add("Secret", nil, CodeOutOfRange, fmt.Sprintf("secret must be at least %d characters", MinWebhookSecretLength))
+	}
+	if len(endpoint.Events) == 0 {
+		errs.Add("Events", endpoint.Events, CodeRequired, "at least one event type is required")
+	}
+	for i, event := range endpoint.Events {
+		if !event.Valid() {
+			errs.Add(fmt.Sprintf("Events[%d]", i), event, CodeUnsupported, "event type must be one of subscription.created, subscription.updated, subscription.deleted, subscription.restored, subscription.expired")
+		}
+	}
+	return errs
+}
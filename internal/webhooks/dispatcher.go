@@ -0,0 +1,287 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"service/internal/billing"
+	"service/internal/logger"
+	"service/internal/models"
+	"service/internal/repository"
+)
+
+const (
+	// maxAttempts is how often a delivery is tried before it is marked
+	// failed.
+	maxAttempts = 10
+	// retryBase is the delay before the second attempt; it doubles with
+	// every further attempt up to retryMax.
+	retryBase = 30 * time.Second
+	retryMax  = 6 * time.Hour
+	// deliveryLease keeps a claimed delivery from being sent by another
+	// dispatcher; it must exceed the request timeout.
+	deliveryLease  = time.Minute
+	requestTimeout = 10 * time.Second
+	batchSize      = 100
+	maxErrorLength = 500
+)
+
+// Dispatcher turns outbox events into deliveries and sends them.
+type Dispatcher struct {
+	tx       repository.Transactor
+	subs     repository.SubscriptionRepository
+	outbox   repository.OutboxRepository
+	webhooks repository.WebhookRepository
+	client   *http.Client
+}
+
+func NewDispatcher(repos repository.Repositories) *Dispatcher {
+	return &Dispatcher{
+		tx:       repos.Tx,
+		subs:     repos.Subscriptions,
+		outbox:   repos.Outbox,
+		webhooks: repos.Webhooks,
+		client:   &http.Client{Timeout: requestTimeout, Transport: publicTransport()},
+	}
+}
+
+// errInternalAddress rejects connections to addresses that are not public.
+var errInternalAddress = errors.New("webhook endpoints must resolve to a public address")
+
+// publicTransport returns a transport that only connects to public
+// addresses. The check runs on the resolved address of every connection,
+// redirects included, so a host name that resolves to a private, loopback or
+// link-local address is refused too. Proxies are not used, as they would
+// connect on the dispatcher's behalf.
+func publicTransport() *http.Transport {
+	dialer := &net.Dialer{Timeout: requestTimeout, Control: dialPublic}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// dialPublic is a net.Dialer Control func that refuses connections to
+// addresses that are not public.
+func dialPublic(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !public(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", errInternalAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// public reports whether addr is a global unicast address outside of the
+// private ranges.
+func public(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate()
+}
+
+// Run emits the expiry events due at now, fans the pending events out to the
+// subscribed endpoints and sends the deliveries that are due. It returns how
+// many deliveries succeeded; failed ones are scheduled for a retry.
+func (d *Dispatcher) Run(ctx context.Context, now time.Time) (int, error) {
+	if err := d.expire(ctx, now); err != nil {
+		return 0, fmt.Errorf("emit expiry events: %w", err)
+	}
+	if err := d.fanOut(ctx, now); err != nil {
+		return 0, fmt.Errorf("fan out events: %w", err)
+	}
+	return d.deliver(ctx, now)
+}
+
+// expire emits subscription.expired for the subscriptions whose end month
+// was the previous one. The event key makes it happen once per subscription
+// and end month however often this runs.
+func (d *Dispatcher) expire(ctx context.Context, now time.Time) error {
+	thisMonth := billing.MonthStart(now.UTC())
+	subs, err := d.subs.List(ctx, repository.SubscriptionFilter{
+		EndsOnOrAfter: thisMonth.AddDate(0, -1, 0),
+		EndsBefore:    thisMonth,
+	})
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		event, err := NewEvent(models.EventSubscriptionExpired, sub, now)
+		if err != nil {
+			return err
+		}
+		key := fmt.Sprintf("%s:%s:%s", models.EventSubscriptionExpired, sub.ID, sub.EndDate.Format(models.MonthLayoutISO))
+		event.Key = &key
+		if err := d.outbox.Append(ctx, event); err != nil && !errors.Is(err, repository.ErrConflict) {
+			return err
+		}
+	}
+	return nil
+}
+
+// fanOut creates a delivery of every pending event for every endpoint
+// subscribed to its type, a batch of events per transaction.
+func (d *Dispatcher) fanOut(ctx context.Context, now time.Time) error {
+	for {
+		var handled int
+		err := d.tx.InTx(ctx, func(ctx context.Context) error {
+			events, err := d.outbox.Pending(ctx, batchSize)
+			if err != nil || len(events) == 0 {
+				return err
+			}
+			endpoints, err := d.webhooks.ListEndpoints(ctx)
+			if err != nil {
+				return err
+			}
+
+			var deliveries []models.WebhookDelivery
+			ids := make([]uint, len(events))
+			for i, event := range events {
+				ids[i] = event.ID
+				for _, endpoint := range endpoints {
+					if endpoint.TenantID != event.TenantID || !endpoint.Events.Contains(event.Type) {
+						continue
+					}
+					deliveries = append(deliveries, models.WebhookDelivery{
+						ID:            uuid.New(),
+						EndpointID:    endpoint.ID,
+						EventID:       event.EventID,
+						EventType:     event.Type,
+						Payload:       event.Payload,
+						Status:        models.DeliveryPending,
+						NextAttemptAt: now,
+						CreatedAt:     now,
+					})
+				}
+			}
+			if err := d.webhooks.CreateDeliveries(ctx, deliveries); err != nil {
+				return err
+			}
+			handled = len(events)
+			return d.outbox.MarkDispatched(ctx, ids, now)
+		})
+		if err != nil || handled < batchSize {
+			return err
+		}
+	}
+}
+
+// deliver sends the deliveries due at now.
+func (d *Dispatcher) deliver(ctx context.Context, now time.Time) (int, error) {
+	endpoints := map[uuid.UUID]*models.WebhookEndpoint{}
+	succeeded := 0
+	for {
+		deliveries, err := d.webhooks.ClaimDeliveries(ctx, now, now.Add(deliveryLease), batchSize)
+		if err != nil {
+			return succeeded, fmt.Errorf("claim deliveries: %w", err)
+		}
+		for i := range deliveries {
+			delivery := &deliveries[i]
+			endpoint, ok := endpoints[delivery.EndpointID]
+			if !ok {
+				endpoint, err = d.webhooks.GetEndpoint(ctx, delivery.EndpointID)
+				if errors.Is(err, repository.ErrNotFound) {
+					// Deleted since the delivery was claimed.
+					continue
+				}
+				if err != nil {
+					return succeeded, fmt.Errorf("get endpoint %s: %w", delivery.EndpointID, err)
+				}
+				endpoints[delivery.EndpointID] = endpoint
+			}
+
+			d.attempt(ctx, endpoint, delivery)
+			if delivery.Status == models.DeliverySucceeded {
+				succeeded++
+			}
+			if err := d.webhooks.UpdateDelivery(context.WithoutCancel(ctx), delivery); err != nil {
+				return succeeded, fmt.Errorf("update delivery %s: %w", delivery.ID, err)
+			}
+		}
+		if len(deliveries) < batchSize {
+			return succeeded, nil
+		}
+	}
+}
+
+// attempt sends a delivery once and records the outcome in it.
+func (d *Dispatcher) attempt(ctx context.Context, endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) {
+	status, err := d.send(ctx, endpoint, delivery)
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = status
+	if err == nil {
+		delivery.Status = models.DeliverySucceeded
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if len(delivery.LastError) > maxErrorLength {
+		delivery.LastError = delivery.LastError[:maxErrorLength]
+	}
+	if delivery.Attempts >= maxAttempts {
+		delivery.Status = models.DeliveryFailed
+	} else {
+		delivery.NextAttemptAt = now.Add(backoff(delivery.Attempts))
+	}
+	logger.Log.Warn("Webhook delivery failed",
+		zap.String("delivery_id", delivery.ID.String()),
+		zap.String("endpoint_id", endpoint.ID.String()),
+		zap.String("event", string(delivery.EventType)),
+		zap.Int("attempts", delivery.Attempts),
+		zap.String("status", string(delivery.Status)),
+		zap.Error(err),
+	)
+}
+
+func (d *Dispatcher) send(ctx context.Context, endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(delivery.EventType))
+	req.Header.Set(DeliveryHeader, delivery.ID.String())
+	req.Header.Set(SignatureHeader, Sign(endpoint.Secret, time.Now(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff is the delay after the given number of failed attempts.
+func backoff(attempts int) time.Duration {
+	delay := retryBase
+	for i := 1; i < attempts && delay < retryMax; i++ {
+		delay *= 2
+	}
+	if delay > retryMax {
+		delay = retryMax
+	}
+	return delay
+}
@@ -0,0 +1,256 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"service/internal/logger"
+	"service/internal/models"
+	"service/internal/repository"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+const secret = "0123456789abcdef"
+
+// receiver is a webhook endpoint that checks signatures and answers with a
+// configurable status.
+type receiver struct {
+	t      *testing.T
+	mu     sync.Mutex
+	status int
+	events []Event
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var timestamp int64
+	for _, part := range strings.Split(r.Header.Get(SignatureHeader), ",") {
+		if v, ok := strings.CutPrefix(part, "t="); ok {
+			timestamp, _ = strconv.ParseInt(v, 10, 64)
+		}
+	}
+	if want := Sign(secret, time.Unix(timestamp, 0), body); r.Header.Get(SignatureHeader) != want {
+		rc.t.Errorf("signature %q, want %q", r.Header.Get(SignatureHeader), want)
+	}
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		rc.t.Errorf("decode %s: %v", body, err)
+	}
+	if r.Header.Get(EventHeader) != string(event.Type) || r.Header.Get(DeliveryHeader) == "" {
+		rc.t.Errorf("headers %v for %s", r.Header, event.Type)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.events = append(rc.events, event)
+	w.WriteHeader(rc.status)
+}
+
+func setup(t *testing.T, events ...models.EventType) (repository.Repositories, *receiver, *models.WebhookEndpoint) {
+	t.Helper()
+	rc := &receiver{t: t, status: http.StatusOK}
+	srv := httptest.NewServer(rc)
+	t.Cleanup(srv.Close)
+
+	repos := repository.NewMemory()
+	endpoint := &models.WebhookEndpoint{URL: srv.URL, Secret: secret, Events: events, CreatedAt: time.Now()}
+	if err := repos.Webhooks.CreateEndpoint(context.Background(), endpoint); err != nil {
+		t.Fatalf("create endpoint: %v", err)
+	}
+	return repos, rc, endpoint
+}
+
+// loopbackDispatcher returns a dispatcher that may reach the receivers of
+// the tests, which listen on the loopback address.
+func loopbackDispatcher(repos repository.Repositories) *Dispatcher {
+	d := NewDispatcher(repos)
+	d.client = &http.Client{Timeout: requestTimeout}
+	return d
+}
+
+func emit(t *testing.T, repos repository.Repositories, eventType models.EventType, sub models.Subscription) {
+	t.Helper()
+	event, err := NewEvent(eventType, sub, time.Now())
+	if err != nil {
+		t.Fatalf("NewEvent: %v", err)
+	}
+	if err := repos.Outbox.Append(context.Background(), event); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+}
+
+func TestDispatcherDeliversSubscribedEvents(t *testing.T) {
+	ctx := context.Background()
+	repos, rc, endpoint := setup(t, models.EventSubscriptionCreated)
+	sub := models.Subscription{ID: uuid.New(), ServiceName: "Netflix"}
+	emit(t, repos, models.EventSubscriptionCreated, sub)
+	emit(t, repos, models.EventSubscriptionDeleted, sub)
+
+	d := loopbackDispatcher(repos)
+	if sent, err := d.Run(ctx, time.Now()); sent != 1 || err != nil {
+		t.Fatalf("Run sent %d: %v", sent, err)
+	}
+	if len(rc.events) != 1 || rc.events[0].Type != models.EventSubscriptionCreated || rc.events[0].Data.Subscription.ID != sub.ID {
+		t.Errorf("received %+v", rc.events)
+	}
+	if pending, _ := repos.Outbox.Pending(ctx, 10); len(pending) != 0 {
+		t.Errorf("events left in the outbox: %+v", pending)
+	}
+	deliveries, _ := repos.Webhooks.ListDeliveries(ctx, repository.DeliveryFilter{EndpointID: endpoint.ID})
+	if len(deliveries) != 1 || deliveries[0].Status != models.DeliverySucceeded || deliveries[0].ResponseStatus != http.StatusOK {
+		t.Errorf("deliveries %+v", deliveries)
+	}
+
+	if sent, err := d.Run(ctx, time.Now()); sent != 0 || err != nil || len(rc.events) != 1 {
+		t.Errorf("second run sent %d (%v), received %d", sent, err, len(rc.events))
+	}
+}
+
+func TestDispatcherRetriesFailedDeliveries(t *testing.T) {
+	ctx := context.Background()
+	repos, rc, endpoint := setup(t, models.EventTypesAll...)
+	rc.status = http.StatusServiceUnavailable
+	emit(t, repos, models.EventSubscriptionUpdated, models.Subscription{ID: uuid.New()})
+
+	d := loopbackDispatcher(repos)
+	now := time.Now()
+	if sent, err := d.Run(ctx, now); sent != 0 || err != nil {
+		t.Fatalf("Run sent %d: %v", sent, err)
+	}
+	deliveries, _ := repos.Webhooks.ListDeliveries(ctx, repository.DeliveryFilter{EndpointID: endpoint.ID})
+	delivery := deliveries[0]
+	if delivery.Status != models.DeliveryPending || delivery.Attempts != 1 || delivery.ResponseStatus != http.StatusServiceUnavailable || delivery.LastError == "" {
+		t.Fatalf("after a failure %+v", delivery)
+	}
+	if wait := delivery.NextAttemptAt.Sub(*delivery.LastAttemptAt); wait != retryBase {
+		t.Errorf("retry scheduled after %s, want %s", wait, retryBase)
+	}
+
+	if d.Run(ctx, now); len(rc.events) != 1 {
+		t.Errorf("retried before the backoff elapsed")
+	}
+	rc.status = http.StatusNoContent
+	if sent, err := d.Run(ctx, now.Add(time.Minute)); sent != 1 || err != nil {
+		t.Errorf("retry sent %d: %v", sent, err)
+	}
+	deliveries, _ = repos.Webhooks.ListDeliveries(ctx, repository.DeliveryFilter{EndpointID: endpoint.ID, Status: models.DeliverySucceeded})
+	if len(deliveries) != 1 || deliveries[0].Attempts != 2 || deliveries[0].LastError != "" {
+		t.Errorf("after the retry %+v", deliveries)
+	}
+}
+
+func TestDispatcherGivesUpAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	repos, rc, endpoint := setup(t, models.EventSubscriptionCreated)
+	rc.status = http.StatusInternalServerError
+	emit(t, repos, models.EventSubscriptionCreated, models.Subscription{ID: uuid.New()})
+
+	d := loopbackDispatcher(repos)
+	for i := 0; i < maxAttempts+2; i++ {
+		d.Run(ctx, time.Now().Add(time.Duration(i)*retryMax))
+	}
+	deliveries, _ := repos.Webhooks.ListDeliveries(ctx, repository.DeliveryFilter{EndpointID: endpoint.ID})
+	if len(rc.events) != maxAttempts || deliveries[0].Status != models.DeliveryFailed || deliveries[0].Attempts != maxAttempts {
+		t.Errorf("%d requests, delivery %+v", len(rc.events), deliveries[0])
+	}
+}
+
+func TestDispatcherEmitsExpiryOnce(t *testing.T) {
+	ctx := context.Background()
+	repos, rc, _ := setup(t, models.EventSubscriptionExpired)
+	now := time.Date(2025, time.October, 3, 0, 0, 0, 0, time.UTC)
+	ended := models.NewMonth(time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC))
+	running := models.NewMonth(time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC))
+	for _, end := range []models.Month{ended, running} {
+		end := end
+		sub := &models.Subscription{ServiceName: "Netflix", UserID: uuid.New(), StartDate: ended, EndDate: &end}
+		if err := repos.Subscriptions.Create(ctx, sub); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	d := loopbackDispatcher(repos)
+	d.Run(ctx, now)
+	d.Run(ctx, now.Add(time.Hour))
+	if len(rc.events) != 1 || rc.events[0].Data.Subscription.EndDate.Time != ended.Time {
+		t.Errorf("received %+v, want one expiry of the September subscription", rc.events)
+	}
+}
+
+func TestDispatcherRefusesInternalAddresses(t *testing.T) {
+	ctx := context.Background()
+	repos, rc, endpoint := setup(t, models.EventSubscriptionCreated)
+	emit(t, repos, models.EventSubscriptionCreated, models.Subscription{ID: uuid.New()})
+
+	if sent, err := NewDispatcher(repos).Run(ctx, time.Now()); sent != 0 || err != nil {
+		t.Fatalf("Run sent %d: %v", sent, err)
+	}
+	deliveries, _ := repos.Webhooks.ListDeliveries(ctx, repository.DeliveryFilter{EndpointID: endpoint.ID})
+	if len(rc.events) != 0 || deliveries[0].Status != models.DeliveryPending || !strings.Contains(deliveries[0].LastError, errInternalAddress.Error()) {
+		t.Errorf("%d requests, delivery %+v", len(rc.events), deliveries[0])
+	}
+}
+
+func TestPublic(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.216.34":        true,
+		"2606:2800:220:1::1":   true,
+		"127.0.0.1":            false,
+		"::1":                  false,
+		"10.0.0.8":             false,
+		"172.16.4.1":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"fe80::1":              false,
+		"fd00::1":              false,
+		"0.0.0.0":              false,
+		"::ffff:127.0.0.1":     false,
+		"::ffff:93.184.216.34": true,
+	} {
+		if got := public(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("public(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{
+		1:  retryBase,
+		2:  2 * retryBase,
+		4:  8 * retryBase,
+		30: retryMax,
+	} {
+		if got := backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	got := Sign("secret", at, []byte(`{"id":1}`))
+	// echo -n '1700000000.{"id":1}' | openssl dgst -sha256 -hmac secret
+	if want := "t=1700000000,v1=3dd1b9aef568d75f6790a84bd2e5dfa1f44409eef3cbdbd3f10b837376100c11"; got != want {
+		t.Fatalf("Sign = %q, want %q", got, want)
+	}
+	if got == Sign("other", at, []byte(`{"id":1}`)) || got == Sign("secret", at, []byte(`{"id":2}`)) {
+		t.Error("signature ignores the secret or the body")
+	}
+}
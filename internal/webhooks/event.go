@@ -0,0 +1,52 @@
+// Package webhooks delivers subscription lifecycle events to the registered
+// webhook endpoints.
+//
+// Events are appended to an outbox in the transaction of the change they
+// describe, so an event is emitted if and only if the change is committed.
+// The Dispatcher then fans the outbox out into one delivery per subscribed
+// endpoint of the tenant owning the subscription and sends the deliveries,
+// signed and retried with exponential backoff.
+package webhooks
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service/internal/models"
+)
+
+// Event is the JSON body of a delivery.
+type Event struct {
+	ID        uuid.UUID        `json:"id"`
+	Type      models.EventType `json:"type"`
+	CreatedAt time.Time        `json:"created_at"`
+	Data      EventData        `json:"data"`
+}
+
+// EventData is the object an event is about.
+type EventData struct {
+	Subscription models.Subscription `json:"subscription"`
+}
+
+// NewEvent returns the outbox record of an event about sub.
+func NewEvent(eventType models.EventType, sub models.Subscription, now time.Time) (*models.OutboxEvent, error) {
+	event := Event{
+		ID:        uuid.New(),
+		Type:      eventType,
+		CreatedAt: now.UTC(),
+		Data:      EventData{Subscription: sub},
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return &models.OutboxEvent{
+		EventID:   event.ID,
+		TenantID:  sub.TenantID,
+		Type:      eventType,
+		Payload:   string(payload),
+		CreatedAt: event.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,33 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// Headers set on every delivery.
+const (
+	// SignatureHeader carries "t=<unix seconds>,v1=<hex HMAC-SHA256>". The
+	// HMAC is keyed with the endpoint secret and computed over the timestamp,
+	// a dot and the raw body.
+	SignatureHeader = "Webhook-Signature"
+	EventHeader     = "Webhook-Event"
+	DeliveryHeader  = "Webhook-Delivery"
+)
+
+// Sign returns the SignatureHeader value of body sent at t.
+func Sign(secret string, t time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + timestamp + ",v1=" + mac(secret, timestamp, body)
+}
+
+func mac(secret, timestamp string, body []byte) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte(timestamp))
+	m.Write([]byte("."))
+	m.Write(body)
+	return hex.EncodeToString(m.Sum(nil))
+}
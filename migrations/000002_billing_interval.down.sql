@@ -0,0 +1,3 @@
+ALTER TABLE subscriptions
+    DROP COLUMN interval_count,
+    DROP COLUMN billing_interval;
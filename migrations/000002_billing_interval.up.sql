@@ -0,0 +1,8 @@
+ALTER TABLE subscriptions
+    ADD COLUMN billing_interval TEXT NOT NULL DEFAULT 'month',
+    ADD COLUMN interval_count INTEGER NOT NULL DEFAULT 1;
+
+ALTER TABLE subscriptions
+    ADD CONSTRAINT chk_subscriptions_billing_interval
+        CHECK (billing_interval IN ('week', 'month', 'quarter', 'year', 'once')),
+    ADD CONSTRAINT chk_subscriptions_interval_count CHECK (interval_count > 0);
@@ -0,0 +1,13 @@
+ALTER TABLE subscriptions
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'RUB';
+
+CREATE TABLE exchange_rates (
+    id BIGSERIAL PRIMARY KEY,
+    base_currency CHAR(3) NOT NULL,
+    quote_currency CHAR(3) NOT NULL,
+    rate DOUBLE PRECISION NOT NULL CHECK (rate > 0),
+    effective_date DATE NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_exchange_rates_pair_date
+    ON exchange_rates(base_currency, quote_currency, effective_date);
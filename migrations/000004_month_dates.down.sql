@@ -0,0 +1,9 @@
+ALTER TABLE subscriptions
+    DROP CONSTRAINT chk_subscriptions_end_date_month,
+    DROP CONSTRAINT chk_subscriptions_start_date_month;
+
+ALTER TABLE subscriptions
+    ALTER COLUMN start_date TYPE TIMESTAMP WITH TIME ZONE
+        USING start_date AT TIME ZONE 'UTC',
+    ALTER COLUMN end_date TYPE TIMESTAMP WITH TIME ZONE
+        USING end_date AT TIME ZONE 'UTC';
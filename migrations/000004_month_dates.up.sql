@@ -0,0 +1,9 @@
+ALTER TABLE subscriptions
+    ALTER COLUMN start_date TYPE DATE
+        USING date_trunc('month', start_date AT TIME ZONE 'UTC')::date,
+    ALTER COLUMN end_date TYPE DATE
+        USING date_trunc('month', end_date AT TIME ZONE 'UTC')::date;
+
+ALTER TABLE subscriptions
+    ADD CONSTRAINT chk_subscriptions_start_date_month CHECK (EXTRACT(DAY FROM start_date) = 1),
+    ADD CONSTRAINT chk_subscriptions_end_date_month CHECK (EXTRACT(DAY FROM end_date) = 1);
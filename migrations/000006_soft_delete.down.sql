@@ -0,0 +1,6 @@
+DELETE FROM subscriptions WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_subscriptions_deleted_at;
+
+ALTER TABLE subscriptions
+    DROP COLUMN deleted_at;
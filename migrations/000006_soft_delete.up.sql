@@ -0,0 +1,4 @@
+ALTER TABLE subscriptions
+    ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX idx_subscriptions_deleted_at ON subscriptions (deleted_at);
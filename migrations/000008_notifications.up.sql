@@ -0,0 +1,12 @@
+CREATE TABLE notifications (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id UUID NOT NULL,
+    kind TEXT NOT NULL,
+    due_date DATE NOT NULL,
+    channel TEXT NOT NULL,
+    claimed_at TIMESTAMPTZ NOT NULL,
+    sent_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_notifications_reminder
+    ON notifications(subscription_id, kind, due_date, channel);
@@ -0,0 +1,4 @@
+DROP TABLE budgets;
+
+ALTER TABLE subscriptions
+    DROP COLUMN category;
@@ -0,0 +1,14 @@
+ALTER TABLE subscriptions
+    ADD COLUMN category TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE budgets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    category TEXT NOT NULL DEFAULT '',
+    amount INTEGER NOT NULL,
+    currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_budgets_user_category ON budgets (user_id, category);
@@ -0,0 +1,28 @@
+ALTER TABLE api_keys NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE api_keys DISABLE ROW LEVEL SECURITY;
+ALTER TABLE outbox_events NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE outbox_events DISABLE ROW LEVEL SECURITY;
+ALTER TABLE webhook_endpoints NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE webhook_endpoints DISABLE ROW LEVEL SECURITY;
+ALTER TABLE budgets NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE budgets DISABLE ROW LEVEL SECURITY;
+ALTER TABLE subscriptions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE subscriptions DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY tenant_isolation ON api_keys;
+DROP POLICY tenant_isolation ON outbox_events;
+DROP POLICY tenant_isolation ON webhook_endpoints;
+DROP POLICY tenant_isolation ON budgets;
+DROP POLICY tenant_isolation ON subscriptions;
+
+ALTER TABLE api_keys DROP COLUMN tenant_id;
+ALTER TABLE outbox_events DROP COLUMN tenant_id;
+ALTER TABLE webhook_endpoints DROP COLUMN tenant_id;
+
+DROP INDEX idx_budgets_tenant_user_category;
+ALTER TABLE budgets DROP COLUMN tenant_id;
+CREATE UNIQUE INDEX idx_budgets_user_category ON budgets (user_id, category);
+
+ALTER TABLE subscriptions DROP COLUMN tenant_id;
+
+DROP TABLE tenants;
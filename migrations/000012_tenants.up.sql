@@ -0,0 +1,70 @@
+CREATE TABLE tenants (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    slug TEXT NOT NULL,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_tenants_slug ON tenants (slug);
+
+-- The default tenant owns every existing row and serves requests that name
+-- no tenant.
+INSERT INTO tenants (id, slug, name, created_at)
+VALUES ('00000000-0000-0000-0000-000000000001', 'default', 'Default', now());
+
+ALTER TABLE subscriptions
+    ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenants(id);
+ALTER TABLE subscriptions ALTER COLUMN tenant_id DROP DEFAULT;
+CREATE INDEX idx_subscriptions_tenant_user ON subscriptions (tenant_id, user_id);
+
+ALTER TABLE budgets
+    ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenants(id);
+ALTER TABLE budgets ALTER COLUMN tenant_id DROP DEFAULT;
+DROP INDEX idx_budgets_user_category;
+CREATE UNIQUE INDEX idx_budgets_tenant_user_category ON budgets (tenant_id, user_id, category);
+
+ALTER TABLE webhook_endpoints
+    ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenants(id);
+ALTER TABLE webhook_endpoints ALTER COLUMN tenant_id DROP DEFAULT;
+CREATE INDEX idx_webhook_endpoints_tenant ON webhook_endpoints (tenant_id);
+
+ALTER TABLE outbox_events
+    ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenants(id);
+ALTER TABLE outbox_events ALTER COLUMN tenant_id DROP DEFAULT;
+
+ALTER TABLE api_keys
+    ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenants(id);
+ALTER TABLE api_keys ALTER COLUMN tenant_id DROP DEFAULT;
+CREATE INDEX idx_api_keys_tenant ON api_keys (tenant_id);
+
+-- Row-level security confines every transaction that sets app.tenant_id to
+-- the rows of that tenant, the application's own filters notwithstanding.
+-- Background jobs and API key lookups run without the setting and see every
+-- row. FORCE applies the policies to the table owner too; superusers and
+-- roles with BYPASSRLS still bypass them.
+CREATE POLICY tenant_isolation ON subscriptions
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+        OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+CREATE POLICY tenant_isolation ON budgets
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+        OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+CREATE POLICY tenant_isolation ON webhook_endpoints
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+        OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+CREATE POLICY tenant_isolation ON outbox_events
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+        OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+CREATE POLICY tenant_isolation ON api_keys
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+        OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+
+ALTER TABLE subscriptions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscriptions FORCE ROW LEVEL SECURITY;
+ALTER TABLE budgets ENABLE ROW LEVEL SECURITY;
+ALTER TABLE budgets FORCE ROW LEVEL SECURITY;
+ALTER TABLE webhook_endpoints ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhook_endpoints FORCE ROW LEVEL SECURITY;
+ALTER TABLE outbox_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE outbox_events FORCE ROW LEVEL SECURITY;
+ALTER TABLE api_keys ENABLE ROW LEVEL SECURITY;
+ALTER TABLE api_keys FORCE ROW LEVEL SECURITY;
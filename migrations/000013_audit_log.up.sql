@@ -0,0 +1,39 @@
+CREATE TABLE audit_entries (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES tenants(id),
+    subscription_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    action TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    changes JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+-- subscription_id has no foreign key so the history outlives the purge of
+-- the subscription.
+CREATE INDEX idx_audit_entries_subscription ON audit_entries (subscription_id, id);
+CREATE INDEX idx_audit_entries_tenant_created ON audit_entries (tenant_id, created_at);
+
+-- The log is append-only: rows cannot be changed or removed, whatever the
+-- privileges of the caller.
+CREATE FUNCTION audit_entries_append_only() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_entries is append-only';
+END;
+$$;
+
+CREATE TRIGGER audit_entries_no_update_delete
+    BEFORE UPDATE OR DELETE ON audit_entries
+    FOR EACH ROW EXECUTE FUNCTION audit_entries_append_only();
+CREATE TRIGGER audit_entries_no_truncate
+    BEFORE TRUNCATE ON audit_entries
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_entries_append_only();
+
+CREATE POLICY tenant_isolation ON audit_entries
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+        OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+
+ALTER TABLE audit_entries ENABLE ROW LEVEL SECURITY;
+ALTER TABLE audit_entries FORCE ROW LEVEL SECURITY;
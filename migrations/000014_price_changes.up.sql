@@ -0,0 +1,18 @@
+CREATE TABLE price_changes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id),
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    price INTEGER NOT NULL,
+    effective_from DATE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT chk_price_changes_effective_from_month CHECK (EXTRACT(DAY FROM effective_from) = 1)
+);
+
+CREATE UNIQUE INDEX idx_price_changes_subscription_month ON price_changes (subscription_id, effective_from);
+
+CREATE POLICY tenant_isolation ON price_changes
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+        OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+
+ALTER TABLE price_changes ENABLE ROW LEVEL SECURITY;
+ALTER TABLE price_changes FORCE ROW LEVEL SECURITY;
@@ -0,0 +1,11 @@
+-- Idempotency keys are chosen by clients, so they are only unique per tenant
+-- and principal. Existing records cannot be attributed to a caller and are
+-- dropped; they would have expired within the TTL anyway.
+DELETE FROM idempotency_keys;
+
+ALTER TABLE idempotency_keys
+    ADD COLUMN tenant_id UUID NOT NULL REFERENCES tenants(id),
+    ADD COLUMN principal VARCHAR(255) NOT NULL;
+
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (tenant_id, principal, key);
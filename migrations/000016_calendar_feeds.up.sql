@@ -0,0 +1,14 @@
+CREATE TABLE calendar_feeds (
+    tenant_id UUID NOT NULL REFERENCES tenants(id),
+    user_id UUID NOT NULL,
+    nonce TEXT NOT NULL,
+    rotated_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (tenant_id, user_id)
+);
+
+CREATE POLICY tenant_isolation ON calendar_feeds
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+        OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+
+ALTER TABLE calendar_feeds ENABLE ROW LEVEL SECURITY;
+ALTER TABLE calendar_feeds FORCE ROW LEVEL SECURITY;
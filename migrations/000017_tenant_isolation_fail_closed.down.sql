@@ -0,0 +1,24 @@
+ALTER POLICY tenant_isolation ON subscriptions
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+        OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+ALTER POLICY tenant_isolation ON budgets
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+        OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+ALTER POLICY tenant_isolation ON webhook_endpoints
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+        OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+ALTER POLICY tenant_isolation ON outbox_events
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+        OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+ALTER POLICY tenant_isolation ON api_keys
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+        OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+ALTER POLICY tenant_isolation ON audit_entries
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+        OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+ALTER POLICY tenant_isolation ON price_changes
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+        OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+ALTER POLICY tenant_isolation ON calendar_feeds
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+        OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
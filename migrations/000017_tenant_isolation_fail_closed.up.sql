@@ -0,0 +1,21 @@
+-- The policies used to show every row to sessions without app.tenant_id, so
+-- a query that forgot to set it saw all tenants. They now show none: the
+-- application sets app.tenant_id for every query confined to a tenant, and
+-- background jobs and API key lookups, which work across tenants, connect as
+-- a role with BYPASSRLS.
+ALTER POLICY tenant_isolation ON subscriptions
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+ALTER POLICY tenant_isolation ON budgets
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+ALTER POLICY tenant_isolation ON webhook_endpoints
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+ALTER POLICY tenant_isolation ON outbox_events
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+ALTER POLICY tenant_isolation ON api_keys
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+ALTER POLICY tenant_isolation ON audit_entries
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+ALTER POLICY tenant_isolation ON price_changes
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+ALTER POLICY tenant_isolation ON calendar_feeds
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);